@@ -9,6 +9,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // schedule time zones on images without zoneinfo
 
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/app"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
@@ -100,7 +101,10 @@ func run() int {
 
 	logger.Info("health server listening", "addr", cfg.HealthListenAddr)
 
-	appl := app.NewApp(botAPI, cfg, client, brokerConn)
+	appl := app.NewApp(botAPI, cfg, client, brokerConn, logger)
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
 
 	done := make(chan error, 1)
 	go func() {
@@ -127,6 +131,23 @@ func run() int {
 				logger.Error("health server exit error", "error", hr.err)
 			}
 			return 0
+		case <-reload:
+			if goEnv != "prod" {
+				if err := godotenv.Overload(); err != nil {
+					logger.Error("failed to reload env", "error", err)
+					continue
+				}
+			}
+			newCfg, err := config.LoadFromEnv()
+			if err != nil {
+				logger.Error("failed to reload config", "error", err)
+				continue
+			}
+			if err := appl.Reload(newCfg); err != nil {
+				logger.Error("failed to apply config", "error", err)
+				continue
+			}
+			logger.Info("config reloaded")
 		case hr := <-healthCh:
 			if hr.err != nil {
 				logger.Error("health server failed", "error", hr.err)
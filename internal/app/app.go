@@ -3,10 +3,21 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/broker"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/chart"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/export"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/storage"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
@@ -16,33 +27,232 @@ import (
 // App wires the bot API, configuration, report fetcher, and broker connection.
 type App struct {
 	botAPI  *tgbotapi.BotAPI
+	cfgMu   sync.RWMutex
 	cfg     *config.Config
 	fetcher ports.ReportFetcher
 	rmq     *broker.Connection
+	pub     *broker.Publisher
+	rpc     *broker.RPCClient
+	logger  ports.Logger
+
+	reportUC *usecase.ReportUsecase
+	alertUC  *usecase.AlertUsecase
+	handler  *telegram.Handler
+}
+
+// ReportSource is the core API client: report totals, daily series, breakdowns, trades, rates,
+// the live portfolio and control requests.
+type ReportSource interface {
+	ports.ReportFetcher
+	ports.ReportSeriesFetcher
+	ports.ReportBreakdownFetcher
+	ports.TradeFetcher
+	ports.RateFetcher
+	ports.PortfolioFetcher
+	ports.CoreController
 }
 
 // NewApp constructs an App from its dependencies.
-func NewApp(botAPI *tgbotapi.BotAPI, cfg *config.Config, fetcher ports.ReportFetcher, rmq *broker.Connection) *App {
-	return &App{botAPI: botAPI, cfg: cfg, fetcher: fetcher, rmq: rmq}
+func NewApp(botAPI *tgbotapi.BotAPI, cfg *config.Config, fetcher ReportSource, rmq *broker.Connection, logger ports.Logger) *App {
+	a := &App{botAPI: botAPI, cfg: cfg, fetcher: fetcher, rmq: rmq, logger: logger}
+	ruc := usecase.NewReportUsecase(fetcher, fetcher, fetcher, chart.NewRenderer())
+	euc := usecase.NewExportUsecase(fetcher, fetcher, export.NewFactory(os.TempDir(), telegram.MaxDocumentBytes))
+	cuc := usecase.NewConversionUsecase(fetcher)
+	prefs := storage.NewPreferencesFile(filepath.Join(cfg.DataDir, "preferences.json"))
+	signals := storage.NewSignalFile(filepath.Join(cfg.DataDir, "signals.json"),
+		storage.WithSignalRetention(time.Duration(cfg.SignalRetentionDays)*24*time.Hour))
+	pub := broker.NewPublisher(cfg.RmqURL, broker.WithOutbox(storage.NewOutboxFile(filepath.Join(cfg.DataDir, "outbox.json"))),
+		broker.WithPublisherLogger(logger), broker.WithDropHandler(a.signalCommandDropped))
+	auc := usecase.NewAlertUsecase(storage.NewAlertFile(filepath.Join(cfg.DataDir, "alerts.json")), fetcher, fetcher,
+		usecase.WithAlertInterval(time.Duration(cfg.AlertIntervalSeconds)*time.Second),
+		usecase.WithAlertHysteresis(cfg.AlertHysteresisPct/100), usecase.WithAlertLogger(logger))
+	opts := []telegram.Option{
+		telegram.WithExport(euc), telegram.WithConversion(cuc), telegram.WithPreferences(prefs),
+		telegram.WithSignals(signals),
+		telegram.WithCriticals(storage.NewCriticalFile(filepath.Join(cfg.DataDir, "critical.json"))),
+		telegram.WithSignalStats(usecase.NewSignalUsecase(signals)),
+		telegram.WithPortfolio(usecase.NewPortfolioUsecase(fetcher)), telegram.WithAlerts(auc),
+		telegram.WithLogger(logger),
+	}
+	if cfg.SignalActionsRoutingKey != "" {
+		opts = append(opts, telegram.WithSignalActions(newSignalCommands(pub, cfg)))
+	}
+	rpc := broker.NewRPCClient(cfg.RmqURL, cfg.CoreRPCExchange, cfg.CoreRPCRoutingKey, broker.WithRPCLogger(logger))
+	var ctl ports.CoreController
+	switch cfg.ControlTransport {
+	case config.ControlHTTP:
+		ctl = fetcher
+	case config.ControlAMQP:
+		ctl = usecase.NewControlUsecase(rpc)
+	}
+	if ctl != nil {
+		audit := auditLog{file: storage.NewAuditFile(filepath.Join(cfg.DataDir, "audit.log")), logger: logger}
+		opts = append(opts, telegram.WithControl(ctl, audit, time.Duration(cfg.ControlTimeoutSeconds)*time.Second))
+	}
+	a.pub, a.rpc, a.reportUC, a.alertUC = pub, rpc, ruc, auc
+	a.handler = telegram.NewHandler(botAPI, cfg, ruc, opts...)
+	return a
 }
 
 // Run starts queue consumers and the Telegram update loop; it blocks until ctx is canceled.
 func (a *App) Run(ctx context.Context) error {
-	ruc := usecase.NewReportUsecase(a.fetcher)
-	h := telegram.NewHandler(a.botAPI, a.cfg, ruc)
+	h := a.handler
+
+	if err := h.SyncCommands(); err != nil {
+		a.logger.Error("failed to sync bot commands", "error", err)
+	}
+	if err := h.LoadTemplates(a.cfg.QueueConsumers); err != nil {
+		return fmt.Errorf("templates: %w", err)
+	}
 
 	for _, qc := range a.cfg.QueueConsumers {
-		chatID := qc.GroupChatID
-		consumer := broker.NewConsumer(a.rmq.Channel(), qc.QueueName, func(msg []byte) error {
-			return h.SendToGroup(chatID, string(msg))
-		})
+		var opts []broker.ConsumerOption
+		if qc.DeadLetterQueue != "" {
+			if err := a.rmq.DeclareQueue(qc.DeadLetterQueue); err != nil {
+				return fmt.Errorf("consumer %q: %w", qc.QueueName, err)
+			}
+			opts = append(opts, broker.WithDeadLetterQueue(qc.DeadLetterQueue))
+		}
+		consumer := broker.NewConsumer(a.rmq.Channel(), qc.QueueName, a.queueHandler(qc), opts...)
 		if err := consumer.Run(ctx); err != nil {
 			return fmt.Errorf("consumer %q: %w", qc.QueueName, err)
 		}
 	}
 
+	defer a.pub.Close()
+	a.pub.RunReplay(ctx)
+	defer a.rpc.Close()
+
+	if key := a.cfg.SignalActionsRoutingKey; key != "" && a.cfg.SignalActionsExchange == "" {
+		// Commands for the default exchange are routed to the queue named by the key.
+		if err := a.rmq.DeclareQueue(key); err != nil {
+			return fmt.Errorf("signal commands: %w", err)
+		}
+	}
+	if q := a.cfg.SignalActionsReplyQueue; q != "" && a.cfg.SignalActionsRoutingKey != "" {
+		if err := a.rmq.DeclareQueue(q); err != nil {
+			return fmt.Errorf("consumer %q: %w", q, err)
+		}
+		if err := broker.NewConsumer(a.rmq.Channel(), q, a.signalReplyHandler()).Run(ctx); err != nil {
+			return fmt.Errorf("consumer %q: %w", q, err)
+		}
+	}
+
+	if a.cfg.NotificationGroup != 0 {
+		sched, err := a.newScheduler()
+		if err != nil {
+			return fmt.Errorf("scheduler: %w", err)
+		}
+		sched.Run(ctx)
+	} else {
+		a.logger.Info("scheduler disabled: NOTIFICATION_GROUP_ID not set")
+	}
+
+	a.alertUC.Run(ctx, h)
 	h.Run(ctx)
 
 	<-ctx.Done()
 	return fmt.Errorf("context ended: %w", ctx.Err())
 }
+
+// queueHandler posts messages of qc to its group and DMs subscribers. Trading signals and
+// their lifecycle events are validated first; invalid ones are rejected to the dead-letter queue.
+// System messages are routed by severity: info is posted silently, and critical messages are
+// also paged to the admins.
+func (a *App) queueHandler(qc config.QueueConsumer) broker.HandlerFunc {
+	h := a.handler
+	return func(msg []byte) error {
+		var err error
+		if qc.Category == domain.NotifySignals {
+			err = a.postSignal(qc, msg)
+		} else {
+			n, renderErr := h.RenderNotification(qc.QueueName, msg)
+			if renderErr != nil {
+				a.logger.Error("failed to render notification, sending raw payload", "queue", qc.QueueName, "error", renderErr)
+			}
+			severity := domain.SeverityWarning
+			if qc.Category == domain.NotifySystem {
+				severity = domain.ParseSeverity(msg)
+				n.Silent = severity == domain.SeverityInfo
+			}
+			// Critical messages are paged whether or not the group post succeeds; a redelivery
+			// of the same payload is not paged again.
+			var pageErr error
+			if severity == domain.SeverityCritical {
+				pageErr = h.PageCritical(criticalKey(qc.QueueName, msg), n)
+			}
+			// A group post cut short after its first part still counts as posted.
+			if err = h.SendNotification(qc.GroupChatID, n); err == nil || errors.Is(err, telegram.ErrPartialDelivery) {
+				dmErr := errors.Join(err, h.NotifySubscribers(qc.Category, n), pageErr)
+				if dmErr != nil {
+					err = fmt.Errorf("%w: %w", telegram.ErrPartialDelivery, dmErr)
+				}
+			} else if pageErr != nil {
+				a.logger.Error("failed to page critical message", "queue", qc.QueueName, "error", pageErr)
+			}
+		}
+		return a.queueResult(qc.QueueName, err)
+	}
+}
+
+// criticalKey identifies a critical message by its queue and payload.
+func criticalKey(queue string, msg []byte) string {
+	sum := sha256.Sum256(msg)
+	return queue + ":" + hex.EncodeToString(sum[:])
+}
+
+// queueResult maps the error of handling a message from queue to the broker's outcome:
+// invalid messages are rejected and partial deliveries are logged but not redelivered.
+func (a *App) queueResult(queue string, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSignal):
+		a.logger.Error("rejected queue message", "queue", queue, "error", err)
+		return broker.Reject(err)
+	case errors.Is(err, telegram.ErrPartialDelivery):
+		// DMs and card edits are best effort: failing them would redeliver the message to the group.
+		a.logger.Error("failed to notify subscribers", "queue", queue, "error", err)
+		return nil
+	}
+	return err
+}
+
+// postSignal posts a new signal or a lifecycle event of one.
+func (a *App) postSignal(qc config.QueueConsumer, msg []byte) error {
+	if domain.IsSignalEvent(msg) {
+		ev, err := domain.ParseSignalEvent(msg)
+		if err != nil {
+			return err
+		}
+		return a.handler.PostSignalEvent(qc.GroupChatID, qc.QueueName, ev)
+	}
+	sig, err := domain.ParseSignal(msg)
+	if err != nil {
+		return err
+	}
+	return a.handler.PostSignal(qc.GroupChatID, qc.QueueName, sig, msg)
+}
+
+// config returns the configuration last applied by Reload.
+func (a *App) config() *config.Config {
+	a.cfgMu.RLock()
+	defer a.cfgMu.RUnlock()
+	return a.cfg
+}
+
+// Reload applies a freshly loaded configuration to running components and re-syncs bot commands.
+// Queue bindings and job schedules are fixed at startup and are not rebuilt, but digests read
+// their accounts and group from the new configuration. Notification templates are re-read
+// first: if any of them fails validation, nothing is applied.
+func (a *App) Reload(cfg *config.Config) error {
+	if err := a.handler.LoadTemplates(cfg.QueueConsumers); err != nil {
+		return fmt.Errorf("templates: %w", err)
+	}
+	a.cfgMu.Lock()
+	a.cfg = cfg
+	a.cfgMu.Unlock()
+	a.handler.UpdateConfig(cfg)
+	if err := a.handler.SyncCommands(); err != nil {
+		return fmt.Errorf("sync commands: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/broker"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Sync() error          { return nil }
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+func (nopLogger) Debug(string, ...any) {}
+
+func TestApp_queueHandler_rejectsInvalidSignals(t *testing.T) {
+	cfg := &config.Config{}
+	a := &App{cfg: cfg, handler: telegram.NewHandler(nil, cfg, nil), logger: nopLogger{}}
+	handle := a.queueHandler(config.QueueConsumer{QueueName: "signals", Category: domain.NotifySignals, GroupChatID: -100})
+
+	for _, msg := range []string{`{"symbol":"BTC","side":"long"}`, `not json`} {
+		err := handle([]byte(msg))
+		var rej *broker.RejectError
+		require.ErrorAs(t, err, &rej, msg)
+		require.ErrorIs(t, err, domain.ErrInvalidSignal, msg)
+	}
+}
+
+func TestApp_Reload_invalidTemplate(t *testing.T) {
+	cfg := &config.Config{}
+	a := &App{cfg: cfg, handler: telegram.NewHandler(nil, cfg, nil), logger: nopLogger{}}
+	next := &config.Config{NotificationGroup: -200, QueueConsumers: []config.QueueConsumer{
+		{QueueName: "signals", Template: filepath.Join(t.TempDir(), "missing.html")},
+	}}
+
+	require.ErrorContains(t, a.Reload(next), "templates: template for signals")
+	require.Same(t, cfg, a.config(), "a rejected reload applies nothing")
+}
+
+func TestReportJobs_titles(t *testing.T) {
+	c := i18n.Default()
+	for name, job := range reportJobs {
+		for _, lang := range c.Locales() {
+			require.NotEqual(t, job.titleKey, c.Localizer(lang).T(job.titleKey), "%s in %s", name, lang)
+		}
+	}
+}
+
+func TestApp_queueResult(t *testing.T) {
+	a := &App{logger: nopLogger{}}
+	require.NoError(t, a.queueResult("q", nil))
+	require.NoError(t, a.queueResult("q", fmt.Errorf("%w: dm 7: blocked", telegram.ErrPartialDelivery)))
+
+	transient := errors.New("telegram: timeout")
+	err := a.queueResult("q", transient)
+	require.Equal(t, transient, err, "transient failures are redelivered")
+	var rej *broker.RejectError
+	require.False(t, errors.As(err, &rej))
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// auditLog records audit entries in a file and mirrors them to the process log, so they are
+// also collected when the data directory is not.
+type auditLog struct {
+	file   ports.AuditLog
+	logger ports.Logger
+}
+
+// Record implements ports.AuditLog.
+func (a auditLog) Record(e domain.AuditEntry) error {
+	a.logger.Info("audit", "user_id", e.UserID, "user", e.User, "command", e.Command, "request_id", e.RequestID,
+		"outcome", string(e.Outcome), "detail", e.Detail)
+	if err := a.file.Record(e); err != nil {
+		a.logger.Error("failed to write the audit log", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/scheduler"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+)
+
+// periodFunc maps an activation time to the inclusive report range it covers.
+type periodFunc func(at time.Time) (from, to string)
+
+// reportJobs are the scheduled job kinds that post a PnL digest to the notification group,
+// titled with a catalog message.
+var reportJobs = map[string]struct {
+	titleKey string
+	period   periodFunc
+}{
+	"daily_pnl":  {titleKey: "digest.daily", period: usecase.PreviousDay},
+	"weekly_pnl": {titleKey: "digest.weekly", period: usecase.PreviousWeek},
+}
+
+func (a *App) newScheduler() (*scheduler.Scheduler, error) {
+	jobs := make([]scheduler.Job, 0, len(a.cfg.ScheduledJobs))
+	for _, sj := range a.cfg.ScheduledJobs {
+		kind, ok := reportJobs[sj.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scheduled job %q", sj.Name)
+		}
+		sch, err := scheduler.Parse(sj.Cron, a.cfg.ScheduleLocation)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled job %q: %w", sj.Name, err)
+		}
+		jobs = append(jobs, scheduler.Job{
+			Name:     sj.Name,
+			Schedule: sch,
+			Run:      a.reportDigest(sj.Name, kind.titleKey, kind.period),
+		})
+	}
+	store := scheduler.NewFileStore(filepath.Join(a.cfg.DataDir, "scheduler.json"))
+	return scheduler.New(store, ports.SystemClock{}, a.logger, jobs...), nil
+}
+
+func (a *App) reportDigest(name, titleKey string, period periodFunc) scheduler.JobFunc {
+	return func(ctx context.Context, at time.Time) error {
+		cfg := a.config() // accounts and the group may have changed since startup
+		from, to := period(at)
+		p := domain.Period{From: from, To: to, Location: at.Location()}
+		var reps []domain.Report
+		if accts := cfg.AllAccounts(); len(accts) == 1 {
+			rep, err := a.reportUC.GetReport(ctx, accts[0], p)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			reps = []domain.Report{*rep}
+		} else {
+			var err error
+			if reps, err = a.reportUC.GetAggregateReport(ctx, accts, p); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if err := a.handler.SendReports(ctx, cfg.NotificationGroup, titleKey, reps); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/broker"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// signalCommands publishes signal commands as JSON to SIGNAL_ACTIONS_EXCHANGE with
+// SIGNAL_ACTIONS_ROUTING_KEY. The command ID is the message and correlation ID, replies are
+// requested on SIGNAL_ACTIONS_REPLY_QUEUE, and commands expire after SIGNAL_ACTIONS_TTL.
+type signalCommands struct {
+	pub      *broker.Publisher
+	exchange string
+	key      string
+	replyTo  string
+	ttl      time.Duration
+}
+
+func newSignalCommands(pub *broker.Publisher, cfg *config.Config) *signalCommands {
+	return &signalCommands{
+		pub:      pub,
+		exchange: cfg.SignalActionsExchange,
+		key:      cfg.SignalActionsRoutingKey,
+		replyTo:  cfg.SignalActionsReplyQueue,
+		ttl:      time.Duration(cfg.SignalActionsTTLSeconds) * time.Second,
+	}
+}
+
+// PublishSignalCommand publishes cmd and waits for the broker's confirmation; while the broker
+// is unavailable the command is kept in the outbox and the error wraps ports.ErrDeferred.
+func (s *signalCommands) PublishSignalCommand(ctx context.Context, cmd domain.SignalCommand) error {
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("marshal signal command: %w", err)
+	}
+	m := ports.OutboundMessage{
+		ID:            cmd.ID,
+		Exchange:      s.exchange,
+		RoutingKey:    s.key,
+		ContentType:   "application/json",
+		CorrelationID: cmd.ID,
+		ReplyTo:       s.replyTo,
+		Body:          body,
+		CreatedAt:     cmd.Time,
+	}
+	if s.ttl > 0 {
+		m.ExpiresAt = cmd.Time.Add(s.ttl)
+	}
+	return s.pub.Publish(ctx, m)
+}
+
+// signalCommandDropped shows a signal command the publisher dropped from its outbox on the
+// signal's cards and tells the admin who sent it.
+func (a *App) signalCommandDropped(m ports.OutboundMessage, reason error) {
+	var cmd domain.SignalCommand
+	if err := json.Unmarshal(m.Body, &cmd); err != nil || cmd.SignalID == "" {
+		a.logger.Error("dropped an outbox message that is not a signal command", "id", m.ID, "error", err)
+		return
+	}
+	why := domain.SignalCommandUnroutable
+	if errors.Is(reason, broker.ErrExpired) {
+		why = domain.SignalCommandExpired
+	}
+	if err := a.handler.SignalCommandDropped(cmd, why); err != nil {
+		a.logger.Error("failed to report a dropped signal command", "id", cmd.ID, "signal_id", cmd.SignalID, "error", err)
+	}
+}
+
+// signalReplyHandler shows the core's replies to signal commands on the signal cards.
+// Malformed replies and replies to unknown commands are rejected.
+func (a *App) signalReplyHandler() broker.HandlerFunc {
+	queue := a.cfg.SignalActionsReplyQueue
+	return func(msg []byte) error {
+		reply, err := domain.ParseSignalCommandReply(msg)
+		if err == nil {
+			err = a.handler.PostSignalCommandReply(reply)
+		}
+		return a.queueResult(queue, err)
+	}
+}
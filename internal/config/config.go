@@ -3,15 +3,35 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
 )
 
-// QueueConsumer binds a RabbitMQ queue name to a target Telegram group chat ID.
+// QueueConsumer binds a RabbitMQ queue name to a target Telegram group chat ID. Users subscribed
+// to Category also receive the messages by DM. Template is an optional path to the message
+// template for JSON payloads. ParseMode is the Telegram parse mode of messages posted as they
+// arrive: "" (plain), "MarkdownV2" or "HTML". Messages that fail validation are moved to
+// DeadLetterQueue; without one they are dropped.
 type QueueConsumer struct {
-	QueueName   string
-	GroupChatID int64
+	QueueName       string
+	GroupChatID     int64
+	Category        domain.NotificationCategory
+	Template        string
+	ParseMode       string
+	DeadLetterQueue string
+}
+
+// ScheduledJob binds a named job kind (e.g. "daily_pnl") to a five-field cron expression.
+type ScheduledJob struct {
+	Name string
+	Cron string
 }
 
 // Config holds runtime configuration for the bot process.
@@ -24,6 +44,53 @@ type Config struct {
 	RmqURL             string
 	HealthListenAddr   string
 	QueueConsumers     []QueueConsumer
+	ViewerIDs          []int64
+	Accounts           []domain.Account
+	AccountRoles       map[string][]string
+	DataDir            string
+	ScheduleLocation   *time.Location
+	ScheduledJobs      []ScheduledJob
+	NumberLocale       string
+	CurrencyDecimals   map[string]int
+	DisplayCurrency    string
+	// LongMessageMaxParts is the most parts a long message is split into before it is sent
+	// as a .txt document instead; 0 always splits.
+	LongMessageMaxParts int
+	// SignalEditCards appends the latest lifecycle status to posted signal cards.
+	SignalEditCards bool
+	// SignalRetentionDays is how long the messages of finished signals are kept in the signal
+	// index after their last event or action; 0 keeps them forever. Outcomes are always kept.
+	SignalRetentionDays int
+	// SignalActionsExchange and SignalActionsRoutingKey are where commands of the signal action
+	// buttons are published; without a routing key signals have no buttons. The core's replies
+	// are consumed from SignalActionsReplyQueue when set.
+	SignalActionsExchange   string
+	SignalActionsRoutingKey string
+	SignalActionsReplyQueue string
+	// SignalActionsTTLSeconds is how long a signal command may wait in the outbox or a queue
+	// before it is dropped as stale; 0 keeps it until delivered.
+	SignalActionsTTLSeconds int
+	// ControlTransport is how /pause, /resume and /status reach the trading core: "http" (the
+	// API at APIBaseURL), "amqp" (RPC over the broker) or "off", which hides the commands. The
+	// core must acknowledge a control request within ControlTimeoutSeconds.
+	ControlTransport      string
+	ControlTimeoutSeconds int
+	// CoreRPCExchange and CoreRPCRoutingKey are where RPC requests to the trading core are
+	// published; replies come back through direct reply-to.
+	CoreRPCExchange   string
+	CoreRPCRoutingKey string
+	// AlertIntervalSeconds is how often user alerts are evaluated. New alerts fire at most once
+	// per AlertCooldownMinutes unless the user sets their own cooldown, and re-arm once the metric
+	// is back past the threshold by AlertHysteresisPct percent of it.
+	AlertIntervalSeconds int
+	AlertCooldownMinutes int
+	AlertHysteresisPct   float64
+	// CriticalRepeatMinutes is how often critical system messages are sent again to the admins
+	// until one of them acknowledges it. After EscalationDelayMinutes unacknowledged, they also
+	// go to EscalationUserIDs.
+	CriticalRepeatMinutes  int
+	EscalationDelayMinutes int
+	EscalationUserIDs      []int64
 }
 
 // LoadFromEnv reads configuration from process environment variables.
@@ -57,7 +124,202 @@ func LoadFromEnv() (*Config, error) {
 		}
 	}
 
-	parts := strings.Split(users, ",")
+	res := parseIDs(users)
+	viewers := parseIDs(os.Getenv("VIEWER_USER_IDS"))
+
+	rmqURL := os.Getenv("RABBITMQ_URL")
+	if rmqURL == "" {
+		return nil, errors.New("RABBITMQ_URL required")
+	}
+
+	healthAddr := os.Getenv("HEALTH_LISTEN_ADDR")
+	if healthAddr == "" {
+		healthAddr = ":8080"
+	}
+
+	queueConsumers, err := loadQueueConsumers()
+	if err != nil {
+		return nil, err
+	}
+
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = "data"
+	}
+
+	tz := os.Getenv("SCHEDULE_TIMEZONE")
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("SCHEDULE_TIMEZONE: %w", err)
+	}
+
+	jobs, err := parseScheduledJobs(os.Getenv("SCHEDULED_JOBS"))
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := parseAccounts(os.Getenv("ACCOUNTS"))
+	if err != nil {
+		return nil, err
+	}
+	accountRoles, err := parseAccountRoles(os.Getenv("ACCOUNT_ROLES"), accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := os.Getenv("NUMBER_LOCALE")
+	if locale == "" {
+		locale = "en"
+	}
+	decimals, err := parseCurrencyDecimals(os.Getenv("CURRENCY_DECIMALS"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxParts := 0
+	if s := os.Getenv("LONG_MESSAGE_MAX_PARTS"); s != "" {
+		if maxParts, err = strconv.Atoi(s); err != nil || maxParts < 0 {
+			return nil, fmt.Errorf("LONG_MESSAGE_MAX_PARTS: want a non-negative integer, got %q", s)
+		}
+	}
+
+	editCards := false
+	if s := os.Getenv("SIGNAL_EDIT_CARDS"); s != "" {
+		if editCards, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("SIGNAL_EDIT_CARDS: %w", err)
+		}
+	}
+	signalActionsTTL := 300
+	if s := os.Getenv("SIGNAL_ACTIONS_TTL"); s != "" {
+		if signalActionsTTL, err = strconv.Atoi(s); err != nil || signalActionsTTL < 0 {
+			return nil, fmt.Errorf("SIGNAL_ACTIONS_TTL: want a non-negative number of seconds, got %q", s)
+		}
+	}
+	signalRetention := 90
+	if s := os.Getenv("SIGNAL_RETENTION_DAYS"); s != "" {
+		if signalRetention, err = strconv.Atoi(s); err != nil || signalRetention < 0 {
+			return nil, fmt.Errorf("SIGNAL_RETENTION_DAYS: want a non-negative number of days, got %q", s)
+		}
+	}
+
+	control, err := parseControlTransport(os.Getenv("CONTROL_TRANSPORT"))
+	if err != nil {
+		return nil, err
+	}
+	controlTimeout := 10
+	if s := os.Getenv("CONTROL_TIMEOUT"); s != "" {
+		if controlTimeout, err = strconv.Atoi(s); err != nil || controlTimeout <= 0 {
+			return nil, fmt.Errorf("CONTROL_TIMEOUT: want a positive number of seconds, got %q", s)
+		}
+	}
+
+	alertInterval := 60
+	if s := os.Getenv("ALERT_INTERVAL"); s != "" {
+		if alertInterval, err = strconv.Atoi(s); err != nil || alertInterval <= 0 {
+			return nil, fmt.Errorf("ALERT_INTERVAL: want a positive number of seconds, got %q", s)
+		}
+	}
+	alertCooldown := 60
+	if s := os.Getenv("ALERT_COOLDOWN"); s != "" {
+		if alertCooldown, err = strconv.Atoi(s); err != nil || alertCooldown < 0 {
+			return nil, fmt.Errorf("ALERT_COOLDOWN: want a non-negative number of minutes, got %q", s)
+		}
+	}
+	alertHysteresis := 10.0
+	if s := os.Getenv("ALERT_HYSTERESIS"); s != "" {
+		if alertHysteresis, err = strconv.ParseFloat(s, 64); err != nil || alertHysteresis < 0 || alertHysteresis > 100 {
+			return nil, fmt.Errorf("ALERT_HYSTERESIS: want a percentage from 0 to 100, got %q", s)
+		}
+	}
+
+	criticalRepeat := 5
+	if s := os.Getenv("CRITICAL_REPEAT"); s != "" {
+		if criticalRepeat, err = strconv.Atoi(s); err != nil || criticalRepeat <= 0 {
+			return nil, fmt.Errorf("CRITICAL_REPEAT: want a positive number of minutes, got %q", s)
+		}
+	}
+	escalationDelay := 15
+	if s := os.Getenv("ESCALATION_DELAY"); s != "" {
+		if escalationDelay, err = strconv.Atoi(s); err != nil || escalationDelay <= 0 {
+			return nil, fmt.Errorf("ESCALATION_DELAY: want a positive number of minutes, got %q", s)
+		}
+	}
+
+	rpcKey := os.Getenv("CORE_RPC_ROUTING_KEY")
+	if rpcKey == "" {
+		rpcKey = "core.rpc"
+	}
+
+	return &Config{
+		BotToken:            bot,
+		UserIDs:             res,
+		ViewerIDs:           viewers,
+		NotificationGroup:   groupID,
+		APIBaseURL:          api,
+		HTTPTimeoutSeconds:  timeout,
+		RmqURL:              rmqURL,
+		HealthListenAddr:    healthAddr,
+		QueueConsumers:      queueConsumers,
+		DataDir:             dataDir,
+		ScheduleLocation:    loc,
+		ScheduledJobs:       jobs,
+		Accounts:            accounts,
+		AccountRoles:        accountRoles,
+		NumberLocale:        locale,
+		CurrencyDecimals:    decimals,
+		DisplayCurrency:     strings.ToUpper(strings.TrimSpace(os.Getenv("DISPLAY_CURRENCY"))),
+		LongMessageMaxParts: maxParts,
+		SignalEditCards:     editCards,
+		SignalRetentionDays: signalRetention,
+
+		SignalActionsExchange:   os.Getenv("SIGNAL_ACTIONS_EXCHANGE"),
+		SignalActionsRoutingKey: os.Getenv("SIGNAL_ACTIONS_ROUTING_KEY"),
+		SignalActionsReplyQueue: os.Getenv("SIGNAL_ACTIONS_REPLY_QUEUE"),
+		SignalActionsTTLSeconds: signalActionsTTL,
+
+		ControlTransport:      control,
+		ControlTimeoutSeconds: controlTimeout,
+		CoreRPCExchange:       os.Getenv("CORE_RPC_EXCHANGE"),
+		CoreRPCRoutingKey:     rpcKey,
+
+		AlertIntervalSeconds: alertInterval,
+		AlertCooldownMinutes: alertCooldown,
+		AlertHysteresisPct:   alertHysteresis,
+
+		CriticalRepeatMinutes:  criticalRepeat,
+		EscalationDelayMinutes: escalationDelay,
+		EscalationUserIDs:      parseIDs(os.Getenv("ESCALATION_USER_IDS")),
+	}, nil
+}
+
+// AllAccounts returns the configured accounts, or the core's default account when none are configured.
+func (c *Config) AllAccounts() []domain.Account {
+	if len(c.Accounts) == 0 {
+		return []domain.Account{{}}
+	}
+	return c.Accounts
+}
+
+// AccountsForRole returns the accounts role may see; roles without a restriction see every account.
+func (c *Config) AccountsForRole(role string) []domain.Account {
+	ids, ok := c.AccountRoles[role]
+	if !ok {
+		return c.AllAccounts()
+	}
+	var out []domain.Account
+	for _, a := range c.AllAccounts() {
+		if slices.Contains(ids, a.ID) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func parseIDs(s string) []int64 {
+	parts := strings.Split(s, ",")
 	res := make([]int64, 0, len(parts))
 	for _, p := range parts {
 		p = strings.TrimSpace(p)
@@ -68,54 +330,187 @@ func LoadFromEnv() (*Config, error) {
 			res = append(res, v)
 		}
 	}
+	return res
+}
 
-	rmqURL := os.Getenv("RABBITMQ_URL")
-	if rmqURL == "" {
-		return nil, errors.New("RABBITMQ_URL required")
+// accountIDPattern keeps account IDs short enough for Telegram's 64-byte callback data.
+var accountIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,16}$`)
+
+// parseAccounts parses "id:exchange:quote,..."; exchange and quote are optional.
+func parseAccounts(s string) ([]domain.Account, error) {
+	var out []domain.Account
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		f := strings.Split(entry, ":")
+		if len(f) > 3 || !accountIDPattern.MatchString(f[0]) {
+			return nil, fmt.Errorf("ACCOUNTS: bad entry %q", entry)
+		}
+		a := domain.Account{ID: f[0]}
+		if len(f) > 1 {
+			a.Exchange = strings.TrimSpace(f[1])
+		}
+		if len(f) > 2 {
+			a.Quote = strings.ToUpper(strings.TrimSpace(f[2]))
+		}
+		if slices.ContainsFunc(out, func(o domain.Account) bool { return o.ID == a.ID }) {
+			return nil, fmt.Errorf("ACCOUNTS: duplicate account %q", a.ID)
+		}
+		out = append(out, a)
 	}
+	return out, nil
+}
 
-	healthAddr := os.Getenv("HEALTH_LISTEN_ADDR")
-	if healthAddr == "" {
-		healthAddr = ":8080"
+// parseCurrencyDecimals parses "TICKER=decimals,..." (e.g. "BTC=8,ETH=6") into a map keyed by upper-case ticker.
+func parseCurrencyDecimals(s string) (map[string]int, error) {
+	out := map[string]int{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ticker, d, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(d))
+		if !ok || strings.TrimSpace(ticker) == "" || err != nil || n < 0 || n > 8 {
+			return nil, fmt.Errorf("CURRENCY_DECIMALS: bad entry %q", entry)
+		}
+		out[strings.ToUpper(strings.TrimSpace(ticker))] = n
 	}
+	return out, nil
+}
 
-	queueConsumers := loadQueueConsumers()
+// parseAccountRoles parses "role=id,id;role=*" into role → visible account IDs; "*" lifts the restriction.
+func parseAccountRoles(s string, accounts []domain.Account) (map[string][]string, error) {
+	out := map[string][]string{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, list, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("ACCOUNT_ROLES: bad entry %q", entry)
+		}
+		if strings.TrimSpace(list) == "*" {
+			continue
+		}
+		ids := []string{}
+		for _, id := range strings.Split(list, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			if !slices.ContainsFunc(accounts, func(a domain.Account) bool { return a.ID == id }) {
+				return nil, fmt.Errorf("ACCOUNT_ROLES: unknown account %q for role %q", id, role)
+			}
+			ids = append(ids, id)
+		}
+		out[role] = ids
+	}
+	return out, nil
+}
 
-	return &Config{
-		BotToken:           bot,
-		UserIDs:            res,
-		NotificationGroup:  groupID,
-		APIBaseURL:         api,
-		HTTPTimeoutSeconds: timeout,
-		RmqURL:             rmqURL,
-		HealthListenAddr:   healthAddr,
-		QueueConsumers:     queueConsumers,
-	}, nil
+const defaultScheduledJobs = "daily_pnl=0 8 * * *;weekly_pnl=0 8 * * 1"
+
+// parseScheduledJobs parses "name=cron;name=cron"; an unset value yields the defaults and "off" disables all jobs.
+func parseScheduledJobs(s string) ([]ScheduledJob, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		s = defaultScheduledJobs
+	}
+	if s == "off" {
+		return nil, nil
+	}
+	var out []ScheduledJob
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, cron, ok := strings.Cut(entry, "=")
+		name, cron = strings.TrimSpace(name), strings.TrimSpace(cron)
+		if !ok || name == "" || cron == "" {
+			return nil, fmt.Errorf("SCHEDULED_JOBS: bad entry %q", entry)
+		}
+		out = append(out, ScheduledJob{Name: name, Cron: cron})
+	}
+	return out, nil
 }
 
-func loadQueueConsumers() []QueueConsumer {
+func loadQueueConsumers() ([]QueueConsumer, error) {
 	defaults := []struct {
-		queueEnv, groupEnv string
-		queueDefault       string
-		groupDefault       int64
+		prefix       string // env prefix: <prefix>_QUEUE, _GROUP_ID, _TEMPLATE, _PARSE_MODE, _DLQ
+		queueDefault string
+		groupDefault int64
+		category     domain.NotificationCategory
+		validated    bool // payloads are validated; invalid ones go to "<queue>.dlq" by default
 	}{
-		{"TRADING_SIGNALS_QUEUE", "TRADING_SIGNALS_GROUP_ID", "trading-signals-queue", -4603798918},
-		{"PNL_REPORTS_QUEUE", "PNL_REPORTS_GROUP_ID", "pnl-reports-queue", -5082938682},
-		{"SYSTEM_QUEUE", "SYSTEM_GROUP_ID", "system-queue", -1003283451332},
+		{"TRADING_SIGNALS", "trading-signals-queue", -4603798918, domain.NotifySignals, true},
+		{"PNL_REPORTS", "pnl-reports-queue", -5082938682, domain.NotifyReports, false},
+		{"SYSTEM", "system-queue", -1003283451332, domain.NotifySystem, false},
 	}
 	out := make([]QueueConsumer, 0, len(defaults))
 	for _, d := range defaults {
-		q := os.Getenv(d.queueEnv)
+		q := os.Getenv(d.prefix + "_QUEUE")
 		if q == "" {
 			q = d.queueDefault
 		}
 		g := d.groupDefault
-		if s := os.Getenv(d.groupEnv); s != "" {
+		if s := os.Getenv(d.prefix + "_GROUP_ID"); s != "" {
 			if v, err := strconv.ParseInt(s, 10, 64); err == nil {
 				g = v
 			}
 		}
-		out = append(out, QueueConsumer{QueueName: q, GroupChatID: g})
+		mode, err := parseParseMode(os.Getenv(d.prefix + "_PARSE_MODE"))
+		if err != nil {
+			return nil, fmt.Errorf("%s_PARSE_MODE: %w", d.prefix, err)
+		}
+		dlq := os.Getenv(d.prefix + "_DLQ")
+		if dlq == "" && d.validated {
+			dlq = q + ".dlq"
+		}
+		out = append(out, QueueConsumer{
+			QueueName:       q,
+			GroupChatID:     g,
+			Category:        d.category,
+			Template:        os.Getenv(d.prefix + "_TEMPLATE"),
+			ParseMode:       mode,
+			DeadLetterQueue: dlq,
+		})
 	}
-	return out
+	return out, nil
+}
+
+// Control transports; see Config.ControlTransport.
+const (
+	ControlOff  = "off"
+	ControlHTTP = "http"
+	ControlAMQP = "amqp"
+)
+
+// parseControlTransport validates CONTROL_TRANSPORT; unset means ControlHTTP.
+func parseControlTransport(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "":
+		return ControlHTTP, nil
+	case ControlOff, ControlHTTP, ControlAMQP:
+		return s, nil
+	}
+	return "", fmt.Errorf("CONTROL_TRANSPORT: unknown transport %q (want http, amqp or off)", s)
+}
+
+// parseParseMode normalizes a parse mode name to the Bot API spelling.
+func parseParseMode(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "plain":
+		return "", nil
+	case "markdownv2":
+		return "MarkdownV2", nil
+	case "html":
+		return "HTML", nil
+	}
+	return "", fmt.Errorf("unknown parse mode %q (want plain, MarkdownV2 or HTML)", s)
 }
@@ -3,13 +3,15 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
 	"github.com/stretchr/testify/require"
 )
 
 func TestLoadFromEnv(t *testing.T) {
 	save := map[string]string{}
-	for _, k := range []string{"BOT_TOKEN", "ADMIN_USER_IDS", "RABBITMQ_URL", "API_BASE_URL", "HTTP_TIMEOUT", "NOTIFICATION_GROUP_ID"} {
+	for _, k := range []string{"BOT_TOKEN", "ADMIN_USER_IDS", "RABBITMQ_URL", "API_BASE_URL", "HTTP_TIMEOUT", "NOTIFICATION_GROUP_ID", "SCHEDULE_TIMEZONE", "SCHEDULED_JOBS", "DATA_DIR", "VIEWER_USER_IDS", "ACCOUNTS", "ACCOUNT_ROLES", "NUMBER_LOCALE", "CURRENCY_DECIMALS", "DISPLAY_CURRENCY", "LONG_MESSAGE_MAX_PARTS", "SIGNAL_EDIT_CARDS", "SIGNAL_RETENTION_DAYS", "SIGNAL_ACTIONS_EXCHANGE", "SIGNAL_ACTIONS_ROUTING_KEY", "SIGNAL_ACTIONS_REPLY_QUEUE", "SIGNAL_ACTIONS_TTL", "CONTROL_TRANSPORT", "CONTROL_TIMEOUT", "CORE_RPC_EXCHANGE", "CORE_RPC_ROUTING_KEY", "ALERT_INTERVAL", "ALERT_COOLDOWN", "ALERT_HYSTERESIS", "CRITICAL_REPEAT", "ESCALATION_DELAY", "ESCALATION_USER_IDS"} {
 		save[k] = os.Getenv(k)
 	}
 	t.Cleanup(func() {
@@ -56,6 +58,32 @@ func TestLoadFromEnv(t *testing.T) {
 		require.NoError(t, os.Unsetenv("API_BASE_URL"))
 		require.NoError(t, os.Unsetenv("HTTP_TIMEOUT"))
 		require.NoError(t, os.Unsetenv("NOTIFICATION_GROUP_ID"))
+		require.NoError(t, os.Unsetenv("SCHEDULE_TIMEZONE"))
+		require.NoError(t, os.Unsetenv("SCHEDULED_JOBS"))
+		require.NoError(t, os.Unsetenv("DATA_DIR"))
+		require.NoError(t, os.Unsetenv("VIEWER_USER_IDS"))
+		require.NoError(t, os.Unsetenv("ACCOUNTS"))
+		require.NoError(t, os.Unsetenv("ACCOUNT_ROLES"))
+		require.NoError(t, os.Unsetenv("NUMBER_LOCALE"))
+		require.NoError(t, os.Unsetenv("CURRENCY_DECIMALS"))
+		require.NoError(t, os.Unsetenv("DISPLAY_CURRENCY"))
+		require.NoError(t, os.Unsetenv("LONG_MESSAGE_MAX_PARTS"))
+		require.NoError(t, os.Unsetenv("SIGNAL_EDIT_CARDS"))
+		require.NoError(t, os.Unsetenv("SIGNAL_RETENTION_DAYS"))
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_EXCHANGE"))
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_ROUTING_KEY"))
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_REPLY_QUEUE"))
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_TTL"))
+		require.NoError(t, os.Unsetenv("CONTROL_TRANSPORT"))
+		require.NoError(t, os.Unsetenv("CONTROL_TIMEOUT"))
+		require.NoError(t, os.Unsetenv("CORE_RPC_EXCHANGE"))
+		require.NoError(t, os.Unsetenv("CORE_RPC_ROUTING_KEY"))
+		require.NoError(t, os.Unsetenv("ALERT_INTERVAL"))
+		require.NoError(t, os.Unsetenv("ALERT_COOLDOWN"))
+		require.NoError(t, os.Unsetenv("ALERT_HYSTERESIS"))
+		require.NoError(t, os.Unsetenv("CRITICAL_REPEAT"))
+		require.NoError(t, os.Unsetenv("ESCALATION_DELAY"))
+		require.NoError(t, os.Unsetenv("ESCALATION_USER_IDS"))
 
 		cfg, err := LoadFromEnv()
 		require.NoError(t, err)
@@ -68,6 +96,35 @@ func TestLoadFromEnv(t *testing.T) {
 		require.Equal(t, "trading-signals-queue", cfg.QueueConsumers[0].QueueName)
 		require.Equal(t, "pnl-reports-queue", cfg.QueueConsumers[1].QueueName)
 		require.Equal(t, "system-queue", cfg.QueueConsumers[2].QueueName)
+		require.Equal(t, domain.NotifySystem, cfg.QueueConsumers[2].Category)
+		require.Equal(t, "data", cfg.DataDir)
+		require.Equal(t, time.UTC, cfg.ScheduleLocation)
+		require.Equal(t, []ScheduledJob{
+			{Name: "daily_pnl", Cron: "0 8 * * *"},
+			{Name: "weekly_pnl", Cron: "0 8 * * 1"},
+		}, cfg.ScheduledJobs)
+		require.Empty(t, cfg.ViewerIDs)
+		require.Equal(t, []domain.Account{{}}, cfg.AllAccounts())
+		require.Equal(t, []domain.Account{{}}, cfg.AccountsForRole("viewer"))
+		require.Equal(t, "en", cfg.NumberLocale)
+		require.Empty(t, cfg.CurrencyDecimals)
+		require.Empty(t, cfg.DisplayCurrency)
+		require.Zero(t, cfg.LongMessageMaxParts)
+		require.False(t, cfg.SignalEditCards)
+		require.Equal(t, 90, cfg.SignalRetentionDays)
+		require.Empty(t, cfg.SignalActionsRoutingKey)
+		require.Empty(t, cfg.SignalActionsReplyQueue)
+		require.Equal(t, 300, cfg.SignalActionsTTLSeconds)
+		require.Equal(t, ControlHTTP, cfg.ControlTransport)
+		require.Equal(t, 10, cfg.ControlTimeoutSeconds)
+		require.Empty(t, cfg.CoreRPCExchange)
+		require.Equal(t, "core.rpc", cfg.CoreRPCRoutingKey)
+		require.Equal(t, 60, cfg.AlertIntervalSeconds)
+		require.Equal(t, 60, cfg.AlertCooldownMinutes)
+		require.InDelta(t, 10.0, cfg.AlertHysteresisPct, 1e-9)
+		require.Equal(t, 5, cfg.CriticalRepeatMinutes)
+		require.Equal(t, 15, cfg.EscalationDelayMinutes)
+		require.Empty(t, cfg.EscalationUserIDs)
 	})
 
 	t.Run("optional env overrides", func(t *testing.T) {
@@ -83,5 +140,234 @@ func TestLoadFromEnv(t *testing.T) {
 		require.Equal(t, "https://api.example.com", cfg.APIBaseURL)
 		require.Equal(t, 30, cfg.HTTPTimeoutSeconds)
 		require.Equal(t, int64(-999), cfg.NotificationGroup)
+
+		require.NoError(t, os.Setenv("LONG_MESSAGE_MAX_PARTS", "3"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, 3, cfg.LongMessageMaxParts)
+
+		require.NoError(t, os.Setenv("LONG_MESSAGE_MAX_PARTS", "-1"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "LONG_MESSAGE_MAX_PARTS")
+		require.NoError(t, os.Unsetenv("LONG_MESSAGE_MAX_PARTS"))
+
+		require.NoError(t, os.Setenv("SIGNAL_EDIT_CARDS", "true"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.True(t, cfg.SignalEditCards)
+
+		require.NoError(t, os.Setenv("SIGNAL_EDIT_CARDS", "maybe"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "SIGNAL_EDIT_CARDS")
+		require.NoError(t, os.Unsetenv("SIGNAL_EDIT_CARDS"))
+
+		require.NoError(t, os.Setenv("SIGNAL_RETENTION_DAYS", "0"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.Zero(t, cfg.SignalRetentionDays)
+
+		require.NoError(t, os.Setenv("SIGNAL_RETENTION_DAYS", "-1"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "SIGNAL_RETENTION_DAYS")
+		require.NoError(t, os.Unsetenv("SIGNAL_RETENTION_DAYS"))
+
+		require.NoError(t, os.Setenv("SIGNAL_ACTIONS_EXCHANGE", "core"))
+		require.NoError(t, os.Setenv("SIGNAL_ACTIONS_ROUTING_KEY", "signal.actions"))
+		require.NoError(t, os.Setenv("SIGNAL_ACTIONS_REPLY_QUEUE", "signal.actions.replies"))
+		require.NoError(t, os.Setenv("SIGNAL_ACTIONS_TTL", "0"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, "core", cfg.SignalActionsExchange)
+		require.Equal(t, "signal.actions", cfg.SignalActionsRoutingKey)
+		require.Equal(t, "signal.actions.replies", cfg.SignalActionsReplyQueue)
+		require.Zero(t, cfg.SignalActionsTTLSeconds)
+		require.NoError(t, os.Setenv("SIGNAL_ACTIONS_TTL", "soon"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "SIGNAL_ACTIONS_TTL")
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_TTL"))
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_EXCHANGE"))
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_ROUTING_KEY"))
+		require.NoError(t, os.Unsetenv("SIGNAL_ACTIONS_REPLY_QUEUE"))
+
+		require.NoError(t, os.Setenv("CONTROL_TRANSPORT", " Off "))
+		require.NoError(t, os.Setenv("CONTROL_TIMEOUT", "5"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, ControlOff, cfg.ControlTransport)
+		require.Equal(t, 5, cfg.ControlTimeoutSeconds)
+
+		require.NoError(t, os.Setenv("CONTROL_TRANSPORT", "smoke-signals"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "CONTROL_TRANSPORT")
+		require.NoError(t, os.Setenv("CONTROL_TRANSPORT", "http"))
+		require.NoError(t, os.Setenv("CONTROL_TIMEOUT", "0"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "CONTROL_TIMEOUT")
+		require.NoError(t, os.Setenv("CONTROL_TRANSPORT", "AMQP"))
+		require.NoError(t, os.Unsetenv("CONTROL_TIMEOUT"))
+		require.NoError(t, os.Setenv("CORE_RPC_EXCHANGE", "core"))
+		require.NoError(t, os.Setenv("CORE_RPC_ROUTING_KEY", "rpc.control"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, ControlAMQP, cfg.ControlTransport)
+		require.Equal(t, "core", cfg.CoreRPCExchange)
+		require.Equal(t, "rpc.control", cfg.CoreRPCRoutingKey)
+		require.NoError(t, os.Unsetenv("CONTROL_TRANSPORT"))
+		require.NoError(t, os.Unsetenv("CORE_RPC_EXCHANGE"))
+		require.NoError(t, os.Unsetenv("CORE_RPC_ROUTING_KEY"))
+
+		require.NoError(t, os.Setenv("ALERT_INTERVAL", "15"))
+		require.NoError(t, os.Setenv("ALERT_COOLDOWN", "0"))
+		require.NoError(t, os.Setenv("ALERT_HYSTERESIS", "2.5"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, 15, cfg.AlertIntervalSeconds)
+		require.Equal(t, 0, cfg.AlertCooldownMinutes)
+		require.InDelta(t, 2.5, cfg.AlertHysteresisPct, 1e-9)
+		for k, v := range map[string]string{"ALERT_INTERVAL": "0", "ALERT_COOLDOWN": "-1", "ALERT_HYSTERESIS": "150"} {
+			require.NoError(t, os.Setenv(k, v))
+			_, err = LoadFromEnv()
+			require.ErrorContains(t, err, k)
+			require.NoError(t, os.Unsetenv(k))
+		}
+	})
+
+	t.Run("escalation settings", func(t *testing.T) {
+		require.NoError(t, os.Setenv("BOT_TOKEN", "t"))
+		require.NoError(t, os.Setenv("ADMIN_USER_IDS", "1"))
+		require.NoError(t, os.Setenv("RABBITMQ_URL", "amqp://x"))
+		require.NoError(t, os.Setenv("CRITICAL_REPEAT", "2"))
+		require.NoError(t, os.Setenv("ESCALATION_DELAY", "30"))
+		require.NoError(t, os.Setenv("ESCALATION_USER_IDS", "7, 8"))
+		cfg, err := LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, 2, cfg.CriticalRepeatMinutes)
+		require.Equal(t, 30, cfg.EscalationDelayMinutes)
+		require.Equal(t, []int64{7, 8}, cfg.EscalationUserIDs)
+		for k, v := range map[string]string{"CRITICAL_REPEAT": "0", "ESCALATION_DELAY": "x"} {
+			require.NoError(t, os.Setenv(k, v))
+			_, err = LoadFromEnv()
+			require.ErrorContains(t, err, k)
+			require.NoError(t, os.Unsetenv(k))
+		}
+		require.NoError(t, os.Unsetenv("ESCALATION_USER_IDS"))
 	})
+
+	t.Run("schedule settings", func(t *testing.T) {
+		require.NoError(t, os.Setenv("BOT_TOKEN", "t"))
+		require.NoError(t, os.Setenv("ADMIN_USER_IDS", "1"))
+		require.NoError(t, os.Setenv("RABBITMQ_URL", "amqp://x"))
+		require.NoError(t, os.Setenv("SCHEDULE_TIMEZONE", "Europe/Warsaw"))
+		require.NoError(t, os.Setenv("SCHEDULED_JOBS", " daily_pnl = 30 7 * * * ; "))
+
+		cfg, err := LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, "Europe/Warsaw", cfg.ScheduleLocation.String())
+		require.Equal(t, []ScheduledJob{{Name: "daily_pnl", Cron: "30 7 * * *"}}, cfg.ScheduledJobs)
+
+		require.NoError(t, os.Setenv("SCHEDULED_JOBS", "off"))
+		cfg, err = LoadFromEnv()
+		require.NoError(t, err)
+		require.Empty(t, cfg.ScheduledJobs)
+
+		require.NoError(t, os.Setenv("SCHEDULED_JOBS", "daily_pnl"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "SCHEDULED_JOBS")
+
+		require.NoError(t, os.Unsetenv("SCHEDULED_JOBS"))
+		require.NoError(t, os.Setenv("SCHEDULE_TIMEZONE", "Mars/Olympus"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "SCHEDULE_TIMEZONE")
+	})
+
+	t.Run("accounts and roles", func(t *testing.T) {
+		require.NoError(t, os.Setenv("BOT_TOKEN", "t"))
+		require.NoError(t, os.Setenv("ADMIN_USER_IDS", "1"))
+		require.NoError(t, os.Setenv("RABBITMQ_URL", "amqp://x"))
+		require.NoError(t, os.Unsetenv("SCHEDULE_TIMEZONE"))
+		require.NoError(t, os.Setenv("VIEWER_USER_IDS", "5,6"))
+		require.NoError(t, os.Setenv("ACCOUNTS", "main:binance:usdt, alt:bybit:USDC"))
+		require.NoError(t, os.Setenv("ACCOUNT_ROLES", "viewer=alt; admin=*"))
+
+		cfg, err := LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, []int64{5, 6}, cfg.ViewerIDs)
+		require.Equal(t, []domain.Account{
+			{ID: "main", Exchange: "binance", Quote: "USDT"},
+			{ID: "alt", Exchange: "bybit", Quote: "USDC"},
+		}, cfg.Accounts)
+		require.Equal(t, []domain.Account{{ID: "alt", Exchange: "bybit", Quote: "USDC"}}, cfg.AccountsForRole("viewer"))
+		require.Len(t, cfg.AccountsForRole("admin"), 2)
+
+		require.NoError(t, os.Setenv("ACCOUNT_ROLES", "viewer=ghost"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "unknown account")
+
+		require.NoError(t, os.Unsetenv("ACCOUNT_ROLES"))
+		require.NoError(t, os.Setenv("ACCOUNTS", "main,main"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "duplicate")
+
+		require.NoError(t, os.Setenv("ACCOUNTS", "a:b:c:d"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "ACCOUNTS")
+	})
+	t.Run("number formatting", func(t *testing.T) {
+		require.NoError(t, os.Setenv("BOT_TOKEN", "t"))
+		require.NoError(t, os.Setenv("ADMIN_USER_IDS", "1"))
+		require.NoError(t, os.Setenv("RABBITMQ_URL", "amqp://x"))
+		require.NoError(t, os.Unsetenv("ACCOUNTS"))
+		require.NoError(t, os.Unsetenv("ACCOUNT_ROLES"))
+		require.NoError(t, os.Setenv("NUMBER_LOCALE", "uk"))
+		require.NoError(t, os.Setenv("CURRENCY_DECIMALS", "btc=8, ETH=6"))
+		require.NoError(t, os.Setenv("DISPLAY_CURRENCY", "eur"))
+
+		cfg, err := LoadFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, "uk", cfg.NumberLocale)
+		require.Equal(t, map[string]int{"BTC": 8, "ETH": 6}, cfg.CurrencyDecimals)
+		require.Equal(t, "EUR", cfg.DisplayCurrency)
+
+		require.NoError(t, os.Setenv("CURRENCY_DECIMALS", "BTC=9"))
+		_, err = LoadFromEnv()
+		require.ErrorContains(t, err, "CURRENCY_DECIMALS")
+	})
+}
+
+func TestLoadQueueConsumers_template(t *testing.T) {
+	t.Setenv("TRADING_SIGNALS_TEMPLATE", "templates/signal.html")
+	t.Setenv("SYSTEM_TEMPLATE", "")
+
+	qc, err := loadQueueConsumers()
+	require.NoError(t, err)
+	require.Equal(t, "templates/signal.html", qc[0].Template)
+	require.Equal(t, domain.NotifySignals, qc[0].Category)
+	require.Empty(t, qc[2].Template)
+}
+
+func TestLoadQueueConsumers_deadLetter(t *testing.T) {
+	t.Setenv("TRADING_SIGNALS_QUEUE", "signals")
+	t.Setenv("SYSTEM_DLQ", "system-dead")
+
+	qc, err := loadQueueConsumers()
+	require.NoError(t, err)
+	require.Equal(t, "signals.dlq", qc[0].DeadLetterQueue)
+	require.Empty(t, qc[1].DeadLetterQueue)
+	require.Equal(t, "system-dead", qc[2].DeadLetterQueue)
+}
+
+func TestLoadQueueConsumers_parseMode(t *testing.T) {
+	t.Setenv("TRADING_SIGNALS_PARSE_MODE", "markdownv2")
+	t.Setenv("SYSTEM_PARSE_MODE", "HTML")
+	t.Setenv("PNL_REPORTS_PARSE_MODE", "plain")
+
+	qc, err := loadQueueConsumers()
+	require.NoError(t, err)
+	require.Equal(t, "MarkdownV2", qc[0].ParseMode)
+	require.Empty(t, qc[1].ParseMode)
+	require.Equal(t, "HTML", qc[2].ParseMode)
+
+	t.Setenv("SYSTEM_PARSE_MODE", "Markdown")
+	_, err = loadQueueConsumers()
+	require.ErrorContains(t, err, "SYSTEM_PARSE_MODE")
 }
@@ -0,0 +1,52 @@
+package domain
+
+import "sort"
+
+// AllAccounts is the pseudo account ID selecting an aggregate over every visible account.
+const AllAccounts = "*"
+
+// Account is a trading account on an exchange; report amounts are in its quote currency.
+// The zero Account is the core's default account.
+type Account struct {
+	ID       string
+	Exchange string
+	Quote    string
+}
+
+// Label returns a short human-readable account name.
+func (a Account) Label() string {
+	switch {
+	case a.ID == "":
+		return "Default account"
+	case a.Exchange == "":
+		return a.ID
+	default:
+		return a.Exchange + " · " + a.ID
+	}
+}
+
+// AggregateByCurrency sums reports per quote currency; amounts in different currencies are never
+// added together. The result is sorted by currency and carries AllAccounts as its account.
+func AggregateByCurrency(reports []Report) []Report {
+	type sums struct {
+		rep             Report
+		income, expense Amount
+	}
+	byCur := map[string]*sums{}
+	for _, r := range reports {
+		agg, ok := byCur[r.Currency]
+		if !ok {
+			agg = &sums{rep: Report{Account: AllAccounts, Currency: r.Currency, From: r.From, To: r.To, TimeZone: r.TimeZone}}
+			byCur[r.Currency] = agg
+		}
+		agg.income += AmountOf(r.Income)
+		agg.expense += AmountOf(r.Expense)
+	}
+	out := make([]Report, 0, len(byCur))
+	for _, s := range byCur {
+		s.rep.Income, s.rep.Expense = s.income.Float64(), s.expense.Float64()
+		out = append(out, s.rep)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Currency < out[j].Currency })
+	return out
+}
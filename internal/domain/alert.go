@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrInvalidAlert marks an alert definition that fails validation.
+var ErrInvalidAlert = errors.New("invalid alert")
+
+// AlertMetric is the quantity an alert watches.
+type AlertMetric string
+
+// Alert metrics.
+const (
+	// AlertPnL is the realized PnL of the account today, in the alert's time zone; with Percent,
+	// in percent of the quote currency balance at the start of the day.
+	AlertPnL AlertMetric = "pnl"
+	// AlertUnrealized is the unrealized PnL of the open positions, or of those on Symbol.
+	AlertUnrealized AlertMetric = "upnl"
+	// AlertLoss is the unrealized loss (negated unrealized PnL) of the open positions, or of
+	// those on Symbol.
+	AlertLoss AlertMetric = "loss"
+	// AlertPrice is the mark price of Symbol, known while the account holds a position on it.
+	AlertPrice AlertMetric = "price"
+)
+
+// AlertMetrics lists every metric in help order.
+var AlertMetrics = []AlertMetric{AlertPnL, AlertUnrealized, AlertLoss, AlertPrice}
+
+// AlertOp compares a metric with the threshold of an alert.
+type AlertOp string
+
+// Alert comparisons.
+const (
+	AlertAbove AlertOp = ">"
+	AlertBelow AlertOp = "<"
+)
+
+// Alert notifies its user when a metric of an account crosses a threshold. It fires once when
+// the condition starts to hold and re-arms only after the metric has moved back past the
+// threshold by the hysteresis band; it never fires twice within Cooldown.
+type Alert struct {
+	ID        string        `json:"id"`
+	UserID    int64         `json:"user_id"`
+	Account   string        `json:"account,omitempty"`
+	Currency  string        `json:"currency,omitempty"`  // quote currency of the account
+	TimeZone  string        `json:"time_zone,omitempty"` // IANA zone of the PnL day; empty for UTC
+	Metric    AlertMetric   `json:"metric"`
+	Symbol    string        `json:"symbol,omitempty"`
+	Op        AlertOp       `json:"op"`
+	Threshold float64       `json:"threshold"`
+	Percent   bool          `json:"percent,omitempty"`
+	Cooldown  time.Duration `json:"cooldown"`
+	Created   time.Time     `json:"created"`
+
+	Triggered bool      `json:"triggered,omitempty"` // fired and not yet re-armed
+	LastFired time.Time `json:"last_fired,omitzero"`
+}
+
+// Validate checks the definition of a; errors wrap ErrInvalidAlert.
+func (a Alert) Validate() error {
+	switch a.Metric {
+	case AlertPnL, AlertUnrealized, AlertLoss:
+	case AlertPrice:
+		if a.Symbol == "" {
+			return fmt.Errorf("%w: price needs a symbol", ErrInvalidAlert)
+		}
+		if a.Threshold <= 0 {
+			return fmt.Errorf("%w: price must be positive", ErrInvalidAlert)
+		}
+	default:
+		return fmt.Errorf("%w: unknown metric %q", ErrInvalidAlert, a.Metric)
+	}
+	if a.Op != AlertAbove && a.Op != AlertBelow {
+		return fmt.Errorf("%w: unknown comparison %q", ErrInvalidAlert, a.Op)
+	}
+	if math.IsNaN(a.Threshold) || math.IsInf(a.Threshold, 0) {
+		return fmt.Errorf("%w: threshold must be a number", ErrInvalidAlert)
+	}
+	if a.Metric == AlertPnL && a.Symbol != "" {
+		return fmt.Errorf("%w: pnl is per account, not per symbol", ErrInvalidAlert)
+	}
+	if a.Percent && a.Metric != AlertPnL {
+		return fmt.Errorf("%w: only pnl accepts a percentage", ErrInvalidAlert)
+	}
+	if a.Percent && a.Currency == "" {
+		return fmt.Errorf("%w: a percentage needs the account's quote currency", ErrInvalidAlert)
+	}
+	if a.Cooldown < 0 {
+		return fmt.Errorf("%w: negative cooldown", ErrInvalidAlert)
+	}
+	return nil
+}
+
+// Breached reports whether value satisfies the condition of a.
+func (a Alert) Breached(value float64) bool {
+	if a.Op == AlertAbove {
+		return value > a.Threshold
+	}
+	return value < a.Threshold
+}
+
+// recovered reports whether value is back past the threshold by the hysteresis band, a
+// fraction of the threshold's magnitude.
+func (a Alert) recovered(value, hysteresis float64) bool {
+	band := math.Abs(a.Threshold) * hysteresis
+	if a.Op == AlertAbove {
+		return value <= a.Threshold-band
+	}
+	return value >= a.Threshold+band
+}
+
+// Evaluate updates the state of a with the current value and reports whether it fires now.
+func (a *Alert) Evaluate(value float64, now time.Time, hysteresis float64) bool {
+	if a.Triggered {
+		if a.recovered(value, hysteresis) {
+			a.Triggered = false
+		}
+		return false
+	}
+	if !a.Breached(value) || (!a.LastFired.IsZero() && now.Before(a.LastFired.Add(a.Cooldown))) {
+		return false
+	}
+	a.Triggered, a.LastFired = true, now
+	return true
+}
+
+// Location returns the time zone of the PnL day of a; unknown zones fall back to UTC.
+func (a Alert) Location() *time.Location {
+	if loc, err := time.LoadLocation(a.TimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
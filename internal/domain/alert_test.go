@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlert_Validate(t *testing.T) {
+	ok := Alert{Metric: AlertPnL, Op: AlertBelow, Threshold: -3, Percent: true, Currency: "USDT"}
+	require.NoError(t, ok.Validate())
+
+	tests := []struct {
+		alert  Alert
+		reason string
+	}{
+		{Alert{Metric: "equity", Op: AlertAbove}, `unknown metric "equity"`},
+		{Alert{Metric: AlertPnL, Op: "="}, `unknown comparison "="`},
+		{Alert{Metric: AlertPrice, Op: AlertAbove, Threshold: 100}, "price needs a symbol"},
+		{Alert{Metric: AlertPrice, Symbol: "BTCUSDT", Op: AlertBelow, Threshold: -1}, "price must be positive"},
+		{Alert{Metric: AlertPnL, Symbol: "BTCUSDT", Op: AlertBelow}, "not per symbol"},
+		{Alert{Metric: AlertLoss, Op: AlertAbove, Threshold: 5, Percent: true, Currency: "USDT"}, "only pnl accepts a percentage"},
+		{Alert{Metric: AlertPnL, Op: AlertBelow, Threshold: -3, Percent: true}, "quote currency"},
+	}
+	for _, tt := range tests {
+		err := tt.alert.Validate()
+		require.ErrorIs(t, err, ErrInvalidAlert)
+		require.ErrorContains(t, err, tt.reason)
+	}
+}
+
+func TestAlert_Evaluate(t *testing.T) {
+	a := Alert{Metric: AlertLoss, Op: AlertAbove, Threshold: 500, Cooldown: time.Hour}
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return start.Add(time.Duration(min) * time.Minute) }
+	const hysteresis = 0.1
+
+	require.False(t, a.Evaluate(400, at(0), hysteresis))
+	require.True(t, a.Evaluate(510, at(1), hysteresis), "fires when the condition starts to hold")
+	require.False(t, a.Evaluate(600, at(2), hysteresis), "fires once while it holds")
+
+	// Flapping around the threshold stays quiet until the value is back below 450.
+	require.False(t, a.Evaluate(480, at(3), hysteresis))
+	require.False(t, a.Evaluate(520, at(4), hysteresis))
+	require.True(t, a.Triggered)
+	require.False(t, a.Evaluate(440, at(5), hysteresis))
+	require.False(t, a.Triggered, "re-armed below the band")
+
+	// Re-armed, but still within the cooldown of the first notification.
+	require.False(t, a.Evaluate(520, at(30), hysteresis))
+	require.False(t, a.Triggered)
+	require.True(t, a.Evaluate(520, at(61), hysteresis), "fires again once the cooldown passed")
+	require.Equal(t, at(61), a.LastFired)
+
+	below := Alert{Metric: AlertPnL, Op: AlertBelow, Threshold: -3}
+	require.True(t, below.Evaluate(-3.5, at(0), hysteresis))
+	require.False(t, below.Evaluate(-2.8, at(1), hysteresis), "still within the band above -3")
+	require.True(t, below.Triggered)
+	require.False(t, below.Evaluate(-2.6, at(2), hysteresis))
+	require.False(t, below.Triggered)
+	require.True(t, below.Evaluate(-4, at(3), hysteresis), "no cooldown")
+}
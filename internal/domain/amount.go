@@ -0,0 +1,30 @@
+package domain
+
+import "math"
+
+// amountScale is the number of Amount units per whole currency unit (8 decimal places,
+// enough for satoshi-level crypto amounts).
+const amountScale = 1e8
+
+// Amount is a fixed-point money value with 8 decimal places. Sums of Amounts are exact, unlike
+// repeated float64 addition; the range is about ±9.2e10 whole units.
+type Amount int64
+
+// AmountOf converts f to an Amount, rounding half away from zero at the 8th decimal.
+func AmountOf(f float64) Amount {
+	return Amount(math.Round(f * amountScale))
+}
+
+// Float64 returns the amount as a float for display and ratios.
+func (a Amount) Float64() float64 {
+	return float64(a) / amountScale
+}
+
+// Sum adds values exactly at 8 decimal places.
+func Sum(values ...float64) float64 {
+	var s Amount
+	for _, v := range values {
+		s += AmountOf(v)
+	}
+	return s.Float64()
+}
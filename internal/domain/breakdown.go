@@ -0,0 +1,45 @@
+package domain
+
+// PnLGroup is the realized profit/loss of one symbol or strategy over a range.
+type PnLGroup struct {
+	Name   string
+	PnL    float64
+	Trades int
+	Wins   int
+}
+
+// WinRate returns the share of winning trades in percent, or 0 without trades.
+func (g PnLGroup) WinRate() float64 {
+	if g.Trades == 0 {
+		return 0
+	}
+	return float64(g.Wins) / float64(g.Trades) * 100
+}
+
+// Breakdown splits the PnL of an account and range by symbol and by strategy.
+// Both slices are ordered by PnL, best first.
+type Breakdown struct {
+	Account    string
+	Currency   string
+	TimeZone   string
+	From       string
+	To         string
+	Symbols    []PnLGroup
+	Strategies []PnLGroup
+}
+
+// TopSymbols returns up to n best-performing symbols, best first.
+func (b Breakdown) TopSymbols(n int) []PnLGroup {
+	return b.Symbols[:min(max(n, 0), len(b.Symbols))]
+}
+
+// BottomSymbols returns up to n worst-performing symbols not already in TopSymbols(n),
+// worst first.
+func (b Breakdown) BottomSymbols(n int) []PnLGroup {
+	start := max(len(b.Symbols)-max(n, 0), min(max(n, 0), len(b.Symbols)))
+	out := make([]PnLGroup, 0, len(b.Symbols)-start)
+	for i := len(b.Symbols) - 1; i >= start; i-- {
+		out = append(out, b.Symbols[i])
+	}
+	return out
+}
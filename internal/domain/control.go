@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// ControlAction is a request to the trading core to change or report its trading state.
+type ControlAction string
+
+// Control actions.
+const (
+	// ControlPause stops opening positions, for one strategy or for all of them.
+	ControlPause ControlAction = "pause"
+	// ControlResume lifts a pause, for one strategy or for all of them.
+	ControlResume ControlAction = "resume"
+	// ControlFlatten pauses all strategies and closes every open position at market.
+	ControlFlatten ControlAction = "flatten"
+	// ControlStatus reports the trading state without changing it.
+	ControlStatus ControlAction = "status"
+)
+
+// ControlRequest is a control request sent to the trading core.
+type ControlRequest struct {
+	// ID correlates the core's reply with the request.
+	ID     string        `json:"id"`
+	Action ControlAction `json:"action"`
+	// Strategy limits pause and resume to one strategy; empty means all strategies.
+	Strategy string `json:"strategy,omitempty"`
+	// UserID and User identify the Telegram user who confirmed the request.
+	UserID int64     `json:"user_id"`
+	User   string    `json:"user"`
+	Time   time.Time `json:"timestamp"`
+}
+
+// ControlReply is the trading core's acknowledgment of a ControlRequest, with its trading state
+// after the request.
+type ControlReply struct {
+	RequestID string `json:"request_id"`
+	OK        bool   `json:"ok"`
+	// Message is an optional human-readable result, e.g. how many positions were closed.
+	Message string `json:"message,omitempty"`
+	// Paused is true while all trading is paused; PausedStrategies lists strategies paused
+	// on their own.
+	Paused           bool     `json:"paused"`
+	PausedStrategies []string `json:"paused_strategies,omitempty"`
+	OpenPositions    int      `json:"open_positions"`
+}
+
+// AuditOutcome is what came of an audited invocation.
+type AuditOutcome string
+
+// Audit outcomes.
+const (
+	AuditDenied    AuditOutcome = "denied"
+	AuditRequested AuditOutcome = "requested"
+	AuditCancelled AuditOutcome = "cancelled"
+	AuditExpired   AuditOutcome = "expired"
+	AuditOK        AuditOutcome = "ok"
+	AuditFailed    AuditOutcome = "failed"
+)
+
+// AuditEntry records one invocation of a privileged command.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	UserID int64     `json:"user_id"`
+	User   string    `json:"user"`
+	// Command is the invoked command with its arguments, e.g. "/pause scalper".
+	Command   string       `json:"command"`
+	RequestID string       `json:"request_id,omitempty"`
+	Outcome   AuditOutcome `json:"outcome"`
+	// Detail is the core's message or the error of a failed request.
+	Detail string `json:"detail,omitempty"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// CriticalPage is a critical system message sent by DM to the admins until one of them
+// acknowledges it.
+type CriticalPage struct {
+	ID string `json:"id"`
+	// Key identifies the queue message the page was raised for, so a redelivery does not page
+	// the admins again.
+	Key       string    `json:"key"`
+	Text      string    `json:"text"`
+	ParseMode string    `json:"parse_mode,omitempty"`
+	Raw       bool      `json:"raw,omitempty"`
+	Raised    time.Time `json:"raised"`
+	// Paged is when it was last sent; Escalated is set once it also went to the escalation list.
+	Paged     time.Time `json:"paged"`
+	Escalated bool      `json:"escalated,omitempty"`
+	// Messages are the latest copy in each chat it was sent to, the only ones with the button.
+	Messages []MessageRef `json:"messages,omitempty"`
+	// AckedAt is when it was acknowledged. Acknowledged pages are kept for a while so that
+	// redeliveries of their message are recognized.
+	AckedAt *time.Time `json:"acked_at,omitempty"`
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is an inclusive range of calendar dates (YYYY-MM-DD) in a time zone.
+// A nil Location means UTC.
+type Period struct {
+	From     string
+	To       string
+	Location *time.Location
+}
+
+// Loc returns the period's time zone.
+func (p Period) Loc() *time.Location {
+	if p.Location == nil {
+		return time.UTC
+	}
+	return p.Location
+}
+
+// Zone returns the IANA name of the period's time zone, or "" for UTC.
+func (p Period) Zone() string {
+	if name := p.Loc().String(); name != "UTC" {
+		return name
+	}
+	return ""
+}
+
+// Bounds returns local midnight at the start of From and local midnight after To; the end
+// is exclusive. Both instants are in the period's location.
+func (p Period) Bounds() (start, end time.Time, err error) {
+	loc := p.Loc()
+	if start, err = time.ParseInLocation(time.DateOnly, p.From, loc); err != nil {
+		return start, end, fmt.Errorf("invalid from date: %w", err)
+	}
+	t, err := time.ParseInLocation(time.DateOnly, p.To, loc)
+	if err != nil {
+		return start, end, fmt.Errorf("invalid to date: %w", err)
+	}
+	return start, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc), nil
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "math"
+
+// Position is an open position of an account, valued at the current mark price. Amounts are
+// in Currency, the account's quote currency.
+type Position struct {
+	Account          string
+	Currency         string
+	Symbol           string
+	Side             Side
+	Size             float64
+	EntryPrice       float64
+	MarkPrice        float64
+	UnrealizedPnL    float64
+	LiquidationPrice float64 // 0 when the position cannot be liquidated
+}
+
+// LiquidationDistance returns how far the mark price may move against the position before it
+// is liquidated, as a fraction of the mark price; NaN without a liquidation price.
+func (p Position) LiquidationDistance() float64 {
+	if p.LiquidationPrice <= 0 || p.MarkPrice <= 0 {
+		return math.NaN()
+	}
+	return math.Abs(p.MarkPrice-p.LiquidationPrice) / p.MarkPrice
+}
+
+// Balance is the balance of one asset of an account.
+type Balance struct {
+	Account   string
+	Asset     string
+	Total     float64
+	Available float64
+}
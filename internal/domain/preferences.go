@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// NotificationCategory is a kind of queue notification a user may also receive by direct message.
+type NotificationCategory string
+
+// Notification categories, one per queue consumer.
+const (
+	NotifySignals NotificationCategory = "signals"
+	NotifyReports NotificationCategory = "reports"
+	NotifySystem  NotificationCategory = "system"
+)
+
+// NotificationCategories lists every category in menu order.
+var NotificationCategories = []NotificationCategory{NotifySignals, NotifyReports, NotifySystem}
+
+// QuietHours is a daily window [Start, End) of local hours 0..23; Start > End wraps midnight.
+type QuietHours struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Contains reports whether hour falls within the window.
+func (q QuietHours) Contains(hour int) bool {
+	if q.Start <= q.End {
+		return hour >= q.Start && hour < q.End
+	}
+	return hour >= q.Start || hour < q.End
+}
+
+// Preferences are per-user bot settings; zero values fall back to the bot defaults.
+type Preferences struct {
+	Timezone     string                 `json:"timezone,omitempty"`
+	Language     string                 `json:"language,omitempty"`
+	Account      string                 `json:"account,omitempty"` // default account ID or AllAccounts
+	NumberLocale string                 `json:"number_locale,omitempty"`
+	Notify       []NotificationCategory `json:"notify,omitempty"` // categories sent by DM
+	QuietHours   *QuietHours            `json:"quiet_hours,omitempty"`
+	// DisplayCurrency is the currency report totals are converted to, or NativeCurrencies;
+	// empty for the bot default.
+	DisplayCurrency string `json:"display_currency,omitempty"`
+}
+
+// NativeCurrencies as Preferences.DisplayCurrency shows reports in the currencies of the
+// accounts, even when the bot converts them by default.
+const NativeCurrencies = "native"
+
+// Location returns the preferred time zone, or fallback when unset or unknown.
+func (p Preferences) Location(fallback *time.Location) *time.Location {
+	if p.Timezone != "" {
+		if loc, err := time.LoadLocation(p.Timezone); err == nil {
+			return loc
+		}
+	}
+	return fallback
+}
+
+// Subscribed reports whether the user receives category c by DM.
+func (p Preferences) Subscribed(c NotificationCategory) bool {
+	return slices.Contains(p.Notify, c)
+}
+
+// Quiet reports whether t falls within the quiet hours in the user's time zone.
+func (p Preferences) Quiet(t time.Time, fallback *time.Location) bool {
+	return p.QuietHours != nil && p.QuietHours.Contains(t.In(p.Location(fallback)).Hour())
+}
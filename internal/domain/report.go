@@ -1,10 +1,43 @@
 // Package domain holds core business types.
 package domain
 
-// Report is a profit/loss summary for a date range.
+// Report is a profit/loss summary for a date range of one account (or AllAccounts for
+// an aggregate), in Currency.
 type Report struct {
-	From    string
-	To      string
-	Income  float64
-	Expense float64
+	Account  string
+	Currency string
+	TimeZone string // IANA name of the zone From and To are in; empty for UTC
+	From     string
+	To       string
+	Income   float64
+	Expense  float64
+}
+
+// DailyPnL is the realized profit/loss of a single calendar day.
+type DailyPnL struct {
+	Date string
+	PnL  float64
+}
+
+// Net returns income minus expense.
+func (r Report) Net() float64 {
+	return r.Income - r.Expense
+}
+
+// ComparisonMode selects the reference period of a comparison.
+type ComparisonMode string
+
+// Comparison modes.
+const (
+	// ComparePrevious compares against the equal-length range immediately before.
+	ComparePrevious ComparisonMode = "prev"
+	// CompareYearAgo compares against the same calendar range one year earlier.
+	CompareYearAgo ComparisonMode = "yoy"
+)
+
+// ReportComparison pairs a report with the report of its reference period.
+type ReportComparison struct {
+	Mode     ComparisonMode
+	Current  Report
+	Previous Report
 }
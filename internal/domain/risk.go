@@ -0,0 +1,27 @@
+package domain
+
+// RiskMetrics summarizes the risk profile of a daily PnL series. Ratios that are undefined for
+// the series (e.g. Sharpe of a constant series) are NaN; a profit factor without losing days is +Inf.
+type RiskMetrics struct {
+	Account  string
+	Currency string
+	TimeZone string
+	From     string
+	To       string
+	Days     int
+
+	// MaxDrawdown is the largest peak-to-trough fall of cumulative PnL; MaxDrawdownPct relates
+	// it to the peak cumulative PnL (NaN when the peak is not positive).
+	MaxDrawdown    float64
+	MaxDrawdownPct float64
+	// Sharpe and Sortino are annualized from daily PnL with a zero risk-free rate.
+	Sharpe  float64
+	Sortino float64
+	// ProfitFactor is gross profit over gross loss.
+	ProfitFactor float64
+	// AvgWin and AvgLoss average winning and losing days; AvgLoss is negative.
+	AvgWin  float64
+	AvgLoss float64
+	// LongestLosingStreak counts consecutive losing days; a flat day ends a streak.
+	LongestLosingStreak int
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Severity ranks a system message: info is posted silently, warning normally, and critical
+// also pages the admins until one of them acknowledges it.
+type Severity string
+
+// Severities.
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// ParseSeverity reads the "severity" field of a system message payload. Payloads without a
+// known severity are warnings, which is how every system message was posted before.
+func ParseSeverity(payload []byte) Severity {
+	var probe struct {
+		Severity string `json:"severity"`
+	}
+	if json.Unmarshal(payload, &probe) != nil {
+		return SeverityWarning
+	}
+	switch strings.ToLower(strings.TrimSpace(probe.Severity)) {
+	case "info":
+		return SeverityInfo
+	case "critical", "crit":
+		return SeverityCritical
+	}
+	return SeverityWarning
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for payload, want := range map[string]Severity{
+		`{"severity":"info","text":"x"}`: SeverityInfo,
+		`{"severity":" CRITICAL "}`:      SeverityCritical,
+		`{"severity":"crit"}`:            SeverityCritical,
+		`{"severity":"warning"}`:         SeverityWarning,
+		`{"severity":"debug"}`:           SeverityWarning,
+		`{"text":"x"}`:                   SeverityWarning,
+		`{"severity":3}`:                 SeverityWarning,
+		`plain text`:                     SeverityWarning,
+	} {
+		require.Equal(t, want, ParseSeverity([]byte(payload)), payload)
+	}
+}
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Side is the direction of a trade.
+type Side string
+
+// Trade sides.
+const (
+	SideLong  Side = "long"
+	SideShort Side = "short"
+)
+
+// ErrInvalidSignal marks a trading signal payload that cannot be decoded or fails validation.
+var ErrInvalidSignal = errors.New("invalid signal")
+
+// Signal is a trade idea published by the trading core on the signals queue. Prices are in the
+// quote currency of Symbol; TakeProfits are ordered from the nearest level. JSON tags describe
+// the stored form; queue payloads are decoded by ParseSignal.
+type Signal struct {
+	ID          string    `json:"id,omitempty"`
+	Symbol      string    `json:"symbol"`
+	Side        Side      `json:"side"`
+	Entry       float64   `json:"entry"`
+	StopLoss    float64   `json:"stop_loss"`
+	TakeProfits []float64 `json:"take_profits"`
+	// Leverage is the suggested leverage; 0 means unspecified (spot).
+	Leverage float64 `json:"leverage,omitempty"`
+	Strategy string  `json:"strategy,omitempty"`
+	// Confidence is the strategy's confidence in [0, 1]; 0 means unspecified.
+	Confidence float64   `json:"confidence,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// signalJSON is the wire format of a Signal. take_profit may be a single level or a list.
+type signalJSON struct {
+	ID         string          `json:"id"`
+	Symbol     string          `json:"symbol"`
+	Side       string          `json:"side"`
+	Entry      float64         `json:"entry"`
+	StopLoss   float64         `json:"stop_loss"`
+	TakeProfit json.RawMessage `json:"take_profit"`
+	Leverage   float64         `json:"leverage"`
+	Strategy   string          `json:"strategy"`
+	Confidence float64         `json:"confidence"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
+
+// ParseSignal decodes and validates a signals queue payload. Sides "buy" and "sell" are
+// accepted as long and short. Errors wrap ErrInvalidSignal and name the offending field.
+func ParseSignal(payload []byte) (Signal, error) {
+	var w signalJSON
+	if err := json.Unmarshal(payload, &w); err != nil {
+		return Signal{}, fmt.Errorf("%w: %w", ErrInvalidSignal, err)
+	}
+	s := Signal{
+		ID:         w.ID,
+		Symbol:     strings.ToUpper(strings.TrimSpace(w.Symbol)),
+		Side:       ParseSide(w.Side),
+		Entry:      w.Entry,
+		StopLoss:   w.StopLoss,
+		Leverage:   w.Leverage,
+		Strategy:   strings.TrimSpace(w.Strategy),
+		Confidence: w.Confidence,
+		Time:       w.Timestamp,
+	}
+	if tp := bytes.TrimSpace(w.TakeProfit); len(tp) > 0 && tp[0] == '[' {
+		if err := json.Unmarshal(tp, &s.TakeProfits); err != nil {
+			return Signal{}, fmt.Errorf("%w: take_profit: %w", ErrInvalidSignal, err)
+		}
+	} else if len(tp) > 0 && string(tp) != "null" {
+		var level float64
+		if err := json.Unmarshal(tp, &level); err != nil {
+			return Signal{}, fmt.Errorf("%w: take_profit: %w", ErrInvalidSignal, err)
+		}
+		s.TakeProfits = []float64{level}
+	}
+	if err := s.Validate(); err != nil {
+		return Signal{}, err
+	}
+	return s, nil
+}
+
+// ParseSide reads a trade side; "buy" and "sell" are accepted as long and short.
+func ParseSide(s string) Side {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "long", "buy":
+		return SideLong
+	case "short", "sell":
+		return SideShort
+	}
+	return Side(s)
+}
+
+// Validate checks that the signal is complete and its levels are on the correct sides of the
+// entry: for a long the stop-loss is below and every take-profit above, for a short the reverse.
+func (s Signal) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSignal, fmt.Sprintf(format, args...))
+	}
+	switch {
+	case s.Symbol == "":
+		return invalid("symbol is required")
+	case s.Side != SideLong && s.Side != SideShort:
+		return invalid("side %q is not long/buy or short/sell", s.Side)
+	case !positive(s.Entry):
+		return invalid("entry must be positive")
+	case !positive(s.StopLoss):
+		return invalid("stop_loss must be positive")
+	case len(s.TakeProfits) == 0:
+		return invalid("take_profit is required")
+	case s.Leverage < 0 || math.IsNaN(s.Leverage):
+		return invalid("leverage must not be negative")
+	case s.Confidence < 0 || s.Confidence > 1 || math.IsNaN(s.Confidence):
+		return invalid("confidence %v is outside [0, 1]", s.Confidence)
+	case s.Time.IsZero():
+		return invalid("timestamp is required")
+	}
+	if s.sign()*(s.Entry-s.StopLoss) <= 0 {
+		return invalid("stop_loss %v is not %s entry %v", s.StopLoss, s.beyond(false), s.Entry)
+	}
+	for i, tp := range s.TakeProfits {
+		if !positive(tp) {
+			return invalid("take_profit %d must be positive", i+1)
+		}
+		if s.sign()*(tp-s.Entry) <= 0 {
+			return invalid("take_profit %d %v is not %s entry %v", i+1, tp, s.beyond(true), s.Entry)
+		}
+	}
+	return nil
+}
+
+func positive(v float64) bool {
+	return v > 0 && !math.IsInf(v, 1)
+}
+
+// sign is +1 for a long and -1 for a short: the direction in which price moves into profit.
+func (s Signal) sign() float64 {
+	if s.Side == SideShort {
+		return -1
+	}
+	return 1
+}
+
+func (s Signal) beyond(profit bool) string {
+	if (s.Side == SideLong) == profit {
+		return "above"
+	}
+	return "below"
+}
+
+// DistancePct returns the signed distance from the entry to level in percent of the entry.
+func (s Signal) DistancePct(level float64) float64 {
+	return (level - s.Entry) / s.Entry * 100
+}
+
+// RiskReward returns the reward-to-risk ratio of take-profit level tp: its distance from the
+// entry over the stop-loss distance.
+func (s Signal) RiskReward(tp float64) float64 {
+	return math.Abs(tp-s.Entry) / math.Abs(s.Entry-s.StopLoss)
+}
+
+// ProfitPct returns the result of exiting at price in percent of the entry: positive when price
+// moved in the signal's direction.
+func (s Signal) ProfitPct(price float64) float64 {
+	return s.sign() * s.DistancePct(price)
+}
+
+// RMultiple returns the result of exiting at price in units of the initial risk, the distance
+// from the entry to the stop-loss: -1 at the stop-loss, 2 at twice the risk in profit.
+func (s Signal) RMultiple(price float64) float64 {
+	return s.sign() * (price - s.Entry) / math.Abs(s.Entry-s.StopLoss)
+}
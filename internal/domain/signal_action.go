@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SignalActionType is an operator action on a published signal, sent to the trading core as a
+// command.
+type SignalActionType string
+
+// Signal actions, in the order their buttons are shown.
+const (
+	SignalAcknowledge     SignalActionType = "acknowledge"
+	SignalSkip            SignalActionType = "skip"
+	SignalCloseNow        SignalActionType = "close"
+	SignalMoveToBreakeven SignalActionType = "move_sl_breakeven"
+)
+
+// SignalActionTypes lists every signal action.
+var SignalActionTypes = []SignalActionType{SignalAcknowledge, SignalSkip, SignalCloseNow, SignalMoveToBreakeven}
+
+// Final reports whether the action ends the signal for the operators: no further actions are
+// offered after it.
+func (a SignalActionType) Final() bool {
+	return a == SignalSkip || a == SignalCloseNow
+}
+
+// SignalCommand is the command an action publishes to the trading core.
+type SignalCommand struct {
+	ID       string           `json:"id"`
+	SignalID string           `json:"signal_id"`
+	Action   SignalActionType `json:"action"`
+	// UserID and User identify the Telegram user who confirmed the action.
+	UserID int64     `json:"user_id"`
+	User   string    `json:"user"`
+	Time   time.Time `json:"timestamp"`
+}
+
+// SignalCommandReply is the trading core's answer to a SignalCommand.
+type SignalCommandReply struct {
+	CommandID string `json:"command_id"`
+	SignalID  string `json:"signal_id"`
+	OK        bool   `json:"ok"`
+	// Message is an optional human-readable result, e.g. the fill price or why it failed.
+	Message string `json:"message,omitempty"`
+}
+
+// ParseSignalCommandReply decodes a reply of the trading core. Errors wrap ErrInvalidSignal.
+func ParseSignalCommandReply(payload []byte) (SignalCommandReply, error) {
+	var r SignalCommandReply
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return SignalCommandReply{}, fmt.Errorf("%w: %w", ErrInvalidSignal, err)
+	}
+	r.CommandID, r.SignalID = strings.TrimSpace(r.CommandID), strings.TrimSpace(r.SignalID)
+	switch {
+	case r.CommandID == "":
+		return SignalCommandReply{}, fmt.Errorf("%w: command_id is required", ErrInvalidSignal)
+	case r.SignalID == "":
+		return SignalCommandReply{}, fmt.Errorf("%w: signal_id is required", ErrInvalidSignal)
+	}
+	return r, nil
+}
+
+// Why a queued signal command was dropped without reaching the trading core.
+const (
+	SignalCommandExpired    = "expired"
+	SignalCommandUnroutable = "unroutable"
+)
+
+// SignalAction is a command sent for a signal with the core's reply, once it arrives. Dropped is
+// set instead when the command never reached the core.
+type SignalAction struct {
+	SignalCommand
+	Reply   *SignalCommandReply `json:"reply,omitempty"`
+	Dropped string              `json:"dropped,omitempty"`
+}
+
+// Action returns the action that sent command id.
+func (r SignalRecord) Action(id string) (SignalAction, bool) {
+	for _, a := range r.Actions {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return SignalAction{}, false
+}
+
+// Done reports whether the signal takes no more actions: it resolved, was cancelled, or an
+// operator skipped or closed it and the command was not dropped.
+func (r SignalRecord) Done() bool {
+	if _, resolved := r.Exit(); resolved || r.Cancelled() {
+		return true
+	}
+	for _, a := range r.Actions {
+		if a.Action.Final() && a.Dropped == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// LastActivity returns when the signal was published or last had an event or action.
+func (r SignalRecord) LastActivity() time.Time {
+	at := r.Signal.Time
+	for _, e := range r.Events {
+		if e.Time.After(at) {
+			at = e.Time
+		}
+	}
+	for _, a := range r.Actions {
+		if a.Time.After(at) {
+			at = a.Time
+		}
+	}
+	return at
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SignalEventType is a step in the lifecycle of a signal.
+type SignalEventType string
+
+// Signal lifecycle events.
+const (
+	SignalTakeProfit SignalEventType = "tp_hit"
+	SignalStopLoss   SignalEventType = "sl_hit"
+	SignalClosed     SignalEventType = "closed"
+	SignalCancelled  SignalEventType = "cancelled"
+)
+
+// SignalEvent reports that a published signal hit a level, was closed, or was cancelled.
+type SignalEvent struct {
+	SignalID string          `json:"signal_id"`
+	Type     SignalEventType `json:"type"`
+	// Level is the 1-based take-profit level of a SignalTakeProfit event.
+	Level int `json:"level,omitempty"`
+	// Price is the fill price; 0 for SignalCancelled.
+	Price float64   `json:"price,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Final reports whether the event ends the signal.
+func (e SignalEvent) Final() bool {
+	return e.Type != SignalTakeProfit
+}
+
+// signalEventJSON is the wire format of a SignalEvent.
+type signalEventJSON struct {
+	Event     string    `json:"event"`
+	SignalID  string    `json:"signal_id"`
+	Level     int       `json:"level"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// IsSignalEvent reports whether a signals queue payload is a lifecycle event rather than a new
+// signal: events carry an "event" field.
+func IsSignalEvent(payload []byte) bool {
+	var probe struct {
+		Event *string `json:"event"`
+	}
+	return json.Unmarshal(payload, &probe) == nil && probe.Event != nil
+}
+
+// ParseSignalEvent decodes and validates a lifecycle event payload. Event names "tp" and "sl"
+// are accepted for tp_hit and sl_hit. Errors wrap ErrInvalidSignal.
+func ParseSignalEvent(payload []byte) (SignalEvent, error) {
+	var w signalEventJSON
+	if err := json.Unmarshal(payload, &w); err != nil {
+		return SignalEvent{}, fmt.Errorf("%w: %w", ErrInvalidSignal, err)
+	}
+	e := SignalEvent{SignalID: strings.TrimSpace(w.SignalID), Level: w.Level, Price: w.Price, Time: w.Timestamp}
+	switch strings.ToLower(strings.TrimSpace(w.Event)) {
+	case "tp_hit", "tp":
+		e.Type = SignalTakeProfit
+	case "sl_hit", "sl":
+		e.Type = SignalStopLoss
+	case "closed":
+		e.Type = SignalClosed
+	case "cancelled", "canceled":
+		e.Type = SignalCancelled
+	default:
+		return SignalEvent{}, fmt.Errorf("%w: unknown event %q", ErrInvalidSignal, w.Event)
+	}
+	switch {
+	case e.SignalID == "":
+		return SignalEvent{}, fmt.Errorf("%w: signal_id is required", ErrInvalidSignal)
+	case e.Type == SignalTakeProfit && e.Level < 1:
+		return SignalEvent{}, fmt.Errorf("%w: level is required for %s", ErrInvalidSignal, e.Type)
+	case e.Type != SignalCancelled && !positive(e.Price):
+		return SignalEvent{}, fmt.Errorf("%w: price must be positive", ErrInvalidSignal)
+	case e.Time.IsZero():
+		return SignalEvent{}, fmt.Errorf("%w: timestamp is required", ErrInvalidSignal)
+	}
+	return e, nil
+}
+
+// MessageRef identifies a Telegram message.
+type MessageRef struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int   `json:"message_id"`
+}
+
+// SignalRecord is a published signal with its lifecycle so far, the messages that show it and
+// the actions operators took on it. Queue and Payload are what the signal was posted from, so
+// its cards can be rendered again. Archived records of long finished signals keep only what
+// statistics need: their messages and payload are dropped.
+type SignalRecord struct {
+	Signal   Signal          `json:"signal"`
+	Queue    string          `json:"queue,omitempty"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	Events   []SignalEvent   `json:"events,omitempty"`
+	Messages []MessageRef    `json:"messages,omitempty"`
+	Actions  []SignalAction  `json:"actions,omitempty"`
+	Archived bool            `json:"archived,omitempty"`
+}
+
+// Message returns the message showing the signal in chatID.
+func (r SignalRecord) Message(chatID int64) (MessageRef, bool) {
+	for _, m := range r.Messages {
+		if m.ChatID == chatID {
+			return m, true
+		}
+	}
+	return MessageRef{}, false
+}
+
+// HasEvent reports whether e was already recorded, so redelivered events are not repeated.
+func (r SignalRecord) HasEvent(e SignalEvent) bool {
+	for _, have := range r.Events {
+		if have.Type == e.Type && have.Level == e.Level {
+			return true
+		}
+	}
+	return false
+}
+
+// Last returns the latest event, if any.
+func (r SignalRecord) Last() (SignalEvent, bool) {
+	if len(r.Events) == 0 {
+		return SignalEvent{}, false
+	}
+	return r.Events[len(r.Events)-1], true
+}
+
+// Cancelled reports whether the signal was cancelled before it resolved.
+func (r SignalRecord) Cancelled() bool {
+	last, ok := r.Last()
+	return ok && last.Type == SignalCancelled
+}
+
+// HitLevel reports whether take-profit level n (1-based) was hit.
+func (r SignalRecord) HitLevel(n int) bool {
+	for _, e := range r.Events {
+		if e.Type == SignalTakeProfit && e.Level == n {
+			return true
+		}
+	}
+	return false
+}
+
+// StoppedOut reports whether the stop-loss was hit.
+func (r SignalRecord) StoppedOut() bool {
+	for _, e := range r.Events {
+		if e.Type == SignalStopLoss {
+			return true
+		}
+	}
+	return false
+}
+
+// Exit returns the price the signal was exited at, and whether it is resolved: stopped out,
+// closed, or with every take-profit level hit. Open and cancelled signals are not resolved.
+func (r SignalRecord) Exit() (price float64, resolved bool) {
+	last := len(r.Signal.TakeProfits)
+	for _, e := range r.Events {
+		switch {
+		case e.Type == SignalStopLoss, e.Type == SignalClosed:
+			return e.Price, true
+		case e.Type == SignalTakeProfit && e.Level == last:
+			price, resolved = e.Price, true
+		}
+	}
+	if r.Cancelled() {
+		return 0, false
+	}
+	return price, resolved
+}
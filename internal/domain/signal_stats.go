@@ -0,0 +1,46 @@
+package domain
+
+// Ratio counts how many of a number of signals reached an outcome.
+type Ratio struct {
+	Hit int
+	Of  int
+}
+
+// Rate returns Hit/Of, or 0 when Of is 0.
+func (r Ratio) Rate() float64 {
+	if r.Of == 0 {
+		return 0
+	}
+	return float64(r.Hit) / float64(r.Of)
+}
+
+// SymbolStats is the combined result of the resolved signals of one symbol.
+type SymbolStats struct {
+	Symbol   string
+	Resolved int
+	TotalR   float64
+}
+
+// SignalStats summarizes the outcomes of the signals published in a period, optionally of one
+// strategy. Empty From and To mean all time.
+type SignalStats struct {
+	From     string
+	To       string
+	TimeZone string
+	Strategy string
+
+	Signals   int
+	Open      int
+	Cancelled int
+	Resolved  int
+
+	// TakeProfits[i] is the hit rate of level i+1 among the signals that have the level and
+	// either resolved or already hit it.
+	TakeProfits []Ratio
+	// StopLoss is the share of resolved signals that were stopped out.
+	StopLoss Ratio
+	// AvgR is the mean R multiple of resolved signals; NaN without any.
+	AvgR float64
+	// Symbols are ordered by TotalR, best first.
+	Symbols []SymbolStats
+}
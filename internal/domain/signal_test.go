@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignal(t *testing.T) {
+	s, err := ParseSignal([]byte(`{"id":"s1","symbol":" btcusdt ","side":"BUY","entry":100,"stop_loss":95,
+		"take_profit":[110,120],"leverage":3,"strategy":"breakout","confidence":0.8,"timestamp":"2026-01-15T08:30:00Z"}`))
+	require.NoError(t, err)
+	require.Equal(t, Signal{
+		ID: "s1", Symbol: "BTCUSDT", Side: SideLong, Entry: 100, StopLoss: 95, TakeProfits: []float64{110, 120},
+		Leverage: 3, Strategy: "breakout", Confidence: 0.8, Time: time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC),
+	}, s)
+	require.InDelta(t, 2.0, s.RiskReward(110), 1e-9)
+	require.InDelta(t, -5.0, s.DistancePct(95), 1e-9)
+
+	s, err = ParseSignal([]byte(`{"symbol":"ETH","side":"short","entry":100,"stop_loss":104,"take_profit":90,"timestamp":"2026-01-15T08:30:00Z"}`))
+	require.NoError(t, err)
+	require.Equal(t, SideShort, s.Side)
+	require.Equal(t, []float64{90}, s.TakeProfits)
+	require.InDelta(t, 2.5, s.RiskReward(90), 1e-9)
+}
+
+func TestParseSignal_invalid(t *testing.T) {
+	const ts = `"timestamp":"2026-01-15T08:30:00Z"`
+	tests := []struct {
+		payload string
+		reason  string
+	}{
+		{`not json`, "invalid character"},
+		{`{"side":"long","entry":1,"stop_loss":0.9,"take_profit":2,` + ts + `}`, "symbol is required"},
+		{`{"symbol":"X","side":"flat","entry":1,"stop_loss":0.9,"take_profit":2,` + ts + `}`, `side "flat"`},
+		{`{"symbol":"X","side":"long","entry":0,"stop_loss":0.9,"take_profit":2,` + ts + `}`, "entry must be positive"},
+		{`{"symbol":"X","side":"long","entry":1,"take_profit":2,` + ts + `}`, "stop_loss must be positive"},
+		{`{"symbol":"X","side":"long","entry":1,"stop_loss":0.9,` + ts + `}`, "take_profit is required"},
+		{`{"symbol":"X","side":"long","entry":1,"stop_loss":0.9,"take_profit":"2",` + ts + `}`, "take_profit"},
+		{`{"symbol":"X","side":"long","entry":1,"stop_loss":0.9,"take_profit":2}`, "timestamp is required"},
+		{`{"symbol":"X","side":"long","entry":1,"stop_loss":1.1,"take_profit":2,` + ts + `}`, "stop_loss 1.1 is not below entry 1"},
+		{`{"symbol":"X","side":"short","entry":1,"stop_loss":1.1,"take_profit":[0.9,1.2],` + ts + `}`, "take_profit 2 1.2 is not below entry 1"},
+		{`{"symbol":"X","side":"long","entry":1,"stop_loss":0.9,"take_profit":2,"confidence":75,` + ts + `}`, "confidence 75 is outside [0, 1]"},
+		{`{"symbol":"X","side":"long","entry":1,"stop_loss":0.9,"take_profit":2,"leverage":-2,` + ts + `}`, "leverage"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSignal([]byte(tt.payload))
+		require.ErrorIs(t, err, ErrInvalidSignal, tt.payload)
+		require.ErrorContains(t, err, tt.reason, tt.payload)
+	}
+}
+
+func TestParseSignalEvent(t *testing.T) {
+	require.True(t, IsSignalEvent([]byte(`{"event":"tp","signal_id":"s1"}`)))
+	require.False(t, IsSignalEvent([]byte(`{"symbol":"BTCUSDT"}`)))
+	require.False(t, IsSignalEvent([]byte(`not json`)))
+
+	ev, err := ParseSignalEvent([]byte(`{"event":"TP","signal_id":"s1","level":2,"price":120,"timestamp":"2026-01-15T10:00:00Z"}`))
+	require.NoError(t, err)
+	require.Equal(t, SignalEvent{SignalID: "s1", Type: SignalTakeProfit, Level: 2, Price: 120,
+		Time: time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)}, ev)
+	require.False(t, ev.Final())
+
+	ev, err = ParseSignalEvent([]byte(`{"event":"canceled","signal_id":"s1","timestamp":"2026-01-15T10:00:00Z"}`))
+	require.NoError(t, err)
+	require.Equal(t, SignalCancelled, ev.Type)
+	require.True(t, ev.Final())
+
+	for payload, reason := range map[string]string{
+		`{"event":"moon","signal_id":"s1"}`:                                            `unknown event "moon"`,
+		`{"event":"sl","price":1,"timestamp":"2026-01-15T10:00:00Z"}`:                  "signal_id is required",
+		`{"event":"tp","signal_id":"s1","price":1,"timestamp":"2026-01-15T10:00:00Z"}`: "level is required",
+		`{"event":"closed","signal_id":"s1","timestamp":"2026-01-15T10:00:00Z"}`:       "price must be positive",
+		`{"event":"closed","signal_id":"s1","price":1}`:                                "timestamp is required",
+	} {
+		_, err := ParseSignalEvent([]byte(payload))
+		require.ErrorIs(t, err, ErrInvalidSignal, payload)
+		require.ErrorContains(t, err, reason, payload)
+	}
+}
+
+func TestParseSignalCommandReply(t *testing.T) {
+	r, err := ParseSignalCommandReply([]byte(`{"command_id":" c1 ","signal_id":"s1","ok":true,"message":"moved"}`))
+	require.NoError(t, err)
+	require.Equal(t, SignalCommandReply{CommandID: "c1", SignalID: "s1", OK: true, Message: "moved"}, r)
+
+	for payload, reason := range map[string]string{
+		`{"signal_id":"s1","ok":true}`: "command_id is required",
+		`{"command_id":"c1"}`:          "signal_id is required",
+		`[]`:                           "cannot unmarshal",
+	} {
+		_, err := ParseSignalCommandReply([]byte(payload))
+		require.ErrorIs(t, err, ErrInvalidSignal, payload)
+		require.ErrorContains(t, err, reason, payload)
+	}
+}
+
+func TestSignalRecord_Done(t *testing.T) {
+	rec := SignalRecord{Signal: Signal{ID: "s1", TakeProfits: []float64{110, 120}}}
+	require.False(t, rec.Done())
+
+	rec.Events = []SignalEvent{{Type: SignalTakeProfit, Level: 1, Price: 110}}
+	rec.Actions = []SignalAction{{SignalCommand: SignalCommand{ID: "c1", Action: SignalAcknowledge}}}
+	require.False(t, rec.Done())
+	a, ok := rec.Action("c1")
+	require.True(t, ok)
+	require.Equal(t, SignalAcknowledge, a.Action)
+
+	rec.Actions = append(rec.Actions, SignalAction{SignalCommand: SignalCommand{ID: "c2", Action: SignalSkip}, Dropped: SignalCommandExpired})
+	require.False(t, rec.Done(), "dropped commands do not end the signal")
+	rec.Actions[1].Dropped = ""
+	require.True(t, rec.Done())
+
+	rec.Actions = nil
+	rec.Events = append(rec.Events, SignalEvent{Type: SignalTakeProfit, Level: 2, Price: 120})
+	require.True(t, rec.Done(), "every level hit")
+}
+
+func TestSignalRecord_LastActivity(t *testing.T) {
+	t0 := time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)
+	rec := SignalRecord{Signal: Signal{ID: "s1", Time: t0}}
+	require.Equal(t, t0, rec.LastActivity())
+
+	rec.Events = []SignalEvent{{Type: SignalTakeProfit, Level: 1, Time: t0.Add(2 * time.Hour)}}
+	rec.Actions = []SignalAction{{SignalCommand: SignalCommand{ID: "c1", Action: SignalAcknowledge, Time: t0.Add(time.Hour)}}}
+	require.Equal(t, t0.Add(2*time.Hour), rec.LastActivity())
+}
@@ -0,0 +1,189 @@
+// Package i18n provides the bot message catalog: per-locale JSON files with placeholders and
+// plural forms, embedded into the binary.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultLocale is used for unknown languages and for keys missing in a locale.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var embedded embed.FS
+
+// message is a catalog entry: a plain text or texts keyed by CLDR plural category
+// ("one", "few", "many", "other").
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+func (m *message) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &m.text); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, &m.plural); err != nil {
+		return fmt.Errorf("want string or plural object: %w", err)
+	}
+	if _, ok := m.plural["other"]; !ok {
+		return fmt.Errorf("plural object without \"other\"")
+	}
+	return nil
+}
+
+// Catalog holds the messages of every locale.
+type Catalog struct {
+	locales map[string]map[string]message
+}
+
+// Load reads every <locale>.json in fsys. The DefaultLocale file is required.
+func Load(fsys fs.FS) (*Catalog, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("i18n: %w", err)
+	}
+	c := &Catalog{locales: make(map[string]map[string]message, len(files))}
+	for _, name := range files {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("i18n: %w", err)
+		}
+		var msgs map[string]message
+		if err := json.Unmarshal(b, &msgs); err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", name, err)
+		}
+		c.locales[strings.TrimSuffix(path.Base(name), ".json")] = msgs
+	}
+	if _, ok := c.locales[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("i18n: missing %s.json", DefaultLocale)
+	}
+	return c, nil
+}
+
+// Default returns the embedded catalog; it panics if the shipped files are malformed,
+// which the package tests rule out.
+func Default() *Catalog {
+	sub, err := fs.Sub(embedded, "locales")
+	if err != nil {
+		panic(err)
+	}
+	c, err := Load(sub)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Locales returns the shipped locales, DefaultLocale first.
+func (c *Catalog) Locales() []string {
+	out := slices.Sorted(maps.Keys(c.locales))
+	i := slices.Index(out, DefaultLocale)
+	return append([]string{DefaultLocale}, slices.Delete(out, i, i+1)...)
+}
+
+// Match returns the shipped locale for a Telegram language code such as "ru" or "uk-UA",
+// or DefaultLocale.
+func (c *Catalog) Match(lang string) string {
+	base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	if _, ok := c.locales[base]; ok {
+		return base
+	}
+	return DefaultLocale
+}
+
+// Localizer returns a Localizer for the best match of lang.
+func (c *Catalog) Localizer(lang string) Localizer {
+	return Localizer{c: c, locale: c.Match(lang)}
+}
+
+// Localizer renders catalog messages in one locale.
+type Localizer struct {
+	c      *Catalog
+	locale string
+}
+
+// Locale returns the locale messages are rendered in.
+func (l Localizer) Locale() string {
+	return l.locale
+}
+
+// T renders key with args given as name/value pairs replacing "{name}" placeholders.
+// Keys missing in the locale fall back to DefaultLocale, then to the key itself.
+func (l Localizer) T(key string, args ...any) string {
+	m, ok := l.lookup(key)
+	if !ok {
+		return key
+	}
+	text := m.text
+	if m.plural != nil {
+		text = m.plural["other"]
+	}
+	return replace(text, args)
+}
+
+// N renders the plural form of key for n; "{count}" is replaced by n.
+func (l Localizer) N(key string, n int, args ...any) string {
+	m, ok := l.lookup(key)
+	if !ok {
+		return key
+	}
+	text := m.text
+	if m.plural != nil {
+		form, ok := m.plural[pluralCategory(l.locale, n)]
+		if !ok {
+			form = m.plural["other"]
+		}
+		text = form
+	}
+	return replace(text, append([]any{"count", n}, args...))
+}
+
+func (l Localizer) lookup(key string) (message, bool) {
+	if m, ok := l.c.locales[l.locale][key]; ok {
+		return m, true
+	}
+	m, ok := l.c.locales[DefaultLocale][key]
+	return m, ok
+}
+
+func replace(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// pluralCategory returns the CLDR cardinal plural category of n in locale.
+func pluralCategory(locale string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	switch locale {
+	case "ru", "uk":
+		switch mod10, mod100 := n%10, n%100; {
+		case mod10 == 1 && mod100 != 11:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
@@ -0,0 +1,114 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// placeholders returns the sorted placeholders of every form of m.
+func placeholders(m message) []string {
+	texts := []string{m.text}
+	for _, t := range m.plural {
+		texts = append(texts, t)
+	}
+	var out []string
+	for _, t := range texts {
+		for _, p := range placeholder.FindAllString(t, -1) {
+			if !slices.Contains(out, p) {
+				out = append(out, p)
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestDefault_everyKeyInEveryLocale(t *testing.T) {
+	c := Default()
+	require.Equal(t, []string{"en", "ru", "uk"}, c.Locales())
+
+	en := c.locales[DefaultLocale]
+	for _, locale := range c.Locales() {
+		msgs := c.locales[locale]
+		for key, want := range en {
+			got, ok := msgs[key]
+			require.True(t, ok, "%s: missing key %q", locale, key)
+			require.Equal(t, want.plural != nil, got.plural != nil, "%s: %q plural mismatch", locale, key)
+			require.Equal(t, placeholders(want), placeholders(got), "%s: %q placeholders", locale, key)
+		}
+		for key := range msgs {
+			_, ok := en[key]
+			require.True(t, ok, "%s: key %q not in %s", locale, key, DefaultLocale)
+		}
+	}
+}
+
+func TestDefault_pluralForms(t *testing.T) {
+	c := Default()
+	for locale, forms := range map[string][]string{"en": {"one", "other"}, "ru": {"one", "few", "many"}, "uk": {"one", "few", "many"}} {
+		for key, m := range c.locales[locale] {
+			if m.plural == nil {
+				continue
+			}
+			for _, f := range forms {
+				require.Contains(t, m.plural, f, "%s: %q", locale, key)
+			}
+		}
+	}
+}
+
+func TestLocalizer(t *testing.T) {
+	c, err := Load(fstest.MapFS{
+		"en.json": {Data: []byte(`{"hi": "Hi, {name}", "days": {"one": "{count} day", "other": "{count} days"}, "only_en": "fallback"}`)},
+		"ru.json": {Data: []byte(`{"hi": "Привет, {name}", "days": {"one": "{count} день", "few": "{count} дня", "many": "{count} дней", "other": "{count} дня"}}`)},
+	})
+	require.NoError(t, err)
+
+	en, ru := c.Localizer("en"), c.Localizer("ru-RU")
+	require.Equal(t, "ru", ru.Locale())
+	require.Equal(t, "en", c.Localizer("fr").Locale())
+	require.Equal(t, "en", c.Localizer("").Locale())
+
+	require.Equal(t, "Hi, Ann", en.T("hi", "name", "Ann"))
+	require.Equal(t, "Привет, {name}", ru.T("hi"))
+	require.Equal(t, "fallback", ru.T("only_en"))
+	require.Equal(t, "missing", ru.T("missing"))
+
+	tests := []struct {
+		n      int
+		en, ru string
+	}{
+		{0, "0 days", "0 дней"},
+		{1, "1 day", "1 день"},
+		{2, "2 days", "2 дня"},
+		{5, "5 days", "5 дней"},
+		{11, "11 days", "11 дней"},
+		{21, "21 days", "21 день"},
+		{22, "22 days", "22 дня"},
+		{112, "112 days", "112 дней"},
+		{-1, "-1 days", "-1 день"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.ru, ru.N("days", tt.n), tt.n)
+		if tt.n != -1 {
+			require.Equal(t, tt.en, en.N("days", tt.n), tt.n)
+		}
+	}
+}
+
+func TestLoad_errors(t *testing.T) {
+	_, err := Load(fstest.MapFS{"ru.json": {Data: []byte(`{}`)}})
+	require.ErrorContains(t, err, "missing en.json")
+
+	_, err = Load(fstest.MapFS{"en.json": {Data: []byte(`{"days": {"one": "day"}}`)}})
+	require.ErrorContains(t, err, "other")
+
+	_, err = Load(fstest.MapFS{"en.json": {Data: []byte(`{"n": 1}`)}})
+	require.Error(t, err)
+}
@@ -2,24 +2,75 @@ package broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// HandlerFunc processes a single message body; a non-nil error causes a negative ack and redelivery.
+// HandlerFunc processes a single message body; a non-nil error causes a negative ack and
+// redelivery, unless it is a rejection (see Reject).
 type HandlerFunc func(msg []byte) error
 
+// RejectError marks a message that can never be processed; see Reject.
+type RejectError struct {
+	Reason error
+}
+
+func (e *RejectError) Error() string { return "rejected: " + e.Reason.Error() }
+
+func (e *RejectError) Unwrap() error { return e.Reason }
+
+// Reject wraps reason so the consumer does not redeliver the message: it is moved to the
+// dead-letter queue with the reason in the RejectReasonHeader header, or dropped when the
+// consumer has none.
+func Reject(reason error) error {
+	return &RejectError{Reason: reason}
+}
+
+// Dead-letter message headers.
+const (
+	RejectReasonHeader  = "x-reject-reason"
+	OriginalQueueHeader = "x-original-queue"
+)
+
+// deadLetterTimeout bounds publishing a rejected message to the dead-letter queue.
+const deadLetterTimeout = 5 * time.Second
+
+// consumeChannel is the part of an AMQP channel a Consumer uses.
+type consumeChannel interface {
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
 // Consumer subscribes to a queue and dispatches deliveries to a HandlerFunc.
 type Consumer struct {
-	ch      *amqp.Channel
-	queue   string
-	handler HandlerFunc
+	ch         consumeChannel
+	queue      string
+	deadLetter string
+	handler    HandlerFunc
+}
+
+// ConsumerOption configures a Consumer.
+type ConsumerOption func(*Consumer)
+
+// WithDeadLetterQueue sends rejected messages to queue.
+func WithDeadLetterQueue(queue string) ConsumerOption {
+	return func(c *Consumer) { c.deadLetter = queue }
 }
 
 // NewConsumer builds a Consumer for queue on channel ch.
-func NewConsumer(ch *amqp.Channel, queue string, handler HandlerFunc) *Consumer {
-	return &Consumer{ch: ch, queue: queue, handler: handler}
+func NewConsumer(ch *amqp.Channel, queue string, handler HandlerFunc, opts ...ConsumerOption) *Consumer {
+	return newConsumer(ch, queue, handler, opts...)
+}
+
+func newConsumer(ch consumeChannel, queue string, handler HandlerFunc, opts ...ConsumerOption) *Consumer {
+	c := &Consumer{ch: ch, queue: queue, handler: handler}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Run starts a goroutine that consumes messages until ctx is canceled.
@@ -47,15 +98,45 @@ func (c *Consumer) Run(ctx context.Context) error {
 					continue
 				}
 
-				if err := c.handler(m.Body); err != nil {
+				err := c.handler(m.Body)
+				var rej *RejectError
+				switch {
+				case errors.As(err, &rej):
+					c.reject(ctx, m, rej)
+				case err != nil:
 					_ = m.Nack(false, true) //nolint:errcheck // broker will redeliver
-					continue
+				default:
+					_ = m.Ack(false) //nolint:errcheck // ack after successful handler
 				}
-
-				_ = m.Ack(false) //nolint:errcheck // ack after successful handler
 			}
 		}
 	}()
 
 	return nil
 }
+
+// reject moves m to the dead-letter queue, or drops it without one. If publishing fails the
+// message is requeued so it is not lost.
+func (c *Consumer) reject(ctx context.Context, m amqp.Delivery, rej *RejectError) {
+	if c.deadLetter == "" {
+		_ = m.Nack(false, false) //nolint:errcheck // dropped, or dead-lettered by the queue's DLX
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, deadLetterTimeout)
+	defer cancel()
+	err := c.ch.PublishWithContext(ctx, "", c.deadLetter, false, false, amqp.Publishing{
+		Headers: amqp.Table{
+			RejectReasonHeader:  rej.Reason.Error(),
+			OriginalQueueHeader: c.queue,
+		},
+		ContentType:  m.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         m.Body,
+	})
+	if err != nil {
+		_ = m.Nack(false, true) //nolint:errcheck // retry dead-lettering on redelivery
+		return
+	}
+	_ = m.Ack(false) //nolint:errcheck // moved to the dead-letter queue
+}
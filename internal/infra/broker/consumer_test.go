@@ -0,0 +1,122 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsumeChannel delivers the messages written to deliveries and records publishes.
+type fakeConsumeChannel struct {
+	deliveries chan amqp.Delivery
+
+	mu         sync.Mutex
+	publishErr error
+	published  map[string][]amqp.Publishing
+}
+
+func newFakeConsumeChannel() *fakeConsumeChannel {
+	return &fakeConsumeChannel{deliveries: make(chan amqp.Delivery), published: map[string][]amqp.Publishing{}}
+}
+
+func (c *fakeConsumeChannel) Consume(string, string, bool, bool, bool, bool, amqp.Table) (<-chan amqp.Delivery, error) {
+	return c.deliveries, nil
+}
+
+func (c *fakeConsumeChannel) PublishWithContext(_ context.Context, _, key string, _, _ bool, msg amqp.Publishing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.publishErr != nil {
+		return c.publishErr
+	}
+	c.published[key] = append(c.published[key], msg)
+	return nil
+}
+
+func (c *fakeConsumeChannel) publishedTo(key string) []amqp.Publishing {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.published[key]
+}
+
+// fakeAcknowledger reports how each delivery was settled: "ack", "nack", or "requeue".
+type fakeAcknowledger chan string
+
+func (a fakeAcknowledger) Ack(uint64, bool) error { a <- "ack"; return nil }
+
+func (a fakeAcknowledger) Nack(_ uint64, _, requeue bool) error {
+	if requeue {
+		a <- "requeue"
+	} else {
+		a <- "nack"
+	}
+	return nil
+}
+
+func (a fakeAcknowledger) Reject(_ uint64, requeue bool) error { return a.Nack(0, false, requeue) }
+
+// deliver sends body through ch and returns how the consumer settled it.
+func deliver(t *testing.T, ch *fakeConsumeChannel, body string) string {
+	t.Helper()
+	acks := make(fakeAcknowledger, 1)
+	ch.deliveries <- amqp.Delivery{Acknowledger: acks, ContentType: "application/json", Body: []byte(body)}
+	select {
+	case outcome := <-acks:
+		return outcome
+	case <-time.After(time.Second):
+		t.Fatalf("delivery %q was not settled", body)
+		return ""
+	}
+}
+
+func TestConsumer_Run(t *testing.T) {
+	handler := func(msg []byte) error {
+		switch string(msg) {
+		case "retry":
+			return errors.New("telegram: timeout")
+		case "invalid":
+			return fmt.Errorf("post: %w", Reject(errors.New("symbol is required")))
+		}
+		return nil
+	}
+
+	t.Run("dead-letter queue", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ch := newFakeConsumeChannel()
+		require.NoError(t, newConsumer(ch, "signals", handler, WithDeadLetterQueue("signals.dlq")).Run(ctx))
+
+		require.Equal(t, "ack", deliver(t, ch, "ok"))
+		require.Equal(t, "requeue", deliver(t, ch, "retry"))
+		require.Empty(t, ch.publishedTo("signals.dlq"))
+
+		require.Equal(t, "ack", deliver(t, ch, "invalid"))
+		dead := ch.publishedTo("signals.dlq")
+		require.Len(t, dead, 1)
+		require.Equal(t, "invalid", string(dead[0].Body))
+		require.Equal(t, "application/json", dead[0].ContentType)
+		require.Equal(t, amqp.Table{RejectReasonHeader: "symbol is required", OriginalQueueHeader: "signals"}, dead[0].Headers)
+
+		// A rejection that cannot be dead-lettered is requeued rather than lost.
+		ch.mu.Lock()
+		ch.publishErr = amqp.ErrClosed
+		ch.mu.Unlock()
+		require.Equal(t, "requeue", deliver(t, ch, "invalid"))
+	})
+
+	t.Run("no dead-letter queue", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ch := newFakeConsumeChannel()
+		require.NoError(t, newConsumer(ch, "signals", handler).Run(ctx))
+
+		require.Equal(t, "nack", deliver(t, ch, "invalid"))
+		require.Empty(t, ch.published)
+	})
+}
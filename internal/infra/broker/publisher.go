@@ -0,0 +1,400 @@
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Publish errors.
+var (
+	// ErrNacked is returned when the broker refuses to take responsibility for a message.
+	ErrNacked = errors.New("message nacked by the broker")
+	// ErrUnroutable is returned for messages no queue is bound to receive.
+	ErrUnroutable = errors.New("message returned as unroutable")
+	// ErrExpired is passed to the drop handler for outbox messages past their ExpiresAt.
+	ErrExpired = errors.New("message expired before it was delivered")
+)
+
+const (
+	// defaultReplayInterval is how often the outbox is replayed; see WithReplayInterval.
+	defaultReplayInterval = 30 * time.Second
+	// dialTimeout bounds connecting to the broker, so a publish fails fast while it is down.
+	dialTimeout = 5 * time.Second
+	// returnsBuffer holds returned messages until their publisher looks for them.
+	returnsBuffer = 256
+)
+
+// confirmation is a pending publisher confirm.
+type confirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// publishChannel is a channel in confirm mode that publishes mandatory messages; tests
+// substitute an in-process fake.
+type publishChannel interface {
+	Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (confirmation, error)
+	// Returns delivers the messages the broker could not route.
+	Returns() <-chan amqp.Return
+	IsClosed() bool
+	Close() error
+}
+
+// amqpPublishChannel is a publishChannel on a connection of its own, so publishing is not
+// blocked by the consumers' flow control and can reconnect independently.
+type amqpPublishChannel struct {
+	conn    *amqp.Connection
+	ch      *amqp.Channel
+	returns chan amqp.Return
+}
+
+func dialPublishChannel(url string) (publishChannel, error) {
+	conn, err := amqp.DialConfig(url, amqp.Config{
+		Heartbeat: 10 * time.Second,
+		Locale:    "en_US",
+		Dial:      amqp.DefaultDial(dialTimeout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("amqp dial: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck // rollback after failed channel
+		return nil, fmt.Errorf("amqp channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = conn.Close() //nolint:errcheck // rollback after failed confirm mode
+		return nil, fmt.Errorf("amqp confirm mode: %w", err)
+	}
+	returns := ch.NotifyReturn(make(chan amqp.Return, returnsBuffer))
+	return &amqpPublishChannel{conn: conn, ch: ch, returns: returns}, nil
+}
+
+func (c *amqpPublishChannel) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (confirmation, error) {
+	dc, err := c.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, true, false, msg)
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+func (c *amqpPublishChannel) Returns() <-chan amqp.Return { return c.returns }
+
+func (c *amqpPublishChannel) IsClosed() bool { return c.ch.IsClosed() }
+
+func (c *amqpPublishChannel) Close() error {
+	return c.conn.Close()
+}
+
+// Publisher publishes messages with publisher confirms and mandatory routing: Publish returns
+// once the broker has routed the message to a queue and confirmed it. It dials a connection of
+// its own on first use and again after failures. With an outbox, messages are stored before
+// they are sent and kept until confirmed, and RunReplay retries them while the broker is down.
+type Publisher struct {
+	dial     func() (publishChannel, error)
+	outbox   ports.Outbox
+	logger   ports.Logger
+	interval time.Duration
+	onDrop   func(m ports.OutboundMessage, reason error)
+
+	mu       sync.Mutex
+	ch       publishChannel
+	returned map[string]amqp.Return // by message ID
+	inflight map[string]bool        // IDs being published, skipped by Replay
+}
+
+// PublisherOption configures a Publisher.
+type PublisherOption func(*Publisher)
+
+// WithOutbox stores messages in outbox until the broker confirms them.
+func WithOutbox(outbox ports.Outbox) PublisherOption {
+	return func(p *Publisher) { p.outbox = outbox }
+}
+
+// WithReplayInterval sets how often RunReplay retries the outbox; the default is 30 seconds.
+func WithReplayInterval(d time.Duration) PublisherOption {
+	return func(p *Publisher) { p.interval = d }
+}
+
+// WithPublisherLogger logs replay results and dropped outbox messages to logger.
+func WithPublisherLogger(logger ports.Logger) PublisherOption {
+	return func(p *Publisher) { p.logger = logger }
+}
+
+// WithDropHandler calls fn with every outbox message Replay drops, with the reason wrapping
+// ErrUnroutable or ErrExpired.
+func WithDropHandler(fn func(m ports.OutboundMessage, reason error)) PublisherOption {
+	return func(p *Publisher) { p.onDrop = fn }
+}
+
+// NewPublisher returns a Publisher for the broker at url; it connects on first use.
+func NewPublisher(url string, opts ...PublisherOption) *Publisher {
+	return newPublisher(func() (publishChannel, error) { return dialPublishChannel(url) }, opts...)
+}
+
+func newPublisher(dial func() (publishChannel, error), opts ...PublisherOption) *Publisher {
+	p := &Publisher{
+		dial:     dial,
+		interval: defaultReplayInterval,
+		returned: map[string]amqp.Return{},
+		inflight: map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Publish sends m and waits for the broker's confirmation, at most until ctx is done. An
+// empty ID is generated. Messages no queue receives fail with ErrUnroutable and are not
+// retried. With an outbox, a message the broker did not confirm is kept for RunReplay and the
+// error wraps ports.ErrDeferred.
+func (p *Publisher) Publish(ctx context.Context, m ports.OutboundMessage) error {
+	if m.ID == "" {
+		m.ID = newMessageID()
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().UTC()
+	}
+	if p.outbox == nil {
+		return p.publish(ctx, m)
+	}
+
+	p.setInflight(m.ID, true)
+	defer p.setInflight(m.ID, false)
+	if err := p.outbox.Put(m); err != nil {
+		return fmt.Errorf("publish %s: %w", m.ID, err)
+	}
+	err := p.publish(ctx, m)
+	if err != nil && !errors.Is(err, ErrUnroutable) {
+		p.keep(m, err)
+		return fmt.Errorf("%w: %w", ports.ErrDeferred, err)
+	}
+	if rmErr := p.outbox.Remove(m.ID); rmErr != nil {
+		p.logError("failed to remove a published message from the outbox", "id", m.ID, "error", rmErr)
+	}
+	return err
+}
+
+// Replay publishes the outbox oldest first and stops at the first message the broker does not
+// confirm. Unroutable and expired messages are dropped and passed to the drop handler. It
+// returns how many messages were delivered.
+func (p *Publisher) Replay(ctx context.Context) (int, error) {
+	if p.outbox == nil {
+		return 0, nil
+	}
+	pending, err := p.outbox.Pending()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, m := range pending {
+		if !p.setInflight(m.ID, true) {
+			continue
+		}
+		var err error
+		if !m.ExpiresAt.IsZero() && !time.Now().Before(m.ExpiresAt) {
+			err = fmt.Errorf("outbox message %s: %w", m.ID, ErrExpired)
+		} else {
+			err = p.publish(ctx, m)
+		}
+		var dropped error
+		switch {
+		case errors.Is(err, ErrUnroutable), errors.Is(err, ErrExpired):
+			p.logError("dropped outbox message", "id", m.ID, "exchange", m.Exchange, "routing_key", m.RoutingKey, "error", err)
+			dropped = err
+		case err != nil:
+			p.keep(m, err)
+			p.setInflight(m.ID, false)
+			return sent, fmt.Errorf("replay %s: %w", m.ID, err)
+		default:
+			sent++
+		}
+		err = p.outbox.Remove(m.ID)
+		p.setInflight(m.ID, false)
+		if err != nil {
+			return sent, err
+		}
+		if dropped != nil && p.onDrop != nil {
+			p.onDrop(m, dropped)
+		}
+	}
+	return sent, nil
+}
+
+// RunReplay starts a goroutine that replays the outbox right away and then every replay
+// interval until ctx is canceled.
+func (p *Publisher) RunReplay(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			n, err := p.Replay(ctx)
+			if err != nil {
+				p.logError("outbox replay stopped", "delivered", n, "error", err)
+			} else if n > 0 && p.logger != nil {
+				p.logger.Info("outbox replayed", "delivered", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close closes the publisher's connection; the next Publish dials again.
+func (p *Publisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ch != nil {
+		_ = p.ch.Close() //nolint:errcheck // best-effort shutdown
+		p.ch = nil
+	}
+}
+
+// publish sends m once on the current channel and waits for its confirm.
+func (p *Publisher) publish(ctx context.Context, m ports.OutboundMessage) error {
+	ch, err := p.channel()
+	if err != nil {
+		return fmt.Errorf("amqp publish %s: %w", m.ID, err)
+	}
+	conf, err := ch.Publish(ctx, m.Exchange, m.RoutingKey, publishing(m))
+	if err != nil {
+		p.reset(ch)
+		return fmt.Errorf("amqp publish %s to %q/%q: %w", m.ID, m.Exchange, m.RoutingKey, err)
+	}
+	ok, err := conf.WaitContext(ctx)
+	returned := p.takeReturn(ch, m.ID)
+	switch {
+	case err != nil:
+		return fmt.Errorf("amqp publish %s: wait for confirm: %w", m.ID, err)
+	case returned:
+		return fmt.Errorf("amqp publish %s to %q/%q: %w", m.ID, m.Exchange, m.RoutingKey, ErrUnroutable)
+	case !ok:
+		if ch.IsClosed() {
+			p.reset(ch)
+		}
+		return fmt.Errorf("amqp publish %s: %w", m.ID, ErrNacked)
+	}
+	return nil
+}
+
+// channel returns the current channel, dialing a new one if needed.
+func (p *Publisher) channel() (publishChannel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ch == nil {
+		ch, err := p.dial()
+		if err != nil {
+			return nil, err
+		}
+		p.ch = ch
+	}
+	return p.ch, nil
+}
+
+// reset drops ch after a failure so the next publish dials again.
+func (p *Publisher) reset(ch publishChannel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ch == ch {
+		_ = ch.Close() //nolint:errcheck // already failed
+		p.ch = nil
+	}
+}
+
+// takeReturn reports whether the broker returned message id. The broker sends a return before
+// the confirm of the same message, so once the confirm arrived the return is buffered on ch.
+func (p *Publisher) takeReturn(ch publishChannel, id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for drained := false; !drained; {
+		select {
+		case r, ok := <-ch.Returns():
+			if !ok {
+				drained = true
+				continue
+			}
+			p.returned[r.MessageId] = r
+		default:
+			drained = true
+		}
+	}
+	_, ok := p.returned[id]
+	delete(p.returned, id)
+	return ok
+}
+
+// setInflight marks message id as being published, or clears the mark. Marking reports false
+// when the message is already in flight.
+func (p *Publisher) setInflight(id string, on bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !on {
+		delete(p.inflight, id)
+		return true
+	}
+	if p.inflight[id] {
+		return false
+	}
+	p.inflight[id] = true
+	return true
+}
+
+// keep records the failed delivery of m in the outbox.
+func (p *Publisher) keep(m ports.OutboundMessage, cause error) {
+	m.Attempts++
+	m.LastError = cause.Error()
+	if err := p.outbox.Put(m); err != nil {
+		p.logError("failed to update an outbox message", "id", m.ID, "error", err)
+	}
+}
+
+func (p *Publisher) logError(msg string, keysAndValues ...any) {
+	if p.logger != nil {
+		p.logger.Error(msg, keysAndValues...)
+	}
+}
+
+// publishing converts m to a persistent AMQP message that expires with m.
+func publishing(m ports.OutboundMessage) amqp.Publishing {
+	var expiration string
+	if !m.ExpiresAt.IsZero() {
+		expiration = strconv.FormatInt(max(time.Until(m.ExpiresAt).Milliseconds(), 1), 10)
+	}
+	var headers amqp.Table
+	if len(m.Headers) > 0 {
+		headers = make(amqp.Table, len(m.Headers))
+		for k, v := range m.Headers {
+			headers[k] = v
+		}
+	}
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   m.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: m.CorrelationID,
+		ReplyTo:       m.ReplyTo,
+		MessageId:     m.ID,
+		Timestamp:     m.CreatedAt,
+		Expiration:    expiration,
+		Body:          m.Body,
+	}
+}
+
+// newMessageID returns a random 128-bit hex ID.
+func newMessageID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) //nolint:errcheck // crypto/rand.Read never fails
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,277 @@
+package broker
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker is an in-process broker: routing keys in queues are routable, everything else is
+// returned. While down, dialing fails and open channels are closed on their next publish.
+type fakeBroker struct {
+	mu        sync.Mutex
+	queues    map[string]bool
+	down      bool
+	nack      bool
+	dials     int
+	published []amqp.Publishing
+}
+
+func newFakeBroker(queues ...string) *fakeBroker {
+	b := &fakeBroker{queues: map[string]bool{}}
+	for _, q := range queues {
+		b.queues[q] = true
+	}
+	return b
+}
+
+func (b *fakeBroker) dial() (publishChannel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.down {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+	b.dials++
+	return &fakeChannel{b: b, returns: make(chan amqp.Return, returnsBuffer)}, nil
+}
+
+func (b *fakeBroker) setDown(down bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.down = down
+}
+
+func (b *fakeBroker) bodies() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []string
+	for _, m := range b.published {
+		out = append(out, string(m.Body))
+	}
+	return out
+}
+
+type fakeChannel struct {
+	b       *fakeBroker
+	returns chan amqp.Return
+	closed  bool
+}
+
+type fakeConfirm bool
+
+func (c fakeConfirm) WaitContext(context.Context) (bool, error) { return bool(c), nil }
+
+func (c *fakeChannel) Publish(_ context.Context, exchange, key string, msg amqp.Publishing) (confirmation, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	if c.b.down {
+		c.closed = true
+	}
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	if !c.b.queues[key] {
+		// Like RabbitMQ, the return precedes the (positive) confirm.
+		c.returns <- amqp.Return{ReplyCode: amqp.NoRoute, Exchange: exchange, RoutingKey: key, MessageId: msg.MessageId}
+		return fakeConfirm(true), nil
+	}
+	if c.b.nack {
+		return fakeConfirm(false), nil
+	}
+	c.b.published = append(c.b.published, msg)
+	return fakeConfirm(true), nil
+}
+
+func (c *fakeChannel) Returns() <-chan amqp.Return { return c.returns }
+
+func (c *fakeChannel) IsClosed() bool {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	return c.closed
+}
+
+func (c *fakeChannel) Close() error {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+type memOutbox struct {
+	mu   sync.Mutex
+	msgs map[string]ports.OutboundMessage
+}
+
+func (o *memOutbox) Put(m ports.OutboundMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.msgs == nil {
+		o.msgs = map[string]ports.OutboundMessage{}
+	}
+	o.msgs[m.ID] = m
+	return nil
+}
+
+func (o *memOutbox) Remove(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.msgs, id)
+	return nil
+}
+
+func (o *memOutbox) Pending() ([]ports.OutboundMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := slices.Collect(maps.Values(o.msgs))
+	slices.SortFunc(out, func(a, b ports.OutboundMessage) int { return cmp.Compare(a.ID, b.ID) })
+	return out, nil
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	b := newFakeBroker("commands")
+	p := newPublisher(b.dial)
+	ctx := context.Background()
+
+	at := time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)
+	require.NoError(t, p.Publish(ctx, ports.OutboundMessage{
+		ID: "m1", RoutingKey: "commands", ContentType: "application/json", CorrelationID: "m1", ReplyTo: "replies",
+		Headers: map[string]string{"x-source": "bot"}, Body: []byte(`{}`), CreatedAt: at,
+	}))
+	require.Len(t, b.published, 1)
+	got := b.published[0]
+	require.Equal(t, "m1", got.MessageId)
+	require.Equal(t, "m1", got.CorrelationId)
+	require.Equal(t, "replies", got.ReplyTo)
+	require.Equal(t, amqp.Table{"x-source": "bot"}, got.Headers)
+	require.Equal(t, amqp.Persistent, got.DeliveryMode)
+	require.Equal(t, at, got.Timestamp)
+
+	require.NoError(t, p.Publish(ctx, ports.OutboundMessage{RoutingKey: "commands", Body: []byte(`{}`)}))
+	require.Len(t, b.published[1].MessageId, 32, "generated ID")
+	require.Equal(t, 1, b.dials)
+
+	err := p.Publish(ctx, ports.OutboundMessage{ID: "m3", RoutingKey: "nowhere"})
+	require.ErrorIs(t, err, ErrUnroutable)
+
+	b.nack = true
+	err = p.Publish(ctx, ports.OutboundMessage{ID: "m4", RoutingKey: "commands"})
+	require.ErrorIs(t, err, ErrNacked)
+	require.NotErrorIs(t, err, ports.ErrDeferred)
+}
+
+func TestPublisher_outbox(t *testing.T) {
+	b := newFakeBroker("commands")
+	outbox := &memOutbox{}
+	var dropped []string
+	p := newPublisher(b.dial, WithOutbox(outbox), WithDropHandler(func(m ports.OutboundMessage, reason error) {
+		require.ErrorIs(t, reason, ErrUnroutable)
+		dropped = append(dropped, m.ID)
+	}))
+	ctx := context.Background()
+
+	require.NoError(t, p.Publish(ctx, ports.OutboundMessage{ID: "a", RoutingKey: "commands", Body: []byte("a")}))
+	pending, _ := outbox.Pending()
+	require.Empty(t, pending, "confirmed messages leave the outbox")
+
+	// While the broker is down messages are kept, in order, with their failures.
+	b.setDown(true)
+	err := p.Publish(ctx, ports.OutboundMessage{ID: "b", RoutingKey: "commands", Body: []byte("b")})
+	require.ErrorIs(t, err, ports.ErrDeferred)
+	require.ErrorIs(t, err, amqp.ErrClosed)
+	err = p.Publish(ctx, ports.OutboundMessage{ID: "c", RoutingKey: "nowhere", Body: []byte("c")})
+	require.ErrorIs(t, err, ports.ErrDeferred)
+	require.ErrorContains(t, err, "connection refused")
+	err = p.Publish(ctx, ports.OutboundMessage{ID: "d", RoutingKey: "commands", Body: []byte("d")})
+	require.ErrorIs(t, err, ports.ErrDeferred)
+
+	n, err := p.Replay(ctx)
+	require.Error(t, err)
+	require.Zero(t, n)
+	pending, _ = outbox.Pending()
+	require.Len(t, pending, 3)
+	require.Equal(t, "b", pending[0].ID)
+	require.Equal(t, 2, pending[0].Attempts)
+	require.Contains(t, pending[0].LastError, "connection refused")
+	require.Equal(t, 1, pending[1].Attempts, "replay stops at the first failure")
+
+	// Once it is back, replay redials, delivers in order and drops what cannot be routed.
+	b.setDown(false)
+	n, err = p.Replay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []string{"a", "b", "d"}, b.bodies())
+	require.Equal(t, 2, b.dials)
+	require.Equal(t, []string{"c"}, dropped)
+	pending, _ = outbox.Pending()
+	require.Empty(t, pending)
+
+	// Nacked messages are kept too.
+	b.nack = true
+	err = p.Publish(ctx, ports.OutboundMessage{ID: "e", RoutingKey: "commands"})
+	require.ErrorIs(t, err, ports.ErrDeferred)
+	require.ErrorIs(t, err, ErrNacked)
+	pending, _ = outbox.Pending()
+	require.Len(t, pending, 1)
+}
+
+func TestPublisher_expiry(t *testing.T) {
+	b := newFakeBroker("commands")
+	outbox := &memOutbox{}
+	var dropped []string
+	p := newPublisher(b.dial, WithOutbox(outbox), WithDropHandler(func(m ports.OutboundMessage, reason error) {
+		require.ErrorIs(t, reason, ErrExpired)
+		dropped = append(dropped, m.ID)
+	}))
+	ctx := context.Background()
+
+	// Messages carry their remaining lifetime as the AMQP expiration.
+	require.NoError(t, p.Publish(ctx, ports.OutboundMessage{ID: "a", RoutingKey: "commands", ExpiresAt: time.Now().Add(time.Minute)}))
+	exp, err := strconv.Atoi(b.published[0].Expiration)
+	require.NoError(t, err)
+	require.InDelta(t, 60000, exp, 1000)
+
+	// Stale messages are dropped from the outbox instead of replayed.
+	now := time.Now()
+	require.NoError(t, outbox.Put(ports.OutboundMessage{ID: "b", RoutingKey: "commands", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, outbox.Put(ports.OutboundMessage{ID: "c", RoutingKey: "commands", CreatedAt: now, Body: []byte("c")}))
+	n, err := p.Replay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"b"}, dropped)
+	require.Equal(t, []string{"", "c"}, b.bodies())
+	require.Empty(t, b.published[1].Expiration)
+	pending, _ := outbox.Pending()
+	require.Empty(t, pending)
+}
+
+func TestPublisher_RunReplay(t *testing.T) {
+	b := newFakeBroker("commands")
+	outbox := &memOutbox{}
+	require.NoError(t, outbox.Put(ports.OutboundMessage{ID: "a", RoutingKey: "commands", Body: []byte("a")}))
+	p := newPublisher(b.dial, WithOutbox(outbox), WithReplayInterval(10*time.Millisecond))
+	defer p.Close()
+
+	b.setDown(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.RunReplay(ctx)
+	time.Sleep(30 * time.Millisecond)
+	require.Empty(t, b.bodies())
+
+	b.setDown(false)
+	require.Eventually(t, func() bool {
+		pending, _ := outbox.Pending()
+		return len(pending) == 0
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"a"}, b.bodies())
+}
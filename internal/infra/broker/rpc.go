@@ -0,0 +1,290 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// directReplyTo is RabbitMQ's pseudo-queue for replies to the publishing channel.
+	directReplyTo = "amq.rabbitmq.reply-to"
+	// defaultRPCTimeout bounds a call whose context has no earlier deadline; see WithRPCTimeout.
+	defaultRPCTimeout = 10 * time.Second
+	// rpcErrorHeader carries the remote procedure's error message in a reply.
+	rpcErrorHeader = "error"
+)
+
+// ErrRPCClosed fails the calls in flight when the channel they were sent on closes.
+var ErrRPCClosed = errors.New("rpc channel closed before the reply")
+
+// rpcChannel is a channel consuming direct replies; tests substitute an in-process fake.
+type rpcChannel interface {
+	// Publish sends a mandatory message.
+	Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error
+	// Replies delivers the replies to the channel's requests; it is closed with the channel.
+	Replies() <-chan amqp.Delivery
+	// Returns delivers the requests the broker could not route.
+	Returns() <-chan amqp.Return
+	Close() error
+}
+
+// amqpRPCChannel is an rpcChannel on a connection of its own.
+type amqpRPCChannel struct {
+	conn    *amqp.Connection
+	ch      *amqp.Channel
+	replies <-chan amqp.Delivery
+	returns chan amqp.Return
+}
+
+func dialRPCChannel(url string) (rpcChannel, error) {
+	conn, err := amqp.DialConfig(url, amqp.Config{
+		Heartbeat: 10 * time.Second,
+		Locale:    "en_US",
+		Dial:      amqp.DefaultDial(dialTimeout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("amqp dial: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck // rollback after failed channel
+		return nil, fmt.Errorf("amqp channel: %w", err)
+	}
+	// Direct reply-to requires consuming in no-ack mode before publishing on the same channel.
+	replies, err := ch.Consume(directReplyTo, "", true, false, false, false, nil)
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck // rollback after failed consume
+		return nil, fmt.Errorf("amqp consume %s: %w", directReplyTo, err)
+	}
+	returns := ch.NotifyReturn(make(chan amqp.Return, returnsBuffer))
+	return &amqpRPCChannel{conn: conn, ch: ch, replies: replies, returns: returns}, nil
+}
+
+func (c *amqpRPCChannel) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	return c.ch.PublishWithContext(ctx, exchange, key, true, false, msg)
+}
+
+func (c *amqpRPCChannel) Replies() <-chan amqp.Delivery { return c.replies }
+
+func (c *amqpRPCChannel) Returns() <-chan amqp.Return { return c.returns }
+
+func (c *amqpRPCChannel) Close() error {
+	return c.conn.Close()
+}
+
+// rpcCall is a call waiting for its reply on channel ch.
+type rpcCall struct {
+	ch   rpcChannel
+	done chan rpcResult
+}
+
+type rpcResult struct {
+	reply amqp.Delivery
+	err   error
+}
+
+// RPCClient is a ports.RPCClient over AMQP: requests are published to an exchange with a routing
+// key, named by their message type, and the core replies through direct reply-to with the
+// request's correlation ID. A reply with an "error" header is a ports.RemoteError. The client
+// dials a connection of its own on first use and again after failures.
+type RPCClient struct {
+	dial     func() (rpcChannel, error)
+	exchange string
+	key      string
+	timeout  time.Duration
+	logger   ports.Logger
+
+	mu      sync.Mutex
+	ch      rpcChannel
+	pending map[string]rpcCall // by correlation ID
+}
+
+// RPCOption configures an RPCClient.
+type RPCOption func(*RPCClient)
+
+// WithRPCTimeout bounds every call; the default is 10 seconds. Calls end earlier when their
+// context is done.
+func WithRPCTimeout(d time.Duration) RPCOption {
+	return func(c *RPCClient) { c.timeout = d }
+}
+
+// WithRPCLogger logs replies that arrive too late for their call.
+func WithRPCLogger(logger ports.Logger) RPCOption {
+	return func(c *RPCClient) { c.logger = logger }
+}
+
+// NewRPCClient returns an RPCClient publishing requests to exchange with routing key key on the
+// broker at url; it connects on first use.
+func NewRPCClient(url, exchange, key string, opts ...RPCOption) *RPCClient {
+	return newRPCClient(func() (rpcChannel, error) { return dialRPCChannel(url) }, exchange, key, opts...)
+}
+
+func newRPCClient(dial func() (rpcChannel, error), exchange, key string, opts ...RPCOption) *RPCClient {
+	c := &RPCClient{dial: dial, exchange: exchange, key: key, timeout: defaultRPCTimeout, pending: map[string]rpcCall{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Call implements ports.RPCClient. The request expires in the broker once the call times out,
+// so a core that was down does not act on requests nobody waits for.
+func (c *RPCClient) Call(ctx context.Context, method string, params, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("rpc %s: encode params: %w", method, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	ch, err := c.channel()
+	if err != nil {
+		return fmt.Errorf("rpc %s: %w", method, err)
+	}
+	id := newMessageID()
+	done := c.register(id, ch)
+	defer c.unregister(id)
+
+	msg := amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: id,
+		MessageId:     id,
+		ReplyTo:       directReplyTo,
+		Type:          method,
+		Timestamp:     time.Now().UTC(),
+		Body:          body,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		msg.Expiration = strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 1), 10)
+	}
+	if err := ch.Publish(ctx, c.exchange, c.key, msg); err != nil {
+		c.reset(ch)
+		return fmt.Errorf("rpc %s: publish: %w", method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("rpc %s: %w", method, ctx.Err())
+	case res := <-done:
+		if res.err != nil {
+			return fmt.Errorf("rpc %s: %w", method, res.err)
+		}
+		if msg, ok := res.reply.Headers[rpcErrorHeader].(string); ok {
+			return &ports.RemoteError{Method: method, Message: msg}
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(res.reply.Body, result); err != nil {
+			return fmt.Errorf("rpc %s: decode result: %w", method, err)
+		}
+		return nil
+	}
+}
+
+// Close closes the client's connection; calls in flight fail and the next call dials again.
+func (c *RPCClient) Close() {
+	c.mu.Lock()
+	ch := c.ch
+	c.ch = nil
+	c.mu.Unlock()
+	if ch != nil {
+		_ = ch.Close() //nolint:errcheck // best-effort shutdown
+	}
+}
+
+// channel returns the current channel, dialing a new one and dispatching its replies if needed.
+func (c *RPCClient) channel() (rpcChannel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ch == nil {
+		ch, err := c.dial()
+		if err != nil {
+			return nil, err
+		}
+		c.ch = ch
+		go c.dispatch(ch)
+	}
+	return c.ch, nil
+}
+
+// dispatch hands the replies and returns of ch to their calls until ch closes, then fails the
+// calls still waiting on it.
+func (c *RPCClient) dispatch(ch rpcChannel) {
+	replies, returns := ch.Replies(), ch.Returns()
+	for {
+		select {
+		case d, ok := <-replies:
+			if !ok {
+				c.reset(ch)
+				c.failAll(ch)
+				return
+			}
+			if !c.deliver(d.CorrelationId, rpcResult{reply: d}) && c.logger != nil {
+				c.logger.Debug("dropped rpc reply without a waiting call", "correlation_id", d.CorrelationId, "type", d.Type)
+			}
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.deliver(r.CorrelationId, rpcResult{err: fmt.Errorf("%q/%q: %w", r.Exchange, r.RoutingKey, ErrUnroutable)})
+		}
+	}
+}
+
+// deliver completes call id with res; it reports false when no call waits for it.
+func (c *RPCClient) deliver(id string, res rpcResult) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call, ok := c.pending[id]
+	if !ok {
+		return false
+	}
+	delete(c.pending, id)
+	call.done <- res
+	return true
+}
+
+func (c *RPCClient) failAll(ch rpcChannel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, call := range c.pending {
+		if call.ch == ch {
+			delete(c.pending, id)
+			call.done <- rpcResult{err: ErrRPCClosed}
+		}
+	}
+}
+
+func (c *RPCClient) register(id string, ch rpcChannel) <-chan rpcResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	done := make(chan rpcResult, 1)
+	c.pending[id] = rpcCall{ch: ch, done: done}
+	return done
+}
+
+func (c *RPCClient) unregister(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// reset drops ch after a failure so the next call dials again.
+func (c *RPCClient) reset(ch rpcChannel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ch == ch {
+		_ = ch.Close() //nolint:errcheck // already failed
+		c.ch = nil
+	}
+}
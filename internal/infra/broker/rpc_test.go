@@ -0,0 +1,238 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResponse is how the fake core answers a request: after delay, with result or an error
+// message. Requests with drop set are never answered.
+type fakeResponse struct {
+	result any
+	errMsg string
+	delay  time.Duration
+	drop   bool
+}
+
+// fakeResponder is an in-process broker with the trading core behind the "core.rpc" key.
+type fakeResponder struct {
+	respond func(method string, params json.RawMessage) fakeResponse
+
+	mu       sync.Mutex
+	down     bool
+	dials    int
+	requests []amqp.Publishing
+	channels []*fakeRPCChannel
+}
+
+func (f *fakeResponder) dial() (rpcChannel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+	f.dials++
+	ch := &fakeRPCChannel{f: f, replies: make(chan amqp.Delivery, 16), returns: make(chan amqp.Return, 16)}
+	f.channels = append(f.channels, ch)
+	return ch, nil
+}
+
+func (f *fakeResponder) lastRequest() amqp.Publishing {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1]
+}
+
+type fakeRPCChannel struct {
+	f       *fakeResponder
+	mu      sync.Mutex
+	closed  bool
+	replies chan amqp.Delivery
+	returns chan amqp.Return
+}
+
+func (c *fakeRPCChannel) Publish(_ context.Context, _, key string, msg amqp.Publishing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return amqp.ErrClosed
+	}
+	c.f.mu.Lock()
+	c.f.requests = append(c.f.requests, msg)
+	c.f.mu.Unlock()
+	if key != "core.rpc" {
+		c.returns <- amqp.Return{ReplyCode: amqp.NoRoute, RoutingKey: key, CorrelationId: msg.CorrelationId}
+		return nil
+	}
+	resp := c.f.respond(msg.Type, msg.Body)
+	if resp.drop {
+		return nil
+	}
+	go func() {
+		time.Sleep(resp.delay)
+		d := amqp.Delivery{CorrelationId: msg.CorrelationId, Type: msg.Type}
+		if resp.errMsg != "" {
+			d.Headers = amqp.Table{rpcErrorHeader: resp.errMsg}
+		} else {
+			d.Body, _ = json.Marshal(resp.result) //nolint:errcheck // test fake
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if !c.closed {
+			c.replies <- d
+		}
+	}()
+	return nil
+}
+
+func (c *fakeRPCChannel) Replies() <-chan amqp.Delivery { return c.replies }
+
+func (c *fakeRPCChannel) Returns() <-chan amqp.Return { return c.returns }
+
+func (c *fakeRPCChannel) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.replies)
+		close(c.returns)
+	}
+	return nil
+}
+
+type echoParams struct {
+	N     int           `json:"n"`
+	Delay time.Duration `json:"delay"`
+}
+
+// echo answers {"n": n} with {"n": n * 10} after the requested delay; method "fail" errors and
+// method "ignore" is never answered.
+func echo(method string, params json.RawMessage) fakeResponse {
+	var p echoParams
+	_ = json.Unmarshal(params, &p) //nolint:errcheck // test fake
+	switch method {
+	case "fail":
+		return fakeResponse{errMsg: "unknown account"}
+	case "ignore":
+		return fakeResponse{drop: true}
+	}
+	return fakeResponse{result: map[string]int{"n": p.N * 10}, delay: p.Delay}
+}
+
+func TestRPCClient_Call(t *testing.T) {
+	f := &fakeResponder{respond: echo}
+	c := newRPCClient(f.dial, "", "core.rpc", WithRPCTimeout(time.Second))
+	defer c.Close()
+	ctx := context.Background()
+
+	var got struct{ N int }
+	require.NoError(t, c.Call(ctx, "positions", echoParams{N: 4}, &got))
+	require.Equal(t, 40, got.N)
+	req := f.lastRequest()
+	require.Equal(t, "positions", req.Type)
+	require.Equal(t, directReplyTo, req.ReplyTo)
+	require.Equal(t, req.MessageId, req.CorrelationId)
+	require.Equal(t, "application/json", req.ContentType)
+	ttl, err := strconv.Atoi(req.Expiration)
+	require.NoError(t, err)
+	require.InDelta(t, 1000, ttl, 100, "requests expire with the call")
+
+	// Replies are matched by correlation ID, whatever order they come back in.
+	var wg sync.WaitGroup
+	results := make([]int, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var r struct{ N int }
+			require.NoError(t, c.Call(ctx, "positions", echoParams{N: i, Delay: time.Duration(5-i) * 10 * time.Millisecond}, &r))
+			results[i] = r.N
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, []int{0, 10, 20, 30, 40}, results)
+	require.Equal(t, 1, f.dials)
+
+	var remote *ports.RemoteError
+	err = c.Call(ctx, "fail", nil, nil)
+	require.ErrorAs(t, err, &remote)
+	require.Equal(t, &ports.RemoteError{Method: "fail", Message: "unknown account"}, remote)
+
+	require.NoError(t, c.Call(ctx, "positions", echoParams{N: 1}, nil), "the result may be ignored")
+}
+
+func TestRPCClient_timeouts(t *testing.T) {
+	f := &fakeResponder{respond: echo}
+	c := newRPCClient(f.dial, "", "core.rpc", WithRPCTimeout(50*time.Millisecond))
+	defer c.Close()
+
+	start := time.Now()
+	err := c.Call(context.Background(), "ignore", nil, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+
+	// A reply arriving after its call timed out is dropped; later calls are unaffected.
+	err = c.Call(context.Background(), "slow", echoParams{N: 1, Delay: 80 * time.Millisecond}, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	time.Sleep(50 * time.Millisecond)
+	var got struct{ N int }
+	require.NoError(t, c.Call(context.Background(), "fast", echoParams{N: 2}, &got))
+	require.Equal(t, 20, got.N)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err = c.Call(ctx, "ignore", nil, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRPCClient_failures(t *testing.T) {
+	f := &fakeResponder{respond: echo}
+	c := newRPCClient(f.dial, "", "core.rpc", WithRPCTimeout(time.Second))
+	defer c.Close()
+	ctx := context.Background()
+
+	// Requests no queue receives fail without waiting for the timeout.
+	start := time.Now()
+	unroutable := newRPCClient(f.dial, "", "nowhere")
+	defer unroutable.Close()
+	err := unroutable.Call(ctx, "positions", nil, nil)
+	require.ErrorIs(t, err, ErrUnroutable)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// Calls in flight fail when their channel closes, and the next call dials again.
+	errs := make(chan error, 1)
+	go func() { errs <- c.Call(ctx, "ignore", nil, nil) }()
+	require.Eventually(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return len(f.requests) == 2
+	}, time.Second, 5*time.Millisecond)
+	f.mu.Lock()
+	ch := f.channels[len(f.channels)-1]
+	f.mu.Unlock()
+	require.NoError(t, ch.Close())
+	require.ErrorIs(t, <-errs, ErrRPCClosed)
+
+	dials := f.dials
+	require.Eventually(t, func() bool { return c.Call(ctx, "positions", nil, nil) == nil }, time.Second, 5*time.Millisecond)
+	require.Equal(t, dials+1, f.dials)
+
+	f.mu.Lock()
+	f.down = true
+	f.mu.Unlock()
+	c.Close()
+	err = c.Call(ctx, "positions", nil, nil)
+	require.ErrorContains(t, err, "connection refused")
+}
@@ -0,0 +1,213 @@
+// Package chart renders PnL charts as PNG images without external dependencies.
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+const (
+	width     = 1000
+	height    = 500
+	scale     = 2
+	padTop    = 20
+	padRight  = 24
+	padBottom = 44
+	padLabel  = 10
+	yTicks    = 5
+	xLabels   = 6
+)
+
+var (
+	colBackground = color.RGBA{255, 255, 255, 255}
+	colGrid       = color.RGBA{230, 230, 230, 255}
+	colAxis       = color.RGBA{90, 90, 90, 255}
+	colText       = color.RGBA{50, 50, 50, 255}
+	colZero       = color.RGBA{150, 150, 150, 255}
+	colLine       = color.RGBA{33, 110, 220, 255}
+	colDrawdown   = color.RGBA{244, 199, 199, 255}
+)
+
+// ErrNoData is returned when there is nothing to plot.
+var ErrNoData = errors.New("chart: no data points")
+
+// Renderer draws cumulative PnL line charts; it implements ports.ChartRenderer.
+type Renderer struct{}
+
+// NewRenderer returns a Renderer.
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// RenderPnL plots the cumulative sum of daily PnL with the drawdown from the running peak shaded
+// and returns the chart encoded as PNG.
+func (r *Renderer) RenderPnL(series []domain.DailyPnL) ([]byte, error) {
+	if len(series) == 0 {
+		return nil, ErrNoData
+	}
+
+	cum := make([]float64, len(series))
+	peak := make([]float64, len(series))
+	var sum domain.Amount
+	runningPeak := 0.0
+	for i, p := range series {
+		sum += domain.AmountOf(p.PnL)
+		cum[i] = sum.Float64()
+		runningPeak = math.Max(runningPeak, cum[i])
+		peak[i] = runningPeak
+	}
+
+	lo, hi := 0.0, 0.0
+	for _, v := range cum {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	step := niceStep((hi - lo) / yTicks)
+	lo = math.Floor(lo/step) * step
+	hi = math.Ceil(hi/step) * step
+	if hi == lo {
+		hi = lo + step
+	}
+
+	labels := make([]string, 0, yTicks+2)
+	for v := lo; v <= hi+step/2; v += step {
+		labels = append(labels, formatTick(v, step))
+	}
+	labelW := 0
+	for _, l := range labels {
+		labelW = max(labelW, textWidth(l, scale))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, 0, 0, width, height, colBackground)
+
+	plot := image.Rect(labelW+2*padLabel, padTop, width-padRight, height-padBottom)
+	xOf := func(i float64) float64 {
+		if len(series) == 1 {
+			return float64(plot.Min.X+plot.Max.X) / 2
+		}
+		return float64(plot.Min.X) + i*float64(plot.Dx())/float64(len(series)-1)
+	}
+	yOf := func(v float64) float64 {
+		return float64(plot.Max.Y) - (v-lo)/(hi-lo)*float64(plot.Dy())
+	}
+
+	// Horizontal grid with value labels.
+	for i, l := range labels {
+		y := int(math.Round(yOf(lo + float64(i)*step)))
+		hline(img, plot.Min.X, plot.Max.X, y, colGrid)
+		drawText(img, plot.Min.X-padLabel-textWidth(l, scale), y-glyphH*scale/2, l, scale, colText)
+	}
+
+	// Drawdown: shade between the running peak and the curve, column by column.
+	for x := plot.Min.X; x <= plot.Max.X; x++ {
+		c, p, ok := interpolate(cum, peak, x, xOf)
+		if !ok || c >= p {
+			continue
+		}
+		vline(img, x, int(math.Round(yOf(p))), int(math.Round(yOf(c))), colDrawdown)
+	}
+
+	// Zero line, dashed.
+	zy := int(math.Round(yOf(0)))
+	for x := plot.Min.X; x <= plot.Max.X; x += 8 {
+		hline(img, x, min(x+4, plot.Max.X), zy, colZero)
+	}
+
+	// Axes.
+	vline(img, plot.Min.X, plot.Min.Y, plot.Max.Y, colAxis)
+	hline(img, plot.Min.X, plot.Max.X, plot.Max.Y, colAxis)
+
+	// Date labels (MM-DD) at evenly spaced points.
+	n := min(xLabels, len(series))
+	for k := range n {
+		i := 0
+		if n > 1 {
+			i = int(math.Round(float64(k*(len(series)-1)) / float64(n-1)))
+		}
+		x := int(math.Round(xOf(float64(i))))
+		vline(img, x, plot.Max.Y, plot.Max.Y+4, colAxis)
+		l := shortDate(series[i].Date)
+		tx := min(max(x-textWidth(l, scale)/2, 0), width-textWidth(l, scale))
+		drawText(img, tx, plot.Max.Y+padLabel, l, scale, colText)
+	}
+
+	// Cumulative PnL line.
+	if len(series) == 1 {
+		x, y := int(xOf(0)), int(yOf(cum[0]))
+		fillRect(img, x-2, y-2, 5, 5, colLine)
+	}
+	for i := 1; i < len(series); i++ {
+		line(img, xOf(float64(i-1)), yOf(cum[i-1]), xOf(float64(i)), yOf(cum[i]), colLine)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("chart: encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// interpolate returns the curve and peak values at pixel column x.
+func interpolate(cum, peak []float64, x int, xOf func(float64) float64) (c, p float64, ok bool) {
+	if len(cum) < 2 {
+		return 0, 0, false
+	}
+	x0 := xOf(0)
+	dx := xOf(1) - x0
+	pos := (float64(x) - x0) / dx
+	if pos < 0 || pos > float64(len(cum)-1) {
+		return 0, 0, false
+	}
+	i := min(int(pos), len(cum)-2)
+	f := pos - float64(i)
+	c = cum[i] + f*(cum[i+1]-cum[i])
+	// The peak only moves when the curve itself makes a new high.
+	return c, math.Max(peak[i], c), true
+}
+
+// niceStep rounds raw up to 1, 2 or 5 times a power of ten.
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch f := raw / exp; {
+	case f <= 1:
+		return exp
+	case f <= 2:
+		return 2 * exp
+	case f <= 5:
+		return 5 * exp
+	default:
+		return 10 * exp
+	}
+}
+
+func formatTick(v, step float64) string {
+	if math.Abs(v) < step/2 {
+		return "0"
+	}
+	if step >= 1000 && math.Mod(v, 1000) == 0 {
+		return strconv.FormatFloat(v/1000, 'f', 0, 64) + "k"
+	}
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+func shortDate(d string) string {
+	if len(d) == len("2006-01-02") {
+		return d[5:]
+	}
+	return d
+}
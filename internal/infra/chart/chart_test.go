@@ -0,0 +1,87 @@
+package chart
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden images in testdata")
+
+func TestRenderer_RenderPnL_golden(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []domain.DailyPnL
+	}{
+		{
+			name: "drawdown",
+			series: []domain.DailyPnL{
+				{Date: "2024-03-01", PnL: 120}, {Date: "2024-03-02", PnL: 80},
+				{Date: "2024-03-03", PnL: -150}, {Date: "2024-03-04", PnL: -60},
+				{Date: "2024-03-05", PnL: 40}, {Date: "2024-03-06", PnL: 210},
+				{Date: "2024-03-07", PnL: -30}, {Date: "2024-03-08", PnL: 95},
+				{Date: "2024-03-09", PnL: -240}, {Date: "2024-03-10", PnL: 130},
+			},
+		},
+		{
+			name:   "single_point",
+			series: []domain.DailyPnL{{Date: "2024-03-01", PnL: -12.5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRenderer().RenderPnL(tt.series)
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tt.name+".png")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, got, 0o600))
+			}
+			want, err := os.ReadFile(golden) //nolint:gosec // fixed test path
+			require.NoError(t, err)
+			requireSamePixels(t, decode(t, want), decode(t, got))
+		})
+	}
+}
+
+func TestRenderer_RenderPnL_empty(t *testing.T) {
+	_, err := NewRenderer().RenderPnL(nil)
+	require.ErrorIs(t, err, ErrNoData)
+}
+
+func TestNiceStepAndTicks(t *testing.T) {
+	require.InDelta(t, 1.0, niceStep(0.7), 1e-9)
+	require.InDelta(t, 20.0, niceStep(13), 1e-9)
+	require.InDelta(t, 500.0, niceStep(420), 1e-9)
+	require.InDelta(t, 1.0, niceStep(0), 1e-9)
+	require.Equal(t, "2k", formatTick(2000, 1000))
+	require.Equal(t, "0.25", formatTick(0.25, 0.05))
+	require.Equal(t, "0", formatTick(1e-12, 0.5))
+}
+
+func decode(t *testing.T, b []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	return img
+}
+
+func requireSamePixels(t *testing.T, want, got image.Image) {
+	t.Helper()
+	require.Equal(t, want.Bounds(), got.Bounds())
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if want.At(x, y) != got.At(x, y) {
+				require.Failf(t, "pixel mismatch", "at (%d,%d): want %v, got %v; rerun with -update after reviewing", x, y, want.At(x, y), got.At(x, y))
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.Set(px, py, c)
+		}
+	}
+}
+
+func hline(img *image.RGBA, x0, x1, y int, c color.Color) {
+	for x := min(x0, x1); x <= max(x0, x1); x++ {
+		img.Set(x, y, c)
+	}
+}
+
+func vline(img *image.RGBA, x, y0, y1 int, c color.Color) {
+	for y := min(y0, y1); y <= max(y0, y1); y++ {
+		img.Set(x, y, c)
+	}
+}
+
+// line draws a 2px-wide segment by stepping along its longer axis.
+func line(img *image.RGBA, x0, y0, x1, y1 float64, c color.Color) {
+	steps := int(math.Ceil(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))))
+	if steps == 0 {
+		steps = 1
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Round(x0 + t*(x1-x0)))
+		y := int(math.Round(y0 + t*(y1-y0)))
+		fillRect(img, x, y, 2, 2, c)
+	}
+}
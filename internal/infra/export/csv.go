@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type csvEncoder struct {
+	buf bytes.Buffer
+	out *bufio.Writer
+}
+
+func (e *csvEncoder) open(f *os.File, header []string) error {
+	e.out = bufio.NewWriter(f)
+	cells := make([]any, len(header))
+	for i, h := range header {
+		cells[i] = h
+	}
+	b, err := e.row(cells)
+	if err != nil {
+		return err
+	}
+	return e.write(b)
+}
+
+func (e *csvEncoder) row(cells []any) ([]byte, error) {
+	record := make([]string, len(cells))
+	for i, c := range cells {
+		switch v := c.(type) {
+		case string:
+			record[i] = v
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case int:
+			record[i] = strconv.Itoa(v)
+		default:
+			return nil, fmt.Errorf("unsupported cell type %T", c)
+		}
+	}
+	e.buf.Reset()
+	w := csv.NewWriter(&e.buf)
+	if err := w.Write(record); err != nil {
+		return nil, fmt.Errorf("csv: %w", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("csv: %w", err)
+	}
+	return bytes.Clone(e.buf.Bytes()), nil
+}
+
+func (e *csvEncoder) write(b []byte) error {
+	if _, err := e.out.Write(b); err != nil {
+		return fmt.Errorf("csv: %w", err)
+	}
+	return nil
+}
+
+func (e *csvEncoder) close() error {
+	if err := e.out.Flush(); err != nil {
+		return fmt.Errorf("csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Package export writes tabular report data as CSV or XLSX files, split into parts that fit an upload limit.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// Supported formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// partOverhead is reserved in every part for container structures (zip directory, XLSX parts).
+const partOverhead = 64 << 10
+
+// ErrUnknownFormat is returned for formats other than FormatCSV and FormatXLSX.
+var ErrUnknownFormat = errors.New("export: unknown format")
+
+// encoder produces the bytes of one file part; the writer decides where parts split.
+type encoder interface {
+	// open starts a part on f and writes the header row.
+	open(f *os.File, header []string) error
+	// row encodes one row without writing it.
+	row(cells []any) ([]byte, error)
+	// write appends an encoded row to the open part.
+	write(b []byte) error
+	// close finishes the open part.
+	close() error
+}
+
+// Factory creates Writers in fresh temporary directories under dir; it implements ports.TableWriterFactory.
+type Factory struct {
+	dir      string
+	maxBytes int64
+}
+
+// NewFactory returns a Factory; maxBytes bounds every part (0 means no limit).
+func NewFactory(dir string, maxBytes int64) *Factory {
+	return &Factory{dir: dir, maxBytes: maxBytes}
+}
+
+// NewTableWriter implements ports.TableWriterFactory.
+func (f *Factory) NewTableWriter(format, name string, header []string) (ports.TableWriter, error) {
+	var enc encoder
+	switch format {
+	case FormatCSV:
+		enc = &csvEncoder{}
+	case FormatXLSX:
+		enc = &xlsxEncoder{}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	dir, err := os.MkdirTemp(f.dir, "export-")
+	if err != nil {
+		return nil, fmt.Errorf("export: temp dir: %w", err)
+	}
+	return &Writer{enc: enc, dir: dir, name: name, ext: format, header: header, maxBytes: f.maxBytes}, nil
+}
+
+// Writer streams rows to disk and starts a new part, repeating the header, whenever the next row
+// would push the current part past maxBytes. Sizes are measured before compression, so XLSX parts
+// stay well under the limit.
+type Writer struct {
+	enc      encoder
+	dir      string
+	name     string
+	ext      string
+	header   []string
+	maxBytes int64
+
+	file      *os.File
+	partBytes int64
+	partRows  int
+	paths     []string
+}
+
+// WriteRow implements ports.TableWriter.
+func (w *Writer) WriteRow(cells ...any) error {
+	b, err := w.enc.row(cells)
+	if err != nil {
+		return fmt.Errorf("export: encode row: %w", err)
+	}
+	if w.file != nil && w.maxBytes > 0 && w.partRows > 0 && w.partBytes+int64(len(b)) > w.maxBytes-partOverhead {
+		if err := w.closePart(); err != nil {
+			return err
+		}
+	}
+	if w.file == nil {
+		if err := w.openPart(); err != nil {
+			return err
+		}
+	}
+	if err := w.enc.write(b); err != nil {
+		return fmt.Errorf("export: write row: %w", err)
+	}
+	w.partBytes += int64(len(b))
+	w.partRows++
+	return nil
+}
+
+func (w *Writer) openPart() error {
+	path := filepath.Join(w.dir, fmt.Sprintf("part%d.%s", len(w.paths)+1, w.ext))
+	f, err := os.Create(path) //nolint:gosec // path is inside our own temp dir
+	if err != nil {
+		return fmt.Errorf("export: create part: %w", err)
+	}
+	w.file = f
+	w.paths = append(w.paths, path)
+	w.partRows = 0
+	w.partBytes = 0
+	if err := w.enc.open(f, w.header); err != nil {
+		return fmt.Errorf("export: write header: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer) closePart() error {
+	err := w.enc.close()
+	if cerr := w.file.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("export: close part: %w", err)
+	}
+	return nil
+}
+
+// Close implements ports.TableWriter. A writer without rows produces a single header-only file.
+func (w *Writer) Close() ([]ports.ExportFile, error) {
+	if w.file == nil && len(w.paths) == 0 {
+		if err := w.openPart(); err != nil {
+			return nil, err
+		}
+	}
+	if w.file != nil {
+		if err := w.closePart(); err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]ports.ExportFile, 0, len(w.paths))
+	for i, p := range w.paths {
+		name := fmt.Sprintf("%s.%s", w.name, w.ext)
+		if len(w.paths) > 1 {
+			name = fmt.Sprintf("%s.part%d-of-%d.%s", w.name, i+1, len(w.paths), w.ext)
+		}
+		dst := filepath.Join(w.dir, name)
+		if err := os.Rename(p, dst); err != nil {
+			return nil, fmt.Errorf("export: name part: %w", err)
+		}
+		w.paths[i] = dst
+		st, err := os.Stat(dst)
+		if err != nil {
+			return nil, fmt.Errorf("export: stat part: %w", err)
+		}
+		files = append(files, ports.ExportFile{Name: name, Path: dst, Size: st.Size()})
+	}
+	return files, nil
+}
+
+// Remove implements ports.TableWriter.
+func (w *Writer) Remove() error {
+	if w.file != nil {
+		_ = w.file.Close() //nolint:errcheck // discarding the export anyway
+		w.file = nil
+	}
+	if err := os.RemoveAll(w.dir); err != nil {
+		return fmt.Errorf("export: remove: %w", err)
+	}
+	return nil
+}
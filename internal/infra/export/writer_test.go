@@ -0,0 +1,95 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_CSV(t *testing.T) {
+	w, err := NewFactory(t.TempDir(), 0).NewTableWriter(FormatCSV, "days_2024-01-01_2024-01-02", []string{"date", "pnl"})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, w.Remove()) })
+
+	require.NoError(t, w.WriteRow("2024-01-01", 10.5))
+	require.NoError(t, w.WriteRow("2024-01-02", -3.0))
+	files, err := w.Close()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "days_2024-01-01_2024-01-02.csv", files[0].Name)
+
+	f, err := os.Open(files[0].Path)
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"date", "pnl"}, {"2024-01-01", "10.5"}, {"2024-01-02", "-3"}}, records)
+}
+
+func TestWriter_splitsParts(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			w, err := NewFactory(t.TempDir(), partOverhead+200).NewTableWriter(format, "trades", []string{"id", "note"})
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, w.Remove()) })
+
+			for range 10 {
+				require.NoError(t, w.WriteRow("id", strings.Repeat("x", 40)))
+			}
+			files, err := w.Close()
+			require.NoError(t, err)
+			require.Greater(t, len(files), 1)
+			require.Equal(t, "trades.part1-of-"+strconv.Itoa(len(files))+"."+format, files[0].Name)
+			for _, f := range files {
+				require.LessOrEqual(t, f.Size, int64(partOverhead+200))
+			}
+		})
+	}
+}
+
+func TestWriter_XLSX(t *testing.T) {
+	w, err := NewFactory(t.TempDir(), 0).NewTableWriter(FormatXLSX, "days", []string{"date", "pnl"})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, w.Remove()) })
+
+	require.NoError(t, w.WriteRow("BTC<USDT>&", 1.25))
+	files, err := w.Close()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	zr, err := zip.OpenReader(files[0].Path)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	names := map[string]bool{}
+	var sheet string
+	for _, f := range zr.File {
+		names[f.Name] = true
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			b, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			sheet = string(b)
+		}
+	}
+	for _, p := range xlsxStatic {
+		require.True(t, names[p.name], p.name)
+	}
+	require.Contains(t, sheet, `<c t="inlineStr" s="1"><is><t xml:space="preserve">date</t></is></c>`)
+	require.Contains(t, sheet, `BTC&lt;USDT&gt;&amp;`)
+	require.Contains(t, sheet, `<c><v>1.25</v></c>`)
+	require.True(t, strings.HasSuffix(sheet, sheetTail))
+}
+
+func TestFactory_unknownFormat(t *testing.T) {
+	_, err := NewFactory(t.TempDir(), 0).NewTableWriter("pdf", "x", nil)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
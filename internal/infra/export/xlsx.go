@@ -0,0 +1,133 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// xlsxStatic are the fixed SpreadsheetML package parts of a single-sheet workbook.
+var xlsxStatic = []struct{ name, body string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// Style 1 is the bold header font.
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font/><font><b/></font></fonts>` +
+		`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+const (
+	sheetHead = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetTail = `</sheetData></worksheet>`
+)
+
+// xlsxEncoder streams rows into xl/worksheets/sheet1.xml using inline strings, so no shared-string
+// table has to be held in memory.
+type xlsxEncoder struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	buf   bytes.Buffer
+}
+
+func (e *xlsxEncoder) open(f *os.File, header []string) error {
+	e.zw = zip.NewWriter(f)
+	for _, p := range xlsxStatic {
+		w, err := e.zw.Create(p.name)
+		if err != nil {
+			return fmt.Errorf("xlsx %s: %w", p.name, err)
+		}
+		if _, err := io.WriteString(w, p.body); err != nil {
+			return fmt.Errorf("xlsx %s: %w", p.name, err)
+		}
+	}
+	sheet, err := e.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("xlsx sheet: %w", err)
+	}
+	e.sheet = sheet
+	if _, err := io.WriteString(sheet, sheetHead); err != nil {
+		return fmt.Errorf("xlsx sheet: %w", err)
+	}
+
+	cells := make([]any, len(header))
+	for i, h := range header {
+		cells[i] = h
+	}
+	b, err := e.encode(cells, 1)
+	if err != nil {
+		return err
+	}
+	return e.write(b)
+}
+
+func (e *xlsxEncoder) row(cells []any) ([]byte, error) {
+	return e.encode(cells, 0)
+}
+
+func (e *xlsxEncoder) encode(cells []any, style int) ([]byte, error) {
+	// Row and cell references are optional; omitting them keeps encoded rows independent
+	// of the part they end up in.
+	e.buf.Reset()
+	e.buf.WriteString(`<row>`)
+	for _, c := range cells {
+		s := ""
+		if style != 0 {
+			s = ` s="` + strconv.Itoa(style) + `"`
+		}
+		switch v := c.(type) {
+		case string:
+			fmt.Fprintf(&e.buf, `<c t="inlineStr"%s><is><t xml:space="preserve">`, s)
+			if err := xml.EscapeText(&e.buf, []byte(v)); err != nil {
+				return nil, fmt.Errorf("xlsx: %w", err)
+			}
+			e.buf.WriteString(`</t></is></c>`)
+		case float64:
+			fmt.Fprintf(&e.buf, `<c%s><v>%s</v></c>`, s, strconv.FormatFloat(v, 'f', -1, 64))
+		case int:
+			fmt.Fprintf(&e.buf, `<c%s><v>%d</v></c>`, s, v)
+		default:
+			return nil, fmt.Errorf("unsupported cell type %T", c)
+		}
+	}
+	e.buf.WriteString(`</row>`)
+	return bytes.Clone(e.buf.Bytes()), nil
+}
+
+func (e *xlsxEncoder) write(b []byte) error {
+	if _, err := e.sheet.Write(b); err != nil {
+		return fmt.Errorf("xlsx: %w", err)
+	}
+	return nil
+}
+
+func (e *xlsxEncoder) close() error {
+	if _, err := io.WriteString(e.sheet, sheetTail); err != nil {
+		return fmt.Errorf("xlsx: %w", err)
+	}
+	if err := e.zw.Close(); err != nil {
+		return fmt.Errorf("xlsx: %w", err)
+	}
+	return nil
+}
@@ -1,13 +1,17 @@
-// Package httpclient implements ports.ReportFetcher over HTTP JSON APIs.
+// Package httpclient implements report ports over HTTP JSON APIs.
 package httpclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
 )
 
@@ -33,17 +37,240 @@ type reportResponse struct {
 	Expense float64 `json:"expense"`
 }
 
+type dailyPnLResponse struct {
+	Date string  `json:"date"`
+	PnL  float64 `json:"pnl"`
+}
+
 // FetchReport implements ports.ReportFetcher.
-func (c *Client) FetchReport(ctx context.Context, from, to string) (*ports.ReportResult, error) {
-	url := fmt.Sprintf("%s/reports?from=%s&to=%s", c.base, from, to)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (c *Client) FetchReport(ctx context.Context, account string, from, to time.Time) (*ports.ReportResult, error) {
+	var rr reportResponse
+	if err := c.getJSON(ctx, "/reports", rangeQuery(account, from, to), &rr); err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	return &ports.ReportResult{Income: rr.Income, Expense: rr.Expense}, nil
+}
+
+// FetchDailyPnL implements ports.ReportSeriesFetcher.
+func (c *Client) FetchDailyPnL(ctx context.Context, account string, from, to time.Time) ([]ports.PnLPoint, error) {
+	var rows []dailyPnLResponse
+	if err := c.getJSON(ctx, "/reports/daily", rangeQuery(account, from, to), &rows); err != nil {
+		return nil, fmt.Errorf("daily pnl: %w", err)
+	}
+	out := make([]ports.PnLPoint, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, ports.PnLPoint{Date: r.Date, PnL: r.PnL})
+	}
+	return out, nil
+}
+
+type pnlGroupResponse struct {
+	Name   string  `json:"name"`
+	PnL    float64 `json:"pnl"`
+	Trades int     `json:"trades"`
+	Wins   int     `json:"wins"`
+}
+
+type breakdownResponse struct {
+	Symbols    []pnlGroupResponse `json:"symbols"`
+	Strategies []pnlGroupResponse `json:"strategies"`
+}
+
+// FetchBreakdown implements ports.ReportBreakdownFetcher.
+func (c *Client) FetchBreakdown(ctx context.Context, account string, from, to time.Time) (*ports.BreakdownResult, error) {
+	var br breakdownResponse
+	if err := c.getJSON(ctx, "/reports/breakdown", rangeQuery(account, from, to), &br); err != nil {
+		return nil, fmt.Errorf("breakdown: %w", err)
+	}
+	return &ports.BreakdownResult{Symbols: pnlGroupResults(br.Symbols), Strategies: pnlGroupResults(br.Strategies)}, nil
+}
+
+func pnlGroupResults(rows []pnlGroupResponse) []ports.PnLGroupResult {
+	out := make([]ports.PnLGroupResult, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, ports.PnLGroupResult(r))
+	}
+	return out
+}
+
+type rateResponse struct {
+	Rate float64 `json:"rate"`
+}
+
+// FetchRate implements ports.RateFetcher.
+func (c *Client) FetchRate(ctx context.Context, base, quote string) (float64, error) {
+	var rr rateResponse
+	if err := c.getJSON(ctx, "/rates", url.Values{"base": {base}, "quote": {quote}}, &rr); err != nil {
+		return 0, fmt.Errorf("rate %s/%s: %w", base, quote, err)
+	}
+	if rr.Rate <= 0 {
+		return 0, fmt.Errorf("rate %s/%s: non-positive rate %v", base, quote, rr.Rate)
+	}
+	return rr.Rate, nil
+}
+
+// rangeQuery builds the range query: from/to as RFC 3339 UTC instants (to exclusive) and the IANA
+// time zone used for day boundaries. account is omitted for the default account, tz for UTC.
+func rangeQuery(account string, from, to time.Time) url.Values {
+	q := url.Values{"from": {from.UTC().Format(time.RFC3339)}, "to": {to.UTC().Format(time.RFC3339)}}
+	if account != "" {
+		q.Set("account", account)
+	}
+	if tz := from.Location().String(); tz != "UTC" {
+		q.Set("tz", tz)
+	}
+	return q
+}
+
+type tradeResponse struct {
+	ID         string  `json:"id"`
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	OpenedAt   string  `json:"opened_at"`
+	ClosedAt   string  `json:"closed_at"`
+	Quantity   float64 `json:"quantity"`
+	EntryPrice float64 `json:"entry_price"`
+	ExitPrice  float64 `json:"exit_price"`
+	Fee        float64 `json:"fee"`
+	PnL        float64 `json:"pnl"`
+}
+
+// FetchTrades implements ports.TradeFetcher. The JSON array is decoded element by element so
+// large ranges are never held in memory.
+func (c *Client) FetchTrades(ctx context.Context, account string, from, to time.Time, fn func(ports.Trade) error) error {
+	err := c.get(ctx, "/reports/trades", rangeQuery(account, from, to), func(dec *json.Decoder) error {
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("decode json: %w", err)
+		}
+		for dec.More() {
+			var tr tradeResponse
+			if err := dec.Decode(&tr); err != nil {
+				return fmt.Errorf("decode json: %w", err)
+			}
+			if err := fn(ports.Trade(tr)); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("decode json: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("build request: %w", err)
+		return fmt.Errorf("trades: %w", err)
 	}
+	return nil
+}
 
+type positionResponse struct {
+	Symbol           string  `json:"symbol"`
+	Side             string  `json:"side"`
+	Size             float64 `json:"size"`
+	EntryPrice       float64 `json:"entry_price"`
+	MarkPrice        float64 `json:"mark_price"`
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+}
+
+type balanceResponse struct {
+	Asset     string  `json:"asset"`
+	Total     float64 `json:"total"`
+	Available float64 `json:"available"`
+}
+
+// FetchPositions implements ports.PortfolioFetcher.
+func (c *Client) FetchPositions(ctx context.Context, account string) ([]ports.PositionResult, error) {
+	var rows []positionResponse
+	if err := c.getJSON(ctx, "/portfolio/positions", accountQuery(account), &rows); err != nil {
+		return nil, fmt.Errorf("positions: %w", err)
+	}
+	out := make([]ports.PositionResult, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, ports.PositionResult(r))
+	}
+	return out, nil
+}
+
+// FetchBalances implements ports.PortfolioFetcher.
+func (c *Client) FetchBalances(ctx context.Context, account string) ([]ports.BalanceResult, error) {
+	var rows []balanceResponse
+	if err := c.getJSON(ctx, "/portfolio/balances", accountQuery(account), &rows); err != nil {
+		return nil, fmt.Errorf("balances: %w", err)
+	}
+	out := make([]ports.BalanceResult, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, ports.BalanceResult(r))
+	}
+	return out, nil
+}
+
+// accountQuery selects account; it is omitted for the default account.
+func accountQuery(account string) url.Values {
+	if account == "" {
+		return nil
+	}
+	return url.Values{"account": {account}}
+}
+
+// Control implements ports.CoreController: the request is posted to /control with its ID in
+// the X-Request-ID header, and the core answers with its acknowledgment. A reply for another
+// request is an error.
+func (c *Client) Control(ctx context.Context, req domain.ControlRequest) (domain.ControlReply, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return domain.ControlReply{}, fmt.Errorf("control %s: encode request: %w", req.Action, err)
+	}
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+"/control", bytes.NewReader(body))
+	if err != nil {
+		return domain.ControlReply{}, fmt.Errorf("control %s: build request: %w", req.Action, err)
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("X-Request-ID", req.ID)
+
+	var reply domain.ControlReply
+	err = c.do(hreq, func(dec *json.Decoder) error {
+		if err := dec.Decode(&reply); err != nil {
+			return fmt.Errorf("decode json: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.ControlReply{}, fmt.Errorf("control %s: %w", req.Action, err)
+	}
+	if reply.RequestID == "" {
+		reply.RequestID = req.ID
+	}
+	if reply.RequestID != req.ID {
+		return domain.ControlReply{}, fmt.Errorf("control %s: reply for request %s, want %s", req.Action, reply.RequestID, req.ID)
+	}
+	return reply, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out any) error {
+	return c.get(ctx, path, query, func(dec *json.Decoder) error {
+		if err := dec.Decode(out); err != nil {
+			return fmt.Errorf("decode json: %w", err)
+		}
+		return nil
+	})
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, decode func(*json.Decoder) error) error {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	return c.do(req, decode)
+}
+
+func (c *Client) do(req *http.Request, decode func(*json.Decoder) error) error {
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http get: %w", err)
+		return fmt.Errorf("http %s: %w", strings.ToLower(req.Method), err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -52,12 +279,7 @@ func (c *Client) FetchReport(ctx context.Context, from, to string) (*ports.Repor
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status: %d", resp.StatusCode)
-	}
-	var rr reportResponse
-	dec := json.NewDecoder(resp.Body)
-	if err := dec.Decode(&rr); err != nil {
-		return nil, fmt.Errorf("decode report json: %w", err)
+		return fmt.Errorf("bad status: %d", resp.StatusCode)
 	}
-	return &ports.ReportResult{Income: rr.Income, Expense: rr.Expense}, nil
+	return decode(json.NewDecoder(resp.Body))
 }
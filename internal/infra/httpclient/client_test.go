@@ -3,27 +3,37 @@ package httpclient
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
 	"github.com/stretchr/testify/require"
 )
 
+var (
+	jan1 = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb1 = time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
 func TestClient_FetchReport(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			require.Equal(t, "/reports", r.URL.Path)
-			require.Equal(t, "2020-01-01", r.URL.Query().Get("from"))
-			require.Equal(t, "2020-01-31", r.URL.Query().Get("to"))
+			require.Equal(t, "2020-01-01T00:00:00Z", r.URL.Query().Get("from"))
+			require.Equal(t, "2020-02-01T00:00:00Z", r.URL.Query().Get("to"))
+			require.False(t, r.URL.Query().Has("tz"))
+			require.False(t, r.URL.Query().Has("account"))
 			w.Header().Set("Content-Type", "application/json")
 			require.NoError(t, json.NewEncoder(w).Encode(map[string]float64{"income": 100.5, "expense": 50.25}))
 		}))
 		defer server.Close()
 
 		client := New(server.URL, 5*time.Second)
-		result, err := client.FetchReport(context.Background(), "2020-01-01", "2020-01-31")
+		result, err := client.FetchReport(context.Background(), "", jan1, feb1)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 		require.Equal(t, 100.5, result.Income)
@@ -37,7 +47,7 @@ func TestClient_FetchReport(t *testing.T) {
 		defer server.Close()
 
 		client := New(server.URL, 5*time.Second)
-		result, err := client.FetchReport(context.Background(), "2020-01-01", "2020-01-31")
+		result, err := client.FetchReport(context.Background(), "", jan1, feb1)
 		require.Error(t, err)
 		require.Nil(t, result)
 		require.Contains(t, err.Error(), "500")
@@ -52,8 +62,158 @@ func TestClient_FetchReport(t *testing.T) {
 		defer server.Close()
 
 		client := New(server.URL, 5*time.Second)
-		result, err := client.FetchReport(context.Background(), "2020-01-01", "2020-01-31")
+		result, err := client.FetchReport(context.Background(), "", jan1, feb1)
 		require.Error(t, err)
 		require.Nil(t, result)
 	})
 }
+
+func TestClient_FetchDailyPnL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/reports/daily", r.URL.Path)
+		require.Equal(t, "main", r.URL.Query().Get("account"))
+		require.Equal(t, "2019-12-31T23:00:00Z", r.URL.Query().Get("from"))
+		require.Equal(t, "2020-01-02T23:00:00Z", r.URL.Query().Get("to"))
+		require.Equal(t, "Europe/Warsaw", r.URL.Query().Get("tz"))
+		_, err := w.Write([]byte(`[{"date":"2020-01-01","pnl":10.5},{"date":"2020-01-02","pnl":-3}]`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	require.NoError(t, err)
+
+	client := New(server.URL, 5*time.Second)
+	got, err := client.FetchDailyPnL(context.Background(), "main",
+		time.Date(2020, 1, 1, 0, 0, 0, 0, warsaw), time.Date(2020, 1, 3, 0, 0, 0, 0, warsaw))
+	require.NoError(t, err)
+	require.Equal(t, []ports.PnLPoint{{Date: "2020-01-01", PnL: 10.5}, {Date: "2020-01-02", PnL: -3}}, got)
+}
+
+func TestClient_FetchTrades(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/reports/trades", r.URL.Path)
+		_, err := w.Write([]byte(`[{"id":"1","symbol":"BTCUSDT","side":"long","pnl":12.5},{"id":"2","symbol":"ETHUSDT","side":"short","pnl":-4}]`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, 5*time.Second)
+
+	var got []ports.Trade
+	err := client.FetchTrades(context.Background(), "", jan1, feb1, func(tr ports.Trade) error {
+		got = append(got, tr)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, "BTCUSDT", got[0].Symbol)
+	require.Equal(t, -4.0, got[1].PnL)
+
+	stop := errors.New("stop")
+	err = client.FetchTrades(context.Background(), "", jan1, feb1, func(ports.Trade) error { return stop })
+	require.ErrorIs(t, err, stop)
+}
+
+func TestClient_FetchBreakdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/reports/breakdown", r.URL.Path)
+		require.Equal(t, "main", r.URL.Query().Get("account"))
+		_, err := w.Write([]byte(`{"symbols":[{"name":"BTCUSDT","pnl":12.5,"trades":4,"wins":3}],"strategies":[{"name":"breakout","pnl":-2,"trades":1,"wins":0}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, 5*time.Second)
+	got, err := client.FetchBreakdown(context.Background(), "main", jan1, feb1)
+	require.NoError(t, err)
+	require.Equal(t, &ports.BreakdownResult{
+		Symbols:    []ports.PnLGroupResult{{Name: "BTCUSDT", PnL: 12.5, Trades: 4, Wins: 3}},
+		Strategies: []ports.PnLGroupResult{{Name: "breakout", PnL: -2, Trades: 1, Wins: 0}},
+	}, got)
+}
+
+func TestClient_FetchRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/rates", r.URL.Path)
+		require.Equal(t, "USDT", r.URL.Query().Get("base"))
+		body := `{"rate":0.92}`
+		if r.URL.Query().Get("quote") == "XXX" {
+			body = `{"rate":0}`
+		}
+		_, err := w.Write([]byte(body))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, 5*time.Second)
+	got, err := client.FetchRate(context.Background(), "USDT", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, 0.92, got)
+
+	_, err = client.FetchRate(context.Background(), "USDT", "XXX")
+	require.ErrorContains(t, err, "non-positive rate")
+}
+
+func TestClient_Control(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/control", r.URL.Path)
+		var req domain.ControlRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, req.ID, r.Header.Get("X-Request-ID"))
+		switch req.Strategy {
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		case "other":
+			req.ID = "someone-else"
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"request_id": req.ID, "ok": true, "message": "paused", "paused_strategies": []string{req.Strategy},
+		}))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, 5*time.Second)
+	got, err := client.Control(context.Background(), domain.ControlRequest{ID: "r1", Action: domain.ControlPause, Strategy: "scalper"})
+	require.NoError(t, err)
+	require.Equal(t, domain.ControlReply{RequestID: "r1", OK: true, Message: "paused", PausedStrategies: []string{"scalper"}}, got)
+
+	_, err = client.Control(context.Background(), domain.ControlRequest{ID: "r2", Action: domain.ControlPause, Strategy: "other"})
+	require.ErrorContains(t, err, "reply for request someone-else")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Control(ctx, domain.ControlRequest{ID: "r3", Action: domain.ControlPause, Strategy: "slow"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_FetchPortfolio(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/portfolio/positions":
+			require.Equal(t, "main", r.URL.Query().Get("account"))
+			body = `[{"symbol":"BTCUSDT","side":"long","size":0.5,"entry_price":60000,"mark_price":61000,"unrealized_pnl":500,"liquidation_price":45000}]`
+		case "/portfolio/balances":
+			require.False(t, r.URL.Query().Has("account"))
+			body = `[{"asset":"USDT","total":1200.5,"available":800}]`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(body))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, 5*time.Second)
+	positions, err := client.FetchPositions(context.Background(), "main")
+	require.NoError(t, err)
+	require.Equal(t, []ports.PositionResult{{Symbol: "BTCUSDT", Side: "long", Size: 0.5, EntryPrice: 60000, MarkPrice: 61000,
+		UnrealizedPnL: 500, LiquidationPrice: 45000}}, positions)
+
+	balances, err := client.FetchBalances(context.Background(), "")
+	require.NoError(t, err)
+	require.Equal(t, []ports.BalanceResult{{Asset: "USDT", Total: 1200.5, Available: 800}}, balances)
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_errors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"x * * * *",
+	} {
+		_, err := Parse(expr, nil)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		expr string
+		loc  *time.Location
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "daily same day",
+			expr: "0 8 * * *",
+			from: time.Date(2024, 3, 10, 7, 59, 30, 0, time.UTC),
+			want: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "daily strictly after",
+			expr: "0 8 * * *",
+			from: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekly monday by name",
+			expr: "0 8 * * mon",
+			from: time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC), // Sunday
+			want: time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			expr: "30 6 * * 7",
+			from: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 17, 6, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "step and list",
+			expr: "*/15 9,17 * * *",
+			from: time.Date(2024, 3, 10, 9, 46, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 10, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "dom or dow when both restricted",
+			expr: "0 0 1 * fri",
+			from: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), // Saturday
+			want: time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "local time zone",
+			expr: "0 8 * * *",
+			loc:  warsaw,
+			from: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 7, 1, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "skipped hour on spring forward",
+			expr: "30 2 * * *",
+			loc:  warsaw,
+			from: time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 4, 1, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "impossible date",
+			expr: "0 0 30 2 *",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr, tt.loc)
+			require.NoError(t, err)
+			got := s.Next(tt.from)
+			require.True(t, tt.want.Equal(got), "want %v, got %v", tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+const (
+	// maxCatchUp bounds how far back a missed activation is still executed after downtime.
+	maxCatchUp = 31 * 24 * time.Hour
+	// retryDelay is the pause before a failed activation is attempted again.
+	retryDelay = time.Minute
+	// idleWake is the wake interval when no job has an upcoming activation.
+	idleWake = time.Hour
+)
+
+// JobFunc executes one activation; at is the scheduled time, not the wall-clock start.
+type JobFunc func(ctx context.Context, at time.Time) error
+
+// Job is a named unit of work bound to a cron schedule.
+type Job struct {
+	Name     string
+	Schedule *Schedule
+	Run      JobFunc
+}
+
+// Scheduler executes jobs at their cron activations. The last activation of every job is
+// persisted before it runs, so a restart never repeats a delivered activation; a missed one
+// is executed once after restart (only the latest, within maxCatchUp).
+type Scheduler struct {
+	jobs   []Job
+	store  StateStore
+	clock  ports.Clock
+	logger ports.Logger
+
+	mu      sync.Mutex
+	retryAt map[string]time.Time
+}
+
+// New returns a Scheduler for jobs.
+func New(store StateStore, clock ports.Clock, logger ports.Logger, jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs:    jobs,
+		store:   store,
+		clock:   clock,
+		logger:  logger,
+		retryAt: make(map[string]time.Time),
+	}
+}
+
+// Run starts a goroutine that executes due jobs until ctx is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	go func() {
+		for {
+			wake := s.RunDue(ctx)
+			d := idleWake
+			if !wake.IsZero() {
+				d = max(wake.Sub(s.clock.Now()), 0)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.clock.After(d):
+			}
+		}
+	}()
+}
+
+// RunDue runs every job whose activation is due and returns the earliest upcoming wake time
+// (zero when there is none).
+func (s *Scheduler) RunDue(ctx context.Context) time.Time {
+	var wake time.Time
+	for _, j := range s.jobs {
+		next := s.runJob(ctx, j)
+		if !next.IsZero() && (wake.IsZero() || next.Before(wake)) {
+			wake = next
+		}
+	}
+	return wake
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j Job) time.Time {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	retry, retrying := s.retryAt[j.Name]
+	s.mu.Unlock()
+	if retrying && now.Before(retry) {
+		return retry
+	}
+
+	last, ok, err := s.store.LastRun(j.Name)
+	if err != nil {
+		s.logger.Error("scheduler state read failed", "job", j.Name, "error", err)
+		return s.deferRetry(j.Name, now)
+	}
+	if !ok {
+		// First sight of this job: anchor at now so only future activations run.
+		if err := s.store.SetLastRun(j.Name, now); err != nil {
+			s.logger.Error("scheduler state write failed", "job", j.Name, "error", err)
+			return s.deferRetry(j.Name, now)
+		}
+		return j.Schedule.Next(now)
+	}
+
+	due := latestDue(j.Schedule, last, now)
+	if due.IsZero() {
+		return j.Schedule.Next(last)
+	}
+
+	if err := s.store.SetLastRun(j.Name, due); err != nil {
+		s.logger.Error("scheduler state write failed", "job", j.Name, "error", err)
+		return s.deferRetry(j.Name, now)
+	}
+	if err := j.Run(ctx, due); err != nil {
+		s.logger.Error("scheduled job failed", "job", j.Name, "at", due, "error", err)
+		if err := s.store.SetLastRun(j.Name, last); err != nil {
+			s.logger.Error("scheduler state rollback failed", "job", j.Name, "error", err)
+		}
+		return s.deferRetry(j.Name, now)
+	}
+	s.logger.Info("scheduled job done", "job", j.Name, "at", due)
+
+	s.mu.Lock()
+	delete(s.retryAt, j.Name)
+	s.mu.Unlock()
+	return j.Schedule.Next(due)
+}
+
+func (s *Scheduler) deferRetry(name string, now time.Time) time.Time {
+	at := now.Add(retryDelay)
+	s.mu.Lock()
+	s.retryAt[name] = at
+	s.mu.Unlock()
+	return at
+}
+
+// latestDue returns the most recent activation in (last, now], ignoring activations older than maxCatchUp.
+func latestDue(sch *Schedule, last, now time.Time) time.Time {
+	from := last
+	if floor := now.Add(-maxCatchUp); from.Before(floor) {
+		from = floor
+	}
+	var due time.Time
+	for t := sch.Next(from); !t.IsZero() && !t.After(now); t = sch.Next(t) {
+		due = t
+	}
+	return due
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(time.Duration) <-chan time.Time {
+	return make(chan time.Time)
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Sync() error          { return nil }
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+func (nopLogger) Debug(string, ...any) {}
+
+type recorder struct {
+	runs []time.Time
+	err  error
+}
+
+func (r *recorder) run(_ context.Context, at time.Time) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.runs = append(r.runs, at)
+	return nil
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	ctx := context.Background()
+	daily, err := Parse("0 8 * * *", nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	clock := &fakeClock{now: time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)}
+	rec := &recorder{}
+	newScheduler := func() *Scheduler {
+		return New(NewFileStore(path), clock, nopLogger{}, Job{Name: "daily", Schedule: daily, Run: rec.run})
+	}
+
+	s := newScheduler()
+	wake := s.RunDue(ctx)
+	require.Empty(t, rec.runs, "first start must not backfill")
+	require.Equal(t, time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC), wake)
+
+	clock.Set(time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC))
+	s.RunDue(ctx)
+	require.Len(t, rec.runs, 1)
+
+	// A restart at the same instant must not post the activation again.
+	s = newScheduler()
+	s.RunDue(ctx)
+	require.Len(t, rec.runs, 1)
+
+	// Three days of downtime run only the latest missed activation once.
+	clock.Set(time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC))
+	s = newScheduler()
+	wake = s.RunDue(ctx)
+	require.Equal(t, []time.Time{
+		time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 14, 8, 0, 0, 0, time.UTC),
+	}, rec.runs)
+	require.Equal(t, time.Date(2024, 3, 15, 8, 0, 0, 0, time.UTC), wake)
+}
+
+func TestScheduler_RunDue_retryAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	daily, err := Parse("0 8 * * *", nil)
+	require.NoError(t, err)
+	store := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, store.SetLastRun("daily", time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)))
+
+	clock := &fakeClock{now: time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC)}
+	rec := &recorder{err: errors.New("api down")}
+	s := New(store, clock, nopLogger{}, Job{Name: "daily", Schedule: daily, Run: rec.run})
+
+	wake := s.RunDue(ctx)
+	require.Equal(t, clock.Now().Add(retryDelay), wake)
+	last, _, err := store.LastRun("daily")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC), last.UTC(), "failed run must be rolled back")
+
+	rec.err = nil
+	s.RunDue(ctx)
+	require.Empty(t, rec.runs, "retry waits for retryDelay")
+
+	clock.Set(wake)
+	s.RunDue(ctx)
+	require.Equal(t, []time.Time{time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC)}, rec.runs)
+}
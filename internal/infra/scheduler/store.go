@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/storage"
+)
+
+// StateStore persists the last scheduled activation of each job.
+type StateStore interface {
+	LastRun(job string) (time.Time, bool, error)
+	SetLastRun(job string, at time.Time) error
+}
+
+// FileStore is a StateStore backed by a JSON file.
+type FileStore struct {
+	file *storage.JSONFile[map[string]time.Time]
+}
+
+// NewFileStore returns a FileStore at path; the file is created on first write.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{file: storage.NewJSONFile[map[string]time.Time](path)}
+}
+
+// LastRun implements StateStore.
+func (s *FileStore) LastRun(job string) (time.Time, bool, error) {
+	state, err := s.file.Load()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("scheduler state: %w", err)
+	}
+	t, ok := state[job]
+	return t, ok, nil
+}
+
+// SetLastRun implements StateStore.
+func (s *FileStore) SetLastRun(job string, at time.Time) error {
+	err := s.file.Update(func(state *map[string]time.Time) error {
+		if *state == nil {
+			*state = map[string]time.Time{}
+		}
+		(*state)[job] = at
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scheduler state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// AlertFile is a ports.AlertStore backed by a JSON file keyed by alert ID.
+type AlertFile struct {
+	file *JSONFile[map[string]domain.Alert]
+}
+
+// NewAlertFile returns an AlertFile at path; the file is created on first write.
+func NewAlertFile(path string) *AlertFile {
+	return &AlertFile{file: NewJSONFile[map[string]domain.Alert](path)}
+}
+
+// Alerts implements ports.AlertStore.
+func (s *AlertFile) Alerts() ([]domain.Alert, error) {
+	all, err := s.file.Load()
+	if err != nil {
+		return nil, fmt.Errorf("alerts: %w", err)
+	}
+	out := slices.Collect(maps.Values(all))
+	slices.SortFunc(out, func(a, b domain.Alert) int {
+		return cmp.Or(a.Created.Compare(b.Created), strings.Compare(a.ID, b.ID))
+	})
+	return out, nil
+}
+
+// AddAlert implements ports.AlertStore.
+func (s *AlertFile) AddAlert(a domain.Alert) error {
+	err := s.file.Update(func(all *map[string]domain.Alert) error {
+		if *all == nil {
+			*all = map[string]domain.Alert{}
+		}
+		(*all)[a.ID] = a
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("alerts: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlert implements ports.AlertStore.
+func (s *AlertFile) DeleteAlert(id string) (bool, error) {
+	var found bool
+	err := s.file.Update(func(all *map[string]domain.Alert) error {
+		_, found = (*all)[id]
+		delete(*all, id)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("alerts: %w", err)
+	}
+	return found, nil
+}
+
+// UpdateAlert implements ports.AlertStore.
+func (s *AlertFile) UpdateAlert(id string, fn func(*domain.Alert)) (bool, error) {
+	var found bool
+	err := s.file.Update(func(all *map[string]domain.Alert) error {
+		a, ok := (*all)[id]
+		if !ok {
+			return nil
+		}
+		found = true
+		fn(&a)
+		(*all)[id] = a
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("alerts: %w", err)
+	}
+	return found, nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	s := NewAlertFile(path)
+
+	all, err := s.Alerts()
+	require.NoError(t, err)
+	require.Empty(t, all)
+
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	pnl := domain.Alert{ID: "b", UserID: 7, Metric: domain.AlertPnL, Op: domain.AlertBelow, Threshold: -3, Percent: true,
+		Currency: "USDT", TimeZone: "Europe/Kyiv", Cooldown: time.Hour, Created: created}
+	loss := domain.Alert{ID: "a", UserID: 8, Metric: domain.AlertLoss, Symbol: "BTCUSDT", Op: domain.AlertAbove, Threshold: 500,
+		Created: created.Add(time.Minute)}
+	require.NoError(t, s.AddAlert(loss))
+	require.NoError(t, s.AddAlert(pnl))
+
+	fired := created.Add(time.Hour)
+	ok, err := s.UpdateAlert("b", func(a *domain.Alert) { a.Triggered, a.LastFired = true, fired })
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.UpdateAlert("missing", func(*domain.Alert) { t.Fatal("called for a missing alert") })
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	all, err = NewAlertFile(path).Alerts()
+	require.NoError(t, err)
+	pnl.Triggered, pnl.LastFired = true, fired
+	require.Equal(t, []domain.Alert{pnl, loss}, all)
+
+	ok, err = s.DeleteAlert("b")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.DeleteAlert("b")
+	require.NoError(t, err)
+	require.False(t, ok)
+	all, err = s.Alerts()
+	require.NoError(t, err)
+	require.Equal(t, []domain.Alert{loss}, all)
+}
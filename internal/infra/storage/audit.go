@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// AuditFile is a ports.AuditLog that appends entries to a file as JSON lines. Entries are never
+// rewritten, so the file can be shipped or rotated by external tools.
+type AuditFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAuditFile returns an AuditFile at path; the file and its directory are created on first write.
+func NewAuditFile(path string) *AuditFile {
+	return &AuditFile{path: path}
+}
+
+// Record implements ports.AuditLog.
+func (a *AuditFile) Record(e domain.AuditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("audit: encode: %w", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o750); err != nil {
+		return fmt.Errorf("audit: create dir for %s: %w", a.path, err)
+	}
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("audit: open %s: %w", a.path, err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		_ = f.Close() //nolint:errcheck // the write error is reported
+		return fmt.Errorf("audit: write %s: %w", a.path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("audit: close %s: %w", a.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	at := time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)
+	want := []domain.AuditEntry{
+		{Time: at, UserID: 1, User: "@alice", Command: "/pause", RequestID: "r1", Outcome: domain.AuditRequested},
+		{Time: at.Add(time.Second), UserID: 1, User: "@alice", Command: "/pause", RequestID: "r1", Outcome: domain.AuditOK, Detail: "paused"},
+	}
+	require.NoError(t, NewAuditFile(path).Record(want[0]))
+	require.NoError(t, NewAuditFile(path).Record(want[1]), "appends to an existing log")
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck // read-only
+	var got []domain.AuditEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e domain.AuditEntry
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &e))
+		got = append(got, e)
+	}
+	require.NoError(t, sc.Err())
+	require.Equal(t, want, got)
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// CriticalFile is a ports.CriticalStore backed by a JSON file keyed by page ID.
+type CriticalFile struct {
+	file *JSONFile[map[string]domain.CriticalPage]
+}
+
+// NewCriticalFile returns a CriticalFile at path; the file is created on first write.
+func NewCriticalFile(path string) *CriticalFile {
+	return &CriticalFile{file: NewJSONFile[map[string]domain.CriticalPage](path)}
+}
+
+// CriticalPages implements ports.CriticalStore, ordered by when they were raised.
+func (c *CriticalFile) CriticalPages() ([]domain.CriticalPage, error) {
+	all, err := c.file.Load()
+	if err != nil {
+		return nil, fmt.Errorf("critical pages: %w", err)
+	}
+	out := slices.Collect(maps.Values(all))
+	slices.SortFunc(out, func(a, b domain.CriticalPage) int {
+		return cmp.Or(a.Raised.Compare(b.Raised), strings.Compare(a.ID, b.ID))
+	})
+	return out, nil
+}
+
+// UpdateCriticalPages implements ports.CriticalStore.
+func (c *CriticalFile) UpdateCriticalPages(fn func(pages map[string]domain.CriticalPage)) error {
+	err := c.file.Update(func(all *map[string]domain.CriticalPage) error {
+		if *all == nil {
+			*all = map[string]domain.CriticalPage{}
+		}
+		fn(*all)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("critical pages: %w", err)
+	}
+	return nil
+}
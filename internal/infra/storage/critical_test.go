@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCriticalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "critical.json")
+	c := NewCriticalFile(path)
+
+	pages, err := c.CriticalPages()
+	require.NoError(t, err)
+	require.Empty(t, pages)
+
+	raised := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, c.UpdateCriticalPages(func(pages map[string]domain.CriticalPage) {
+		pages["b"] = domain.CriticalPage{ID: "b", Key: "k2", Text: "disk full", Raised: raised.Add(time.Minute), Paged: raised}
+		pages["a"] = domain.CriticalPage{ID: "a", Key: "k1", Text: "core down", Raised: raised, Paged: raised,
+			Messages: []domain.MessageRef{{ChatID: 7, MessageID: 3}}}
+	}))
+	acked := raised.Add(time.Hour)
+	require.NoError(t, c.UpdateCriticalPages(func(pages map[string]domain.CriticalPage) {
+		p := pages["a"]
+		p.AckedAt = &acked
+		pages["a"] = p
+	}))
+
+	pages, err = NewCriticalFile(path).CriticalPages()
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	require.Equal(t, "a", pages[0].ID)
+	require.Equal(t, []domain.MessageRef{{ChatID: 7, MessageID: 3}}, pages[0].Messages)
+	require.True(t, acked.Equal(*pages[0].AckedAt))
+	require.Nil(t, pages[1].AckedAt)
+}
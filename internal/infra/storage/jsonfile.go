@@ -0,0 +1,78 @@
+// Package storage provides small file-backed persistence helpers for bot-local state.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONFile keeps a value of type T in a JSON file that is replaced atomically on every update.
+// A missing file reads as the zero value of T.
+type JSONFile[T any] struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONFile returns a JSONFile at path; the file and its directory are created on first write.
+func NewJSONFile[T any](path string) *JSONFile[T] {
+	return &JSONFile[T]{path: path}
+}
+
+// Load reads the current value.
+func (f *JSONFile[T]) Load() (T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+// Update reads the current value, applies fn and writes the result back unless fn fails.
+func (f *JSONFile[T]) Update(fn func(v *T) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, err := f.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(&v); err != nil {
+		return err
+	}
+	return f.save(v)
+}
+
+func (f *JSONFile[T]) load() (T, error) {
+	var v T
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return v, fmt.Errorf("read %s: %w", f.path, err)
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return v, fmt.Errorf("decode %s: %w", f.path, err)
+	}
+	return v, nil
+}
+
+func (f *JSONFile[T]) save(v T) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", f.path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o750); err != nil {
+		return fmt.Errorf("create dir for %s: %w", f.path, err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("replace %s: %w", f.path, err)
+	}
+	return nil
+}
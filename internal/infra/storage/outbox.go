@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// OutboxFile is a ports.Outbox backed by a JSON file keyed by message ID.
+type OutboxFile struct {
+	file *JSONFile[map[string]ports.OutboundMessage]
+}
+
+// NewOutboxFile returns an OutboxFile at path; the file is created on first write.
+func NewOutboxFile(path string) *OutboxFile {
+	return &OutboxFile{file: NewJSONFile[map[string]ports.OutboundMessage](path)}
+}
+
+// Put implements ports.Outbox.
+func (o *OutboxFile) Put(m ports.OutboundMessage) error {
+	err := o.file.Update(func(all *map[string]ports.OutboundMessage) error {
+		if *all == nil {
+			*all = map[string]ports.OutboundMessage{}
+		}
+		(*all)[m.ID] = m
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+	return nil
+}
+
+// Remove implements ports.Outbox.
+func (o *OutboxFile) Remove(id string) error {
+	err := o.file.Update(func(all *map[string]ports.OutboundMessage) error {
+		delete(*all, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+	return nil
+}
+
+// Pending implements ports.Outbox.
+func (o *OutboxFile) Pending() ([]ports.OutboundMessage, error) {
+	all, err := o.file.Load()
+	if err != nil {
+		return nil, fmt.Errorf("outbox: %w", err)
+	}
+	out := slices.Collect(maps.Values(all))
+	slices.SortFunc(out, func(a, b ports.OutboundMessage) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+	return out, nil
+}
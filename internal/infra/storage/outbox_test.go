@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	o := NewOutboxFile(path)
+
+	pending, err := o.Pending()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	at := time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)
+	second := ports.OutboundMessage{ID: "b", RoutingKey: "commands", Body: []byte(`{"n":2}`), CreatedAt: at.Add(time.Second)}
+	first := ports.OutboundMessage{ID: "a", RoutingKey: "commands", Body: []byte(`{"n":1}`), CreatedAt: at,
+		Headers: map[string]string{"x-source": "bot"}}
+	require.NoError(t, o.Put(second))
+	require.NoError(t, o.Put(first))
+	first.Attempts, first.LastError = 1, "connection refused"
+	require.NoError(t, o.Put(first))
+
+	pending, err = NewOutboxFile(path).Pending()
+	require.NoError(t, err)
+	require.Equal(t, []ports.OutboundMessage{first, second}, pending)
+
+	require.NoError(t, o.Remove("a"))
+	require.NoError(t, o.Remove("missing"))
+	pending, err = o.Pending()
+	require.NoError(t, err)
+	require.Equal(t, []ports.OutboundMessage{second}, pending)
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// PreferencesFile is a ports.PreferencesStore backed by a JSON file keyed by user ID.
+type PreferencesFile struct {
+	file *JSONFile[map[int64]domain.Preferences]
+}
+
+// NewPreferencesFile returns a PreferencesFile at path; the file is created on first write.
+func NewPreferencesFile(path string) *PreferencesFile {
+	return &PreferencesFile{file: NewJSONFile[map[int64]domain.Preferences](path)}
+}
+
+// Preferences implements ports.PreferencesStore; unknown users get zero Preferences.
+func (s *PreferencesFile) Preferences(userID int64) (domain.Preferences, error) {
+	all, err := s.file.Load()
+	if err != nil {
+		return domain.Preferences{}, fmt.Errorf("preferences: %w", err)
+	}
+	return all[userID], nil
+}
+
+// AllPreferences implements ports.PreferencesReader.
+func (s *PreferencesFile) AllPreferences() (map[int64]domain.Preferences, error) {
+	all, err := s.file.Load()
+	if err != nil {
+		return nil, fmt.Errorf("preferences: %w", err)
+	}
+	return all, nil
+}
+
+// UpdatePreferences implements ports.PreferencesStore.
+func (s *PreferencesFile) UpdatePreferences(userID int64, fn func(*domain.Preferences)) error {
+	err := s.file.Update(func(all *map[int64]domain.Preferences) error {
+		if *all == nil {
+			*all = map[int64]domain.Preferences{}
+		}
+		p := (*all)[userID]
+		fn(&p)
+		(*all)[userID] = p
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("preferences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferencesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prefs", "preferences.json")
+	s := NewPreferencesFile(path)
+
+	got, err := s.Preferences(7)
+	require.NoError(t, err)
+	require.Equal(t, domain.Preferences{}, got)
+
+	require.NoError(t, s.UpdatePreferences(7, func(p *domain.Preferences) { p.Timezone = "Europe/Kyiv" }))
+
+	got, err = NewPreferencesFile(path).Preferences(7)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Kyiv", got.Timezone)
+
+	got, err = s.Preferences(8)
+	require.NoError(t, err)
+	require.Empty(t, got.Timezone)
+
+	all, err := s.AllPreferences()
+	require.NoError(t, err)
+	require.Equal(t, map[int64]domain.Preferences{7: {Timezone: "Europe/Kyiv"}}, all)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// SignalFile is a ports.SignalStore backed by a JSON file keyed by signal ID.
+type SignalFile struct {
+	file      *JSONFile[map[string]domain.SignalRecord]
+	retention time.Duration
+	now       func() time.Time
+}
+
+// SignalFileOption configures a SignalFile.
+type SignalFileOption func(*SignalFile)
+
+// WithSignalRetention archives signals that are done and have had no activity for d: the
+// messages showing them and their payload are dropped, while their outcome stays for
+// statistics. They are archived whenever the file is written. Without it, records are kept whole.
+func WithSignalRetention(d time.Duration) SignalFileOption {
+	return func(s *SignalFile) { s.retention = d }
+}
+
+// NewSignalFile returns a SignalFile at path; the file is created on first write.
+func NewSignalFile(path string, opts ...SignalFileOption) *SignalFile {
+	s := &SignalFile{file: NewJSONFile[map[string]domain.SignalRecord](path), now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Signal implements ports.SignalStore.
+func (s *SignalFile) Signal(id string) (domain.SignalRecord, bool, error) {
+	all, err := s.file.Load()
+	if err != nil {
+		return domain.SignalRecord{}, false, fmt.Errorf("signals: %w", err)
+	}
+	rec, ok := all[id]
+	return rec, ok, nil
+}
+
+// Signals implements ports.SignalReader, ordered by signal time.
+func (s *SignalFile) Signals() ([]domain.SignalRecord, error) {
+	all, err := s.file.Load()
+	if err != nil {
+		return nil, fmt.Errorf("signals: %w", err)
+	}
+	out := slices.Collect(maps.Values(all))
+	slices.SortFunc(out, func(a, b domain.SignalRecord) int {
+		return cmp.Or(a.Signal.Time.Compare(b.Signal.Time), strings.Compare(a.Signal.ID, b.Signal.ID))
+	})
+	return out, nil
+}
+
+// UpdateSignal implements ports.SignalStore.
+func (s *SignalFile) UpdateSignal(id string, fn func(*domain.SignalRecord)) error {
+	err := s.file.Update(func(all *map[string]domain.SignalRecord) error {
+		if *all == nil {
+			*all = map[string]domain.SignalRecord{}
+		}
+		s.prune(*all, id)
+		rec := (*all)[id]
+		fn(&rec)
+		(*all)[id] = rec
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("signals: %w", err)
+	}
+	return nil
+}
+
+// prune archives expired signals in all, except keep.
+func (s *SignalFile) prune(all map[string]domain.SignalRecord, keep string) {
+	if s.retention <= 0 {
+		return
+	}
+	cutoff := s.now().Add(-s.retention)
+	for id, rec := range all {
+		if id == keep || rec.Archived || !rec.Done() || !rec.LastActivity().Before(cutoff) {
+			continue
+		}
+		rec.Messages, rec.Payload, rec.Archived = nil, nil, true
+		all[id] = rec
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signals.json")
+	s := NewSignalFile(path)
+
+	_, ok, err := s.Signal("s1")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	sig := domain.Signal{ID: "s1", Symbol: "BTCUSDT", Side: domain.SideLong, Entry: 100, StopLoss: 95,
+		TakeProfits: []float64{110}, Time: time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)}
+	require.NoError(t, s.UpdateSignal("s1", func(r *domain.SignalRecord) {
+		r.Signal = sig
+		r.Messages = append(r.Messages, domain.MessageRef{ChatID: -100, MessageID: 42})
+	}))
+
+	rec, ok, err := NewSignalFile(path).Signal("s1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, sig, rec.Signal)
+	ref, ok := rec.Message(-100)
+	require.True(t, ok)
+	require.Equal(t, 42, ref.MessageID)
+
+	require.NoError(t, s.UpdateSignal("s0", func(r *domain.SignalRecord) {
+		r.Signal = domain.Signal{ID: "s0", Time: sig.Time.Add(-time.Hour)}
+	}))
+	all, err := s.Signals()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, "s0", all[0].Signal.ID)
+}
+
+func TestSignalFile_retention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signals.json")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSignalFile(path, WithSignalRetention(30*24*time.Hour))
+	s.now = func() time.Time { return now }
+
+	old := now.Add(-40 * 24 * time.Hour)
+	put := func(id string, rec domain.SignalRecord) {
+		t.Helper()
+		require.NoError(t, s.file.Update(func(all *map[string]domain.SignalRecord) error {
+			if *all == nil {
+				*all = map[string]domain.SignalRecord{}
+			}
+			(*all)[id] = rec
+			return nil
+		}))
+	}
+	closed := []domain.SignalEvent{{Type: domain.SignalClosed, Price: 101, Time: old}}
+	msgs := []domain.MessageRef{{ChatID: -100, MessageID: 1}}
+	put("open", domain.SignalRecord{Signal: domain.Signal{ID: "open", Time: old}, Messages: msgs})
+	put("closed", domain.SignalRecord{Signal: domain.Signal{ID: "closed", Symbol: "BTCUSDT", Side: domain.SideLong,
+		Entry: 100, StopLoss: 95, Time: old}, Payload: []byte(`{"id":"closed"}`), Events: closed, Messages: msgs})
+	put("recent", domain.SignalRecord{Signal: domain.Signal{ID: "recent", Time: old}, Messages: msgs,
+		Events: []domain.SignalEvent{{Type: domain.SignalCancelled, Time: now.Add(-time.Hour)}}})
+	put("late", domain.SignalRecord{Signal: domain.Signal{ID: "late", Time: old}, Events: closed})
+	stats := func() domain.SignalStats {
+		t.Helper()
+		all, err := s.Signals()
+		require.NoError(t, err)
+		return usecase.SignalStatsOf(all, "")
+	}
+	before := stats()
+
+	// Writing archives finished signals past retention, but not the one being updated.
+	require.NoError(t, s.UpdateSignal("late", func(r *domain.SignalRecord) {
+		r.Messages = append(r.Messages, domain.MessageRef{ChatID: 7, MessageID: 1})
+	}))
+	for id, archived := range map[string]bool{"open": false, "closed": true, "recent": false, "late": false} {
+		rec, ok, err := s.Signal(id)
+		require.NoError(t, err)
+		require.True(t, ok, id)
+		require.Equal(t, archived, rec.Archived, id)
+		require.Equal(t, archived, rec.Messages == nil, id)
+	}
+	rec, _, err := s.Signal("closed")
+	require.NoError(t, err)
+	require.Empty(t, rec.Payload)
+	require.Equal(t, closed, rec.Events)
+
+	// Archived signals still count in the statistics.
+	require.Equal(t, before, stats())
+	require.Equal(t, 4, stats().Signals)
+}
@@ -0,0 +1,19 @@
+package ports
+
+import "github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+
+// AlertStore persists user alerts together with their evaluation state.
+type AlertStore interface {
+	// Alerts returns every alert in creation order.
+	Alerts() ([]domain.Alert, error)
+	AddAlert(a domain.Alert) error
+	// DeleteAlert removes alert id; it reports false for unknown alerts.
+	DeleteAlert(id string) (bool, error)
+	// UpdateAlert applies fn to alert id; it reports false, without calling fn, for unknown alerts.
+	UpdateAlert(id string, fn func(*domain.Alert)) (bool, error)
+}
+
+// AlertNotifier tells the owner of an alert that it fired at value.
+type AlertNotifier interface {
+	NotifyAlert(a domain.Alert, value float64) error
+}
@@ -0,0 +1,18 @@
+package ports
+
+import "time"
+
+// Clock abstracts wall-clock time so schedulers can be driven by a fake in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the real-time Clock.
+type SystemClock struct{}
+
+// Now returns the current time.
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// After waits for d to elapse and then sends the current time on the returned channel.
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// CoreController sends control requests to the trading core. Control returns the core's
+// acknowledgment; it fails when the core does not answer before ctx is done.
+type CoreController interface {
+	Control(ctx context.Context, req domain.ControlRequest) (domain.ControlReply, error)
+}
+
+// AuditLog records invocations of privileged commands.
+type AuditLog interface {
+	Record(e domain.AuditEntry) error
+}
@@ -0,0 +1,12 @@
+package ports
+
+import "github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+
+// CriticalStore persists critical system messages paged to the admins, so repeats and
+// acknowledgments survive a restart.
+type CriticalStore interface {
+	// CriticalPages returns every stored page.
+	CriticalPages() ([]domain.CriticalPage, error)
+	// UpdateCriticalPages applies fn to the stored pages, keyed by ID, and saves the result.
+	UpdateCriticalPages(fn func(pages map[string]domain.CriticalPage)) error
+}
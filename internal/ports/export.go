@@ -0,0 +1,47 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// Trade is a single closed trade returned by report providers.
+type Trade struct {
+	ID         string
+	Symbol     string
+	Side       string
+	OpenedAt   string
+	ClosedAt   string
+	Quantity   float64
+	EntryPrice float64
+	ExitPrice  float64
+	Fee        float64
+	PnL        float64
+}
+
+// TradeFetcher streams closed trades of an account for a [from, to) range to fn in close-time
+// order; a non-nil error from fn stops the stream and is returned.
+type TradeFetcher interface {
+	FetchTrades(ctx context.Context, account string, from, to time.Time, fn func(Trade) error) error
+}
+
+// ExportFile is one finished export document on local disk.
+type ExportFile struct {
+	Name string
+	Path string
+	Size int64
+}
+
+// TableWriter streams rows into one or more export files. Cells are strings or float64.
+type TableWriter interface {
+	WriteRow(cells ...any) error
+	// Close finishes the last file and returns all parts in order.
+	Close() ([]ExportFile, error)
+	// Remove deletes every file the writer produced.
+	Remove() error
+}
+
+// TableWriterFactory opens a TableWriter for format (e.g. "csv", "xlsx") with the given base name and header row.
+type TableWriterFactory interface {
+	NewTableWriter(format, name string, header []string) (TableWriter, error)
+}
@@ -0,0 +1,40 @@
+package ports
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDeferred is returned by publishers that could not reach the broker but stored the message
+// in their outbox; it is delivered once the broker is back.
+var ErrDeferred = errors.New("broker unavailable, message queued for delivery")
+
+// OutboundMessage is a message the bot publishes to the broker. ID is also the AMQP message ID,
+// so consumers can drop the duplicates a replay may produce.
+type OutboundMessage struct {
+	ID            string            `json:"id"`
+	Exchange      string            `json:"exchange"`
+	RoutingKey    string            `json:"routing_key"`
+	ContentType   string            `json:"content_type,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	ReplyTo       string            `json:"reply_to,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Body          []byte            `json:"body"`
+	CreatedAt     time.Time         `json:"created_at"`
+	// ExpiresAt, if set, is when the message goes stale: it is then dropped instead of replayed,
+	// and the broker drops it from queues too.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// Attempts and LastError describe failed deliveries so far.
+	Attempts  int    `json:"attempts,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Outbox persists outbound messages until the broker confirms them.
+type Outbox interface {
+	// Put adds m, or replaces the message with its ID.
+	Put(m OutboundMessage) error
+	// Remove deletes message id; unknown IDs are ignored.
+	Remove(id string) error
+	// Pending returns the stored messages, oldest first.
+	Pending() ([]OutboundMessage, error)
+}
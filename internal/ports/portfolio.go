@@ -0,0 +1,28 @@
+package ports
+
+import "context"
+
+// PositionResult is an open position returned by portfolio providers.
+type PositionResult struct {
+	Symbol           string
+	Side             string
+	Size             float64
+	EntryPrice       float64
+	MarkPrice        float64
+	UnrealizedPnL    float64
+	LiquidationPrice float64
+}
+
+// BalanceResult is the balance of one asset returned by portfolio providers.
+type BalanceResult struct {
+	Asset     string
+	Total     float64
+	Available float64
+}
+
+// PortfolioFetcher fetches the live state of an account; an empty account selects the core's
+// default account.
+type PortfolioFetcher interface {
+	FetchPositions(ctx context.Context, account string) ([]PositionResult, error)
+	FetchBalances(ctx context.Context, account string) ([]BalanceResult, error)
+}
@@ -0,0 +1,15 @@
+package ports
+
+import "github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+
+// PreferencesReader reads per-user preferences keyed by Telegram user ID.
+type PreferencesReader interface {
+	Preferences(userID int64) (domain.Preferences, error)
+	AllPreferences() (map[int64]domain.Preferences, error)
+}
+
+// PreferencesStore reads and updates per-user preferences.
+type PreferencesStore interface {
+	PreferencesReader
+	UpdatePreferences(userID int64, fn func(*domain.Preferences)) error
+}
@@ -0,0 +1,8 @@
+package ports
+
+import "context"
+
+// RateFetcher returns how many units of quote one unit of base is worth.
+type RateFetcher interface {
+	FetchRate(ctx context.Context, base, quote string) (float64, error)
+}
@@ -1,6 +1,11 @@
 package ports
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
 
 // ReportResult is the DTO returned by report providers (e.g. HTTP API).
 type ReportResult struct {
@@ -8,7 +13,44 @@ type ReportResult struct {
 	Expense float64
 }
 
-// ReportFetcher fetches report data for a date range from an external source.
+// ReportFetcher fetches report data of an account from an external source.
+// An empty account selects the core's default account. Ranges passed to report ports are
+// [from, to) instants located in the user's time zone, which also defines day boundaries.
 type ReportFetcher interface {
-	FetchReport(ctx context.Context, from, to string) (*ReportResult, error)
+	FetchReport(ctx context.Context, account string, from, to time.Time) (*ReportResult, error)
+}
+
+// PnLPoint is one day of realized profit/loss returned by report providers.
+type PnLPoint struct {
+	Date string
+	PnL  float64
+}
+
+// ReportSeriesFetcher fetches the daily PnL series of an account for a range.
+type ReportSeriesFetcher interface {
+	FetchDailyPnL(ctx context.Context, account string, from, to time.Time) ([]PnLPoint, error)
+}
+
+// ChartRenderer renders a daily PnL series as an encoded image.
+type ChartRenderer interface {
+	RenderPnL(series []domain.DailyPnL) ([]byte, error)
+}
+
+// PnLGroupResult is the PnL, trade count and winning trade count of one symbol or strategy.
+type PnLGroupResult struct {
+	Name   string
+	PnL    float64
+	Trades int
+	Wins   int
+}
+
+// BreakdownResult is the DTO of a per-symbol and per-strategy PnL breakdown.
+type BreakdownResult struct {
+	Symbols    []PnLGroupResult
+	Strategies []PnLGroupResult
+}
+
+// ReportBreakdownFetcher fetches the per-symbol and per-strategy PnL of an account for a range.
+type ReportBreakdownFetcher interface {
+	FetchBreakdown(ctx context.Context, account string, from, to time.Time) (*BreakdownResult, error)
 }
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+)
+
+// RPCClient calls procedures of the trading core and waits for their results.
+type RPCClient interface {
+	// Call sends params as JSON to method and decodes the JSON result into result, unless it is
+	// nil. It fails when ctx is done or the client's own timeout passes before the reply.
+	Call(ctx context.Context, method string, params, result any) error
+}
+
+// RemoteError is a failure reported by the remote procedure itself.
+type RemoteError struct {
+	Method  string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("%s: remote error: %s", e.Method, e.Message)
+}
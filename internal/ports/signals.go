@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// SignalReader reads the ledger of published signals.
+type SignalReader interface {
+	// Signal returns the record of signal id; ok is false for unknown signals.
+	Signal(id string) (rec domain.SignalRecord, ok bool, err error)
+	// Signals returns every recorded signal.
+	Signals() ([]domain.SignalRecord, error)
+}
+
+// SignalStore persists published signals, their lifecycle events and the messages showing them.
+type SignalStore interface {
+	SignalReader
+	// UpdateSignal applies fn to the record of signal id, creating it if needed.
+	UpdateSignal(id string, fn func(*domain.SignalRecord)) error
+}
+
+// SignalCommandPublisher sends operator commands on signals to the trading core.
+// PublishSignalCommand returns once the broker has accepted the command.
+type SignalCommandPublisher interface {
+	PublishSignalCommand(ctx context.Context, cmd domain.SignalCommand) error
+}
@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"fmt"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// accountsFor returns the accounts userID may see according to their role.
+func (h *Handler) accountsFor(userID int64) []domain.Account {
+	r := h.roleOf(userID)
+	if r < roleViewer {
+		return nil
+	}
+	return h.config().AccountsForRole(r.String())
+}
+
+// accountFor resolves account id if userID may see it.
+func (h *Handler) accountFor(userID int64, id string) (domain.Account, bool) {
+	for _, a := range h.accountsFor(userID) {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return domain.Account{}, false
+}
+
+// defaultAccount returns the preferred account ID of userID (possibly AllAccounts) if they
+// may still see it.
+func (h *Handler) defaultAccount(userID int64) (string, bool) {
+	id := h.preferences(userID).Account
+	if id == "" {
+		return "", false
+	}
+	if id == domain.AllAccounts {
+		return id, len(h.accountsFor(userID)) > 1
+	}
+	_, ok := h.accountFor(userID, id)
+	return id, ok
+}
+
+func (h *Handler) sendAccountPickerBestEffort(chatID int64, tr i18n.Localizer, accts []domain.Account) {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(accts)+1)
+	for _, a := range accts {
+		label := a.Label()
+		if a.Quote != "" {
+			label = fmt.Sprintf("%s (%s)", label, a.Quote)
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "acct:"+a.ID)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(tr.T("accounts.all"), "acct:"+domain.AllAccounts)))
+
+	msg := tgbotapi.NewMessage(chatID, tr.T("accounts.select"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := h.bot.Send(msg); err != nil {
+		return
+	}
+}
@@ -0,0 +1,261 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// alertButtonsPerRow is how many delete buttons share a keyboard row in /alert list.
+const alertButtonsPerRow = 5
+
+// cmdAlert handles "/alert add <metric> [symbol] <op> <value>[%] [@account] [cooldown]" and
+// "/alert [list]".
+func (h *Handler) cmdAlert(_ context.Context, msg *tgbotapi.Message, args string) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	tr := h.tr(msg.From)
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0 || strings.EqualFold(fields[0], "list"):
+		text, kb, err := h.alertList(tr, userID)
+		if err != nil {
+			h.replyBestEffort(chatID, tr.T("error", "err", err))
+			return
+		}
+		out := tgbotapi.NewMessage(chatID, text)
+		if kb != nil {
+			out.ReplyMarkup = *kb
+		}
+		if _, err := h.bot.Send(out); err != nil {
+			return
+		}
+	case strings.EqualFold(fields[0], "add"):
+		h.addAlert(msg, fields[1:])
+	default:
+		h.replyBestEffort(chatID, tr.T("alert.usage"))
+	}
+}
+
+func (h *Handler) addAlert(msg *tgbotapi.Message, fields []string) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	tr := h.tr(msg.From)
+	a, acctID, explicit, ok := parseAlertArgs(fields)
+	if !ok {
+		h.replyBestEffort(chatID, tr.T("alert.usage"))
+		return
+	}
+	acct, ok := h.alertAccount(userID, acctID, explicit)
+	if !ok {
+		if explicit {
+			h.replyBestEffort(chatID, tr.T("portfolio.unknown_account", "account", acctID))
+		} else {
+			h.replyBestEffort(chatID, tr.T("alert.need_account"))
+		}
+		return
+	}
+	a.UserID, a.Account, a.Currency = userID, acct.ID, acct.Quote
+	a.TimeZone = h.location(userID).String()
+	if a.Cooldown < 0 {
+		a.Cooldown = time.Duration(h.config().AlertCooldownMinutes) * time.Minute
+	}
+
+	a, err := h.alertUC.Add(a)
+	switch {
+	case errors.Is(err, domain.ErrInvalidAlert):
+		h.replyBestEffort(chatID, tr.T("alert.invalid", "err", err))
+	case errors.Is(err, usecase.ErrTooManyAlerts):
+		h.replyBestEffort(chatID, tr.T("alert.too_many", "max", usecase.MaxAlertsPerUser))
+	case err != nil:
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+	default:
+		h.replyBestEffort(chatID, tr.T("alert.added", "alert", alertText(tr, h.moneyFor(userID), a)))
+	}
+}
+
+// parseAlertArgs reads "<metric> [symbol] <op> <value>[%] [@account] [cooldown]"; the comparison
+// may be joined to the value, as in "<-3%". Without a cooldown the returned one is negative.
+func parseAlertArgs(fields []string) (a domain.Alert, account string, explicit, ok bool) {
+	a.Cooldown = -1
+	if len(fields) < 3 {
+		return a, "", false, false
+	}
+	a.Metric = domain.AlertMetric(strings.ToLower(fields[0]))
+	fields = fields[1:]
+	if !strings.HasPrefix(fields[0], ">") && !strings.HasPrefix(fields[0], "<") {
+		a.Symbol, fields = strings.ToUpper(fields[0]), fields[1:]
+	}
+	if len(fields) == 0 || (!strings.HasPrefix(fields[0], ">") && !strings.HasPrefix(fields[0], "<")) {
+		return a, "", false, false
+	}
+	a.Op = domain.AlertOp(fields[0][:1])
+	value := fields[0][1:]
+	fields = fields[1:]
+	if value == "" {
+		if len(fields) == 0 {
+			return a, "", false, false
+		}
+		value, fields = fields[0], fields[1:]
+	}
+	value, a.Percent = strings.CutSuffix(value, "%")
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return a, "", false, false
+	}
+	a.Threshold = v
+
+	for _, f := range fields {
+		if id, ok := strings.CutPrefix(f, "@"); ok && !explicit {
+			account, explicit = id, true
+			continue
+		}
+		d, err := time.ParseDuration(f)
+		if err != nil || d < 0 || a.Cooldown >= 0 {
+			return a, "", false, false
+		}
+		a.Cooldown = d
+	}
+	return a, account, explicit, true
+}
+
+// alertAccount resolves the account of a new alert: the one named, else the user's default
+// account, else their only account.
+func (h *Handler) alertAccount(userID int64, id string, explicit bool) (domain.Account, bool) {
+	if explicit {
+		return h.accountFor(userID, id)
+	}
+	if id, ok := h.defaultAccount(userID); ok && id != domain.AllAccounts {
+		return h.accountFor(userID, id)
+	}
+	if accts := h.accountsFor(userID); len(accts) == 1 {
+		return accts[0], true
+	}
+	return domain.Account{}, false
+}
+
+// alertList renders the alerts of userID with a delete button for each, or nil buttons when
+// there are none.
+func (h *Handler) alertList(tr i18n.Localizer, userID int64) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	alerts, err := h.alertUC.List(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(alerts) == 0 {
+		return tr.T("alert.none") + "\n\n" + tr.T("alert.usage"), nil, nil
+	}
+	f := h.moneyFor(userID)
+	lines := []string{tr.T("alert.list_title", "count", len(alerts), "max", usecase.MaxAlertsPerUser)}
+	var (
+		rows [][]tgbotapi.InlineKeyboardButton
+		row  []tgbotapi.InlineKeyboardButton
+	)
+	for i, a := range alerts {
+		state := "🟢"
+		if a.Triggered {
+			state = "🔴"
+		}
+		line := fmt.Sprintf("%d. %s %s", i+1, state, alertText(tr, f, a))
+		if a.Cooldown > 0 {
+			line += " · " + tr.T("alert.cooldown", "duration", shortDuration(a.Cooldown))
+		}
+		lines = append(lines, line)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr.T("alert.btn_delete", "n", i+1), "al:del:"+a.ID))
+		if len(row) == alertButtonsPerRow {
+			rows, row = append(rows, row), nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return strings.Join(lines, "\n"), &kb, nil
+}
+
+// handleAlertCallback deletes the alert of an "al:del:<id>" button and updates the list in place.
+func (h *Handler) handleAlertCallback(q *tgbotapi.CallbackQuery, data string) {
+	tr := h.tr(q.From)
+	id, ok := strings.CutPrefix(data, "al:del:")
+	if !ok || h.alertUC == nil {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	deleted, err := h.alertUC.Delete(q.From.ID, id)
+	switch {
+	case err != nil:
+		h.answerCallbackBestEffort(q, tr.T("error", "err", err))
+		return
+	case deleted:
+		h.answerCallbackBestEffort(q, tr.T("alert.deleted"))
+	default:
+		h.answerCallbackBestEffort(q, tr.T("alert.not_found"))
+	}
+
+	text, kb, err := h.alertList(tr, q.From.ID)
+	if err != nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text)
+	edit.ReplyMarkup = kb
+	if _, err := h.bot.Send(edit); err != nil {
+		return
+	}
+}
+
+// NotifyAlert implements ports.AlertNotifier: the owner of a gets a direct message, silently
+// during their quiet hours. Users who lost access are skipped.
+func (h *Handler) NotifyAlert(a domain.Alert, value float64) error {
+	if h.roleOf(a.UserID) < roleViewer {
+		return nil
+	}
+	tr := h.tr(&tgbotapi.User{ID: a.UserID})
+	f := h.moneyFor(a.UserID)
+	msg := tgbotapi.NewMessage(a.UserID, tr.T("alert.fired", "alert", alertText(tr, f, a), "value", alertValue(f, a, value)))
+	msg.DisableNotification = h.preferences(a.UserID).Quiet(time.Now(), h.defaultLocation())
+	if _, err := h.bot.Send(msg); err != nil {
+		return fmt.Errorf("telegram alert %s: %w", a.ID, err)
+	}
+	return nil
+}
+
+// alertText describes the condition of a, e.g. "Unrealized loss BTCUSDT > 500.00 USDT · main".
+func alertText(tr i18n.Localizer, f moneyFormat, a domain.Alert) string {
+	s := tr.T("alert.metric." + string(a.Metric))
+	if a.Symbol != "" {
+		s += " " + a.Symbol
+	}
+	s += " " + string(a.Op) + " " + alertValue(f, a, a.Threshold)
+	if a.Account != "" {
+		s += " · " + a.Account
+	}
+	return s
+}
+
+// alertValue renders a value of the metric of a.
+func alertValue(f moneyFormat, a domain.Alert, v float64) string {
+	switch {
+	case a.Percent:
+		return f.Number(v, 2) + "%"
+	case a.Metric == domain.AlertPrice:
+		return price(f, v)
+	}
+	return f.Amount(v, a.Currency)
+}
+
+// shortDuration renders d without zero minutes and seconds, e.g. "1h" or "1h30m".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
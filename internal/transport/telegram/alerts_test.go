@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+type memAlerts map[string]domain.Alert
+
+func (m memAlerts) Alerts() ([]domain.Alert, error) {
+	var out []domain.Alert
+	for _, a := range m {
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func (m memAlerts) AddAlert(a domain.Alert) error {
+	m[a.ID] = a
+	return nil
+}
+
+func (m memAlerts) DeleteAlert(id string) (bool, error) {
+	_, ok := m[id]
+	delete(m, id)
+	return ok, nil
+}
+
+func (m memAlerts) UpdateAlert(id string, fn func(*domain.Alert)) (bool, error) {
+	a, ok := m[id]
+	if ok {
+		fn(&a)
+		m[id] = a
+	}
+	return ok, nil
+}
+
+func TestParseAlertArgs(t *testing.T) {
+	tests := []struct {
+		args     string
+		want     domain.Alert
+		account  string
+		explicit bool
+	}{
+		{"pnl < -3%", domain.Alert{Metric: domain.AlertPnL, Op: domain.AlertBelow, Threshold: -3, Percent: true, Cooldown: -1}, "", false},
+		{"LOSS btcusdt >500 @main 30m", domain.Alert{Metric: domain.AlertLoss, Symbol: "BTCUSDT", Op: domain.AlertAbove, Threshold: 500, Cooldown: 30 * time.Minute}, "main", true},
+		{"price ETHUSDT > 3500.5 0s", domain.Alert{Metric: domain.AlertPrice, Symbol: "ETHUSDT", Op: domain.AlertAbove, Threshold: 3500.5}, "", false},
+	}
+	for _, tt := range tests {
+		a, account, explicit, ok := parseAlertArgs(strings.Fields(tt.args))
+		require.True(t, ok, tt.args)
+		require.Equal(t, tt.want, a, tt.args)
+		require.Equal(t, tt.account, account, tt.args)
+		require.Equal(t, tt.explicit, explicit, tt.args)
+	}
+
+	for _, args := range []string{"pnl", "pnl -3", "upnl BTCUSDT = 5", "pnl < x", "pnl <", "pnl < 1 soon", "pnl < 1 1m 2m", "pnl < 1 @a @b"} {
+		_, _, _, ok := parseAlertArgs(strings.Fields(args))
+		require.False(t, ok, args)
+	}
+}
+
+func TestHandler_alerts(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	store := memAlerts{}
+	prefs := memPreferences{}
+	cfg := &config.Config{
+		UserIDs:              []int64{7},
+		ViewerIDs:            []int64{8},
+		Accounts:             []domain.Account{{ID: "main", Quote: "USDT"}, {ID: "alt", Quote: "USDC"}},
+		AlertCooldownMinutes: 60,
+	}
+	h := NewHandler(bot, cfg, nil, WithPreferences(prefs), WithAlerts(usecase.NewAlertUsecase(store, nil, nil)))
+	ctx := context.Background()
+	lastText := func() string {
+		sent := fake.Calls("sendMessage")
+		return sent[len(sent)-1].params.Get("text")
+	}
+
+	h.handleMessage(ctx, commandMessage(7, "/alert"))
+	require.True(t, strings.HasPrefix(lastText(), "You have no alerts.\n\nUsage:"), lastText())
+
+	// With several accounts and no default the account must be named.
+	h.handleMessage(ctx, commandMessage(7, "/alert add pnl < -3%"))
+	require.Contains(t, lastText(), "You have several accounts")
+	h.handleMessage(ctx, commandMessage(7, "/alert add pnl < -3% @nope"))
+	require.Equal(t, "Unknown account: nope", lastText())
+	h.handleMessage(ctx, commandMessage(7, "/alert add loss BTCUSDT > 500 @main"))
+	require.Equal(t, "✅ Alert added: Unrealized loss BTCUSDT > 500.00 USDT · main", lastText())
+	h.handleMessage(ctx, commandMessage(7, "/alert add pnl BTCUSDT < 1 @main"))
+	require.Equal(t, "Invalid alert: invalid alert: pnl is per account, not per symbol", lastText())
+
+	prefs[7] = domain.Preferences{Account: "alt", Timezone: "Europe/Kyiv"}
+	h.handleMessage(ctx, commandMessage(7, "/alert add pnl < -3% 2h30m"))
+	require.Equal(t, "✅ Alert added: Daily PnL < -3.00% · alt", lastText())
+
+	all, err := store.Alerts()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	var loss, pct domain.Alert
+	for _, a := range all {
+		if a.Metric == domain.AlertLoss {
+			loss = a
+		} else {
+			pct = a
+		}
+	}
+	require.Equal(t, time.Hour, loss.Cooldown, "ALERT_COOLDOWN by default")
+	require.Equal(t, "Europe/Kyiv", pct.TimeZone)
+	require.Equal(t, "USDC", pct.Currency)
+	require.Equal(t, 150*time.Minute, pct.Cooldown)
+
+	// Fired alerts are sent to their owner; users without access get nothing.
+	require.NoError(t, h.NotifyAlert(loss, 612.5))
+	require.Equal(t, "🔔 Alert: Unrealized loss BTCUSDT > 500.00 USDT · main\nNow: 612.50 USDT", lastText())
+	require.Equal(t, "7", fake.Calls("sendMessage")[len(fake.Calls("sendMessage"))-1].params.Get("chat_id"))
+	sent := len(fake.Calls("sendMessage"))
+	require.NoError(t, h.NotifyAlert(domain.Alert{UserID: 99, Metric: domain.AlertPnL, Op: domain.AlertBelow}, -1))
+	require.Len(t, fake.Calls("sendMessage"), sent)
+
+	// Other users neither see nor delete the alerts.
+	h.handleMessage(ctx, commandMessage(8, "/alert list"))
+	require.True(t, strings.HasPrefix(lastText(), "You have no alerts."))
+	h.handleCallback(ctx, signalCallback(8, 60, "al:del:"+loss.ID))
+	require.Equal(t, "Alert not found", fake.Calls("answerCallbackQuery")[0].params.Get("text"))
+	require.Len(t, store, 2)
+	require.True(t, strings.HasPrefix(fake.Calls("editMessageText")[0].params.Get("text"), "You have no alerts."), "their own list is refreshed")
+
+	h.handleMessage(ctx, commandMessage(7, "/alert list"))
+	list := fake.Calls("sendMessage")
+	require.Contains(t, lastText(), "🔔 Your alerts (2/20):\n1. 🟢 ")
+	require.Contains(t, lastText(), "Daily PnL < -3.00% · alt · cooldown 2h30m")
+	data := keyboardData(t, list[len(list)-1].params.Get("reply_markup"))
+	require.Len(t, data, 2)
+
+	h.handleCallback(ctx, signalCallback(7, 61, "al:del:"+loss.ID))
+	require.Equal(t, "Alert deleted", fake.Calls("answerCallbackQuery")[1].params.Get("text"))
+	require.NotContains(t, store, loss.ID)
+	edits := fake.Calls("editMessageText")
+	require.Len(t, edits, 2)
+	require.Equal(t, "61", edits[1].params.Get("message_id"))
+	require.Equal(t, "🔔 Your alerts (1/20):\n1. 🟢 Daily PnL < -3.00% · alt · cooldown 2h30m", edits[1].params.Get("text"))
+	require.Equal(t, []string{"al:del:" + pct.ID}, keyboardData(t, edits[1].params.Get("reply_markup")))
+}
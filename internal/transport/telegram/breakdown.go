@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxMessageLen is the Telegram limit for a text message.
+	maxMessageLen = 4096
+	// breakdownTopN is how many best and worst symbols a breakdown lists.
+	breakdownTopN = 5
+	// breakdownPageLen leaves room for the page title and <pre> markup within maxMessageLen.
+	breakdownPageLen = maxMessageLen - 256
+)
+
+// handleBreakdownCallback serves "bd:<account>:<from>:<to>[:<page>]"; without a page a new
+// message is sent, with a page the pressed message is edited in place.
+func (h *Handler) handleBreakdownCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	chatID := q.Message.Chat.ID
+	tr := h.tr(q.From)
+	parts := strings.Split(data, ":")[1:]
+	page, edit := 0, false
+	if len(parts) == 4 {
+		p, err := strconv.Atoi(parts[3])
+		if err != nil || p < 0 {
+			h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+			return
+		}
+		page, edit, parts = p, true, parts[:3]
+	}
+	acct, from, to, ok := h.reportArgs(q.From.ID, parts)
+	if !ok {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	h.answerCallbackBestEffort(q, "")
+
+	b, err := h.reportUC.GetBreakdown(ctx, acct, h.period(q.From.ID, from, to))
+	if errors.Is(err, usecase.ErrNoData) {
+		h.replyBestEffort(chatID, tr.T("breakdown.no_trades", "from", from, "to", to))
+		return
+	}
+	if err != nil {
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+		return
+	}
+
+	pages := breakdownPages(tr, h.moneyFor(q.From.ID), b, breakdownTopN, breakdownPageLen)
+	page = min(page, len(pages)-1)
+	text := breakdownTitle(tr, b, page, len(pages)) + "\n<pre>" + pages[page] + "</pre>"
+	kb := breakdownKeyboard(tr, b, page, len(pages))
+
+	if edit {
+		msg := tgbotapi.NewEditMessageText(chatID, q.Message.MessageID, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		if kb != nil {
+			msg.ReplyMarkup = kb
+		}
+		if _, err := h.bot.Send(msg); err != nil {
+			return
+		}
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if kb != nil {
+		msg.ReplyMarkup = *kb
+	}
+	if _, err := h.bot.Send(msg); err != nil {
+		return
+	}
+}
+
+func breakdownTitle(tr i18n.Localizer, b *domain.Breakdown, page, pages int) string {
+	title := html.EscapeString(tr.T("breakdown.title", "from", b.From, "to", b.To, "zone", zoneSuffix(b.TimeZone)))
+	if b.Account != "" {
+		title = html.EscapeString(b.Account) + ": " + title
+	}
+	if pages > 1 {
+		title += fmt.Sprintf(" (%d/%d)", page+1, pages)
+	}
+	return title
+}
+
+// breakdownKeyboard returns previous/next page buttons, or nil for a single page.
+func breakdownKeyboard(tr i18n.Localizer, b *domain.Breakdown, page, pages int) *tgbotapi.InlineKeyboardMarkup {
+	if pages < 2 {
+		return nil
+	}
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr.T("breakdown.btn_prev"), fmt.Sprintf("bd:%s:%s:%s:%d", b.Account, b.From, b.To, page-1)))
+	}
+	if page < pages-1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr.T("breakdown.btn_next"), fmt.Sprintf("bd:%s:%s:%s:%d", b.Account, b.From, b.To, page+1)))
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &kb
+}
+
+// breakdownPages renders top/bottom n symbols and all strategies as an aligned, HTML-escaped
+// table split into pages of at most limit bytes. A section continued on the next page repeats
+// its title and column header.
+func breakdownPages(tr i18n.Localizer, f moneyFormat, b *domain.Breakdown, n, limit int) []string {
+	sections := []struct {
+		title string
+		rows  []domain.PnLGroup
+	}{
+		{tr.N("breakdown.top", n), b.TopSymbols(n)},
+		{tr.N("breakdown.bottom", n), b.BottomSymbols(n)},
+		{tr.T("breakdown.strategies"), b.Strategies},
+	}
+
+	pnlTitle := "PNL"
+	if b.Currency != "" {
+		pnlTitle += " " + b.Currency
+	}
+	nameW, pnlW := len("NAME"), len([]rune(pnlTitle))
+	for _, s := range sections {
+		for _, g := range s.rows {
+			nameW = max(nameW, len([]rune(g.Name)))
+			pnlW = max(pnlW, len([]rune(f.Signed(g.PnL, ""))))
+		}
+	}
+	header := fmt.Sprintf("%-*s %*s %6s %6s", nameW, "NAME", pnlW, pnlTitle, "TRADES", "WIN%")
+
+	var (
+		pages []string
+		cur   strings.Builder
+	)
+	flush := func() {
+		pages = append(pages, strings.TrimRight(cur.String(), "\n"))
+		cur.Reset()
+	}
+	for _, s := range sections {
+		if len(s.rows) == 0 {
+			continue
+		}
+		intro := html.EscapeString(s.title) + "\n" + header + "\n"
+		if cur.Len() > 0 {
+			intro = "\n" + intro
+		}
+		first := true
+		for _, g := range s.rows {
+			line := html.EscapeString(fmt.Sprintf("%-*s %*s %6d %5.1f%%", nameW, g.Name, pnlW, f.Signed(g.PnL, ""), g.Trades, g.WinRate())) + "\n"
+			if first {
+				line = intro + line
+			}
+			if cur.Len() > 0 && cur.Len()+len(line) > limit {
+				flush()
+				if !first {
+					line = html.EscapeString(tr.T("breakdown.continued", "title", s.title)) + "\n" + header + "\n" + line
+				} else {
+					line = strings.TrimPrefix(line, "\n")
+				}
+			}
+			cur.WriteString(line)
+			first = false
+		}
+	}
+	if cur.Len() > 0 || len(pages) == 0 {
+		flush()
+	}
+	return pages
+}
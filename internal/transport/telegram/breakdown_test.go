@@ -0,0 +1,85 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakdownPages(t *testing.T) {
+	b := &domain.Breakdown{
+		Symbols: []domain.PnLGroup{
+			{Name: "BTCUSDT", PnL: 120.5, Trades: 4, Wins: 3},
+			{Name: "ETHUSDT", PnL: 10, Trades: 2, Wins: 1},
+			{Name: "XRPUSDT", PnL: -7.25, Trades: 3, Wins: 0},
+		},
+		Strategies: []domain.PnLGroup{{Name: "a<b", PnL: 3, Trades: 1, Wins: 1}},
+	}
+
+	t.Run("single page", func(t *testing.T) {
+		pages := breakdownPages(enTr, enMoney, b, 2, maxMessageLen)
+		require.Equal(t, []string{"Top 2 symbols\n" +
+			"NAME        PNL TRADES   WIN%\n" +
+			"BTCUSDT +120.50      4  75.0%\n" +
+			"ETHUSDT  +10.00      2  50.0%\n" +
+			"\n" +
+			"Bottom 2 symbols\n" +
+			"NAME        PNL TRADES   WIN%\n" +
+			"XRPUSDT   -7.25      3   0.0%\n" +
+			"\n" +
+			"Strategies\n" +
+			"NAME        PNL TRADES   WIN%\n" +
+			"a&lt;b       +3.00      1 100.0%"}, pages)
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		many := &domain.Breakdown{}
+		for i := range 30 {
+			many.Strategies = append(many.Strategies, domain.PnLGroup{Name: fmt.Sprintf("s%02d", i), PnL: float64(30 - i)})
+		}
+		pages := breakdownPages(enTr, enMoney, many, 5, 300)
+		require.Greater(t, len(pages), 1)
+		rows := 0
+		for i, p := range pages {
+			require.LessOrEqual(t, len(p), 300)
+			if i > 0 {
+				require.True(t, strings.HasPrefix(p, "Strategies (cont.)\nNAME"), p)
+			}
+			rows += strings.Count(p, "\ns")
+		}
+		require.Equal(t, 30, rows)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, []string{""}, breakdownPages(enTr, enMoney, &domain.Breakdown{}, 5, maxMessageLen))
+	})
+}
+
+func TestHandler_breakdownCallback(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	src := &stubReports{}
+	for i := range 200 {
+		src.breakdown.Strategies = append(src.breakdown.Strategies, ports.PnLGroupResult{Name: fmt.Sprintf("strategy-%03d", i), PnL: float64(i)})
+	}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, usecase.NewReportUsecase(src, src, src, stubRenderer{}))
+
+	h.handleCallback(context.Background(), callback(7, 7, "bd::2024-01-01:2024-01-31"))
+	msgs := fake.Calls("sendMessage")
+	require.Len(t, msgs, 1)
+	require.Equal(t, "HTML", msgs[0].params.Get("parse_mode"))
+	require.Contains(t, msgs[0].params.Get("text"), "(1/")
+	require.Contains(t, msgs[0].params.Get("reply_markup"), "bd::2024-01-01:2024-01-31:1")
+
+	h.handleCallback(context.Background(), callback(7, 7, "bd::2024-01-01:2024-01-31:1"))
+	edits := fake.Calls("editMessageText")
+	require.Len(t, edits, 1)
+	require.Contains(t, edits[0].params.Get("text"), "(2/")
+	require.Contains(t, edits[0].params.Get("reply_markup"), "bd::2024-01-01:2024-01-31:0")
+}
@@ -0,0 +1,273 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chatScope is a bit set of chat kinds a command is available in.
+type chatScope uint8
+
+const (
+	scopePrivate chatScope = 1 << iota
+	scopeGroup
+)
+
+// chatScopeOf returns the kind of chat.
+func chatScopeOf(chat *tgbotapi.Chat) chatScope {
+	if chat.IsPrivate() {
+		return scopePrivate
+	}
+	return scopeGroup
+}
+
+// role is the minimal access level required to run a command.
+type role int
+
+const (
+	roleUser role = iota
+	roleViewer
+	roleAdmin
+)
+
+// String returns the role name used in ACCOUNT_ROLES.
+func (r role) String() string {
+	switch r {
+	case roleAdmin:
+		return "admin"
+	case roleViewer:
+		return "viewer"
+	default:
+		return "user"
+	}
+}
+
+type commandFunc func(ctx context.Context, msg *tgbotapi.Message, args string)
+
+// command is a router entry; the same registry drives dispatch and setMyCommands. Its
+// description is the catalog message "cmd.<name>". Invocations of audited commands are
+// recorded in the audit log, including those refused for lack of rights.
+type command struct {
+	name  string
+	scope chatScope
+	role  role
+	run   commandFunc
+	audit bool
+}
+
+func (c command) description(tr i18n.Localizer) string {
+	return tr.T("cmd." + c.name)
+}
+
+func (h *Handler) registerCommands() {
+	h.commands = []command{
+		{
+			name:  "start",
+			scope: scopePrivate,
+			role:  roleViewer,
+			run:   h.cmdStart,
+		},
+		{
+			name:  "export",
+			scope: scopePrivate,
+			role:  roleViewer,
+			run:   h.cmdExport,
+		},
+		{
+			name:  "settings",
+			scope: scopePrivate,
+			role:  roleViewer,
+			run:   h.cmdSettings,
+		},
+		{
+			name:  "signals",
+			scope: scopePrivate,
+			role:  roleViewer,
+			run:   h.cmdSignals,
+		},
+		{
+			name:  "template",
+			scope: scopePrivate,
+			role:  roleAdmin,
+			run:   h.cmdTemplate,
+		},
+	}
+	if h.portfolioUC != nil {
+		h.commands = append(h.commands,
+			command{
+				name:  "positions",
+				scope: scopePrivate,
+				role:  roleViewer,
+				run:   h.cmdPositions,
+			},
+			command{
+				name:  "balance",
+				scope: scopePrivate,
+				role:  roleViewer,
+				run:   h.cmdBalance,
+			},
+		)
+	}
+	if h.alertUC != nil {
+		h.commands = append(h.commands, command{
+			name:  "alert",
+			scope: scopePrivate,
+			role:  roleViewer,
+			run:   h.cmdAlert,
+		})
+	}
+	if h.controlEnabled() {
+		h.commands = append(h.commands,
+			command{
+				name:  "pause",
+				scope: scopePrivate,
+				role:  roleAdmin,
+				run:   h.cmdPause,
+				audit: true,
+			},
+			command{
+				name:  "resume",
+				scope: scopePrivate,
+				role:  roleAdmin,
+				run:   h.cmdResume,
+				audit: true,
+			},
+			command{
+				name:  "status",
+				scope: scopePrivate,
+				role:  roleAdmin,
+				run:   h.cmdStatus,
+				audit: true,
+			},
+		)
+	}
+	h.commands = append(h.commands, command{
+		name:  "help",
+		scope: scopePrivate | scopeGroup,
+		role:  roleUser,
+		run:   h.cmdHelp,
+	})
+}
+
+func (h *Handler) findCommand(name string) (command, bool) {
+	for _, c := range h.commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func (h *Handler) roleOf(userID int64) role {
+	if h.isAdmin(userID) {
+		return roleAdmin
+	}
+	if slices.Contains(h.config().ViewerIDs, userID) {
+		return roleViewer
+	}
+	return roleUser
+}
+
+// commandsFor returns the commands visible in scope to a user with role r.
+func (h *Handler) commandsFor(scope chatScope, r role) []command {
+	var out []command
+	for _, c := range h.commands {
+		if c.scope&scope != 0 && c.role <= r {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func botCommands(cmds []command, tr i18n.Localizer) []tgbotapi.BotCommand {
+	out := make([]tgbotapi.BotCommand, 0, len(cmds))
+	for _, c := range cmds {
+		out = append(out, tgbotapi.BotCommand{Command: c.name, Description: c.description(tr)})
+	}
+	return out
+}
+
+// SyncCommands publishes the command registry via setMyCommands: public commands for all
+// private and group chats, and each admin's and viewer's role list scoped to their private chat.
+// Users removed since the previous sync get their chat-scoped list deleted.
+func (h *Handler) SyncCommands() error {
+	cfg := h.config()
+	privileged := map[int64]role{}
+	for _, id := range cfg.ViewerIDs {
+		privileged[id] = roleViewer
+	}
+	for _, id := range cfg.UserIDs {
+		privileged[id] = roleAdmin
+	}
+	users := slices.Sorted(maps.Keys(privileged))
+
+	for _, lang := range h.catalog.Locales() {
+		code := lang
+		if lang == i18n.DefaultLocale {
+			code = ""
+		}
+
+		if err := h.setCommands(tgbotapi.NewBotCommandScopeAllPrivateChats(), code, h.commandsFor(scopePrivate, roleUser), lang); err != nil {
+			return err
+		}
+		if err := h.setCommands(tgbotapi.NewBotCommandScopeAllGroupChats(), code, h.commandsFor(scopeGroup, roleUser), lang); err != nil {
+			return err
+		}
+		for _, id := range users {
+			if err := h.setCommands(tgbotapi.NewBotCommandScopeChat(id), code, h.commandsFor(scopePrivate, privileged[id]), lang); err != nil {
+				return err
+			}
+		}
+	}
+
+	h.syncedMu.Lock()
+	defer h.syncedMu.Unlock()
+	for _, id := range h.syncedUsers {
+		if _, ok := privileged[id]; ok {
+			continue
+		}
+		for _, lang := range h.catalog.Locales() {
+			code := lang
+			if lang == i18n.DefaultLocale {
+				code = ""
+			}
+			cfg := tgbotapi.NewDeleteMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeChat(id), code)
+			if _, err := h.bot.Request(cfg); err != nil {
+				return fmt.Errorf("telegram delete commands for %d: %w", id, err)
+			}
+		}
+	}
+	h.syncedUsers = users
+	return nil
+}
+
+func (h *Handler) setCommands(scope tgbotapi.BotCommandScope, code string, cmds []command, lang string) error {
+	cfg := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, code, botCommands(cmds, h.catalog.Localizer(lang))...)
+	if _, err := h.bot.Request(cfg); err != nil {
+		return fmt.Errorf("telegram set commands (%s, %q): %w", scope.Type, code, err)
+	}
+	return nil
+}
+
+func (h *Handler) cmdStart(_ context.Context, msg *tgbotapi.Message, _ string) {
+	st := h.getState(msg.From.ID)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.Step = 0
+	h.sendMenuBestEffort(msg.Chat.ID, h.tr(msg.From))
+}
+
+func (h *Handler) cmdHelp(_ context.Context, msg *tgbotapi.Message, _ string) {
+	tr := h.tr(msg.From)
+	var b strings.Builder
+	for _, c := range h.commandsFor(chatScopeOf(msg.Chat), h.roleOf(msg.From.ID)) {
+		fmt.Fprintf(&b, "/%s — %s\n", c.name, c.description(tr))
+	}
+	h.replyBestEffort(msg.Chat.ID, strings.TrimSpace(b.String()))
+}
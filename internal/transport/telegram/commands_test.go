@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_commandsFor(t *testing.T) {
+	h := NewHandler(nil, &config.Config{}, nil)
+
+	names := func(cmds []command) []string {
+		out := make([]string, 0, len(cmds))
+		for _, c := range cmds {
+			out = append(out, c.name)
+		}
+		return out
+	}
+
+	require.Equal(t, []string{"help"}, names(h.commandsFor(scopePrivate, roleUser)))
+	require.Equal(t, []string{"help"}, names(h.commandsFor(scopeGroup, roleAdmin)))
+	require.Contains(t, names(h.commandsFor(scopePrivate, roleAdmin)), "start")
+}
+
+func TestCommand_description(t *testing.T) {
+	h := NewHandler(nil, &config.Config{}, nil)
+	c, ok := h.findCommand("start")
+	require.True(t, ok)
+	require.Equal(t, "Открыть главное меню", c.description(h.catalog.Localizer("ru")))
+	require.Equal(t, "Open the main menu", c.description(h.catalog.Localizer("de")))
+	require.Equal(t, "Open the main menu", c.description(h.catalog.Localizer("")))
+
+	for _, c := range h.commands {
+		for _, lang := range h.catalog.Locales() {
+			require.NotEqual(t, "cmd."+c.name, c.description(h.catalog.Localizer(lang)), "%s in %s", c.name, lang)
+		}
+	}
+}
+
+func TestHandler_SyncCommands(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{10, 20}}, nil)
+
+	require.NoError(t, h.SyncCommands())
+
+	set := fake.Calls("setMyCommands")
+	// (all private + all groups + 2 admins) per language
+	require.Len(t, set, 4*len(h.catalog.Locales()))
+
+	var scope tgbotapi.BotCommandScope
+	require.NoError(t, json.Unmarshal([]byte(set[2].params.Get("scope")), &scope))
+	require.Equal(t, "chat", scope.Type)
+	require.Equal(t, int64(10), scope.ChatID)
+	require.Empty(t, set[0].params.Get("language_code"))
+	require.Equal(t, "ru", set[4].params.Get("language_code"))
+
+	h.UpdateConfig(&config.Config{UserIDs: []int64{10}})
+	require.NoError(t, h.SyncCommands())
+
+	del := fake.Calls("deleteMyCommands")
+	require.Len(t, del, len(h.catalog.Locales()))
+	require.NoError(t, json.Unmarshal([]byte(del[0].params.Get("scope")), &scope))
+	require.Equal(t, int64(20), scope.ChatID)
+}
+
+func TestHandler_handleMessage_scope(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	src := &stubPortfolio{balances: map[string][]ports.BalanceResult{"main": {{Asset: "USDT", Total: 1}}}}
+	cfg := &config.Config{UserIDs: []int64{7}, Accounts: []domain.Account{{ID: "main", Quote: "USDT"}}}
+	h := NewHandler(bot, cfg, nil, WithPortfolio(usecase.NewPortfolioUsecase(src)))
+	inGroup := func(text string) *tgbotapi.Message {
+		msg := commandMessage(7, text)
+		msg.Chat = &tgbotapi.Chat{ID: -100, Type: "supergroup"}
+		return msg
+	}
+
+	h.handleMessage(context.Background(), inGroup("/balance"))
+	sent := fake.Calls("sendMessage")
+	require.Len(t, sent, 1)
+	require.Equal(t, "-100", sent[0].params.Get("chat_id"))
+	require.Equal(t, "Use this command in a private chat with the bot", sent[0].params.Get("text"))
+
+	h.handleMessage(context.Background(), inGroup("/help"))
+	sent = fake.Calls("sendMessage")
+	require.Len(t, sent, 2)
+	require.Contains(t, sent[1].params.Get("text"), "/help")
+
+	h.handleMessage(context.Background(), commandMessage(7, "/balance"))
+	require.Contains(t, fake.Calls("sendMessage")[2].params.Get("text"), "USDT")
+}
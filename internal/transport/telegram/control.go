@@ -0,0 +1,289 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// controlConfirmTTL is how long a control request waits for its confirmation.
+	controlConfirmTTL = 60 * time.Second
+	// defaultControlTimeout bounds waiting for the core's acknowledgment; see WithControl.
+	defaultControlTimeout = 10 * time.Second
+)
+
+// Control callback steps: "ctl:<step>:<nonce>".
+const (
+	controlConfirm = "ok"
+	controlCancel  = "no"
+)
+
+// strategyPattern keeps strategy names to plain identifiers.
+var strategyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// pendingControl is a control request waiting for its requester to confirm it.
+type pendingControl struct {
+	req     domain.ControlRequest
+	command string // as typed, for the audit log
+	expires time.Time
+}
+
+// controlState holds control requests awaiting confirmation, keyed by their single-use nonce.
+type controlState struct {
+	mu      sync.Mutex
+	pending map[string]pendingControl
+}
+
+// put stores p under a new nonce and returns it. Requests whose confirmation expired are
+// dropped and returned so they can be audited.
+func (s *controlState) put(p pendingControl, now time.Time) (nonce string, expired []pendingControl) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		s.pending = map[string]pendingControl{}
+	}
+	for n, old := range s.pending {
+		if now.After(old.expires) {
+			expired = append(expired, old)
+			delete(s.pending, n)
+		}
+	}
+	nonce = newNonce()
+	s.pending[nonce] = p
+	return nonce, expired
+}
+
+// take removes and returns the request of nonce.
+func (s *controlState) take(nonce string) (pendingControl, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pending[nonce]
+	delete(s.pending, nonce)
+	return p, ok
+}
+
+// restore puts back the request of nonce after a press that did not use it up.
+func (s *controlState) restore(nonce string, p pendingControl) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[nonce] = p
+}
+
+func (h *Handler) controlEnabled() bool {
+	return h.control != nil
+}
+
+// cmdPause handles /pause [strategy|flatten].
+func (h *Handler) cmdPause(ctx context.Context, msg *tgbotapi.Message, args string) {
+	req := domain.ControlRequest{Action: domain.ControlPause}
+	switch {
+	case args == "":
+	case strings.EqualFold(args, "flatten"):
+		req.Action = domain.ControlFlatten
+	case strategyPattern.MatchString(args):
+		req.Strategy = args
+	default:
+		h.replyBestEffort(msg.Chat.ID, h.tr(msg.From).T("control.usage.pause"))
+		return
+	}
+	h.requestControl(ctx, msg, req)
+}
+
+// cmdResume handles /resume [strategy].
+func (h *Handler) cmdResume(ctx context.Context, msg *tgbotapi.Message, args string) {
+	req := domain.ControlRequest{Action: domain.ControlResume}
+	if args != "" {
+		if !strategyPattern.MatchString(args) {
+			h.replyBestEffort(msg.Chat.ID, h.tr(msg.From).T("control.usage.resume"))
+			return
+		}
+		req.Strategy = args
+	}
+	h.requestControl(ctx, msg, req)
+}
+
+// cmdStatus handles /status. It changes nothing, so it is sent without confirmation.
+func (h *Handler) cmdStatus(ctx context.Context, msg *tgbotapi.Message, _ string) {
+	h.requestControl(ctx, msg, domain.ControlRequest{Action: domain.ControlStatus})
+}
+
+// requestControl asks the sender of msg to confirm req within controlConfirmTTL; status
+// requests are sent right away. A request whose message cannot be shown is audited as failed.
+func (h *Handler) requestControl(ctx context.Context, msg *tgbotapi.Message, req domain.ControlRequest) {
+	tr := h.tr(msg.From)
+	req.ID = newCommandID()
+	req.UserID = msg.From.ID
+	req.User = userLabel(msg.From)
+	p := pendingControl{req: req, command: commandText(msg)}
+
+	if req.Action == domain.ControlStatus {
+		sent, err := h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, tr.T("control.sending", "action", controlActionText(tr, req))))
+		if err != nil {
+			h.audit(req, p.command, domain.AuditFailed, "send status message: "+err.Error())
+			return
+		}
+		h.sendControl(ctx, tr, p, msg.Chat.ID, sent.MessageID)
+		return
+	}
+
+	now := time.Now()
+	p.expires = now.Add(controlConfirmTTL)
+	nonce, expired := h.controls.put(p, now)
+	for _, old := range expired {
+		h.audit(old.req, old.command, domain.AuditExpired, "")
+	}
+	h.audit(req, p.command, domain.AuditRequested, "")
+
+	out := tgbotapi.NewMessage(msg.Chat.ID, tr.T("control.prompt", "action", controlActionText(tr, req), "seconds", int(controlConfirmTTL.Seconds())))
+	out.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("control.confirm"), "ctl:"+controlConfirm+":"+nonce),
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("control.cancel"), "ctl:"+controlCancel+":"+nonce),
+	))
+	if _, err := h.bot.Send(out); err != nil {
+		h.controls.take(nonce)
+		h.audit(req, p.command, domain.AuditFailed, "send confirmation prompt: "+err.Error())
+		return
+	}
+}
+
+// handleControlCallback confirms or cancels a pending control request. Nonces are single-use
+// and only the admin who sent the command may press its buttons.
+func (h *Handler) handleControlCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	tr := h.tr(q.From)
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) != 3 || !h.controlEnabled() {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	step, nonce := parts[1], parts[2]
+	chatID, messageID := q.Message.Chat.ID, q.Message.MessageID
+
+	p, ok := h.controls.take(nonce)
+	if !ok {
+		h.answerCallbackBestEffort(q, tr.T("control.expired"))
+		h.editTextBestEffort(chatID, messageID, tr.T("control.expired"))
+		return
+	}
+	if q.From.ID != p.req.UserID || h.roleOf(q.From.ID) < roleAdmin {
+		h.controls.restore(nonce, p)
+		h.audit(domain.ControlRequest{ID: p.req.ID, UserID: q.From.ID, User: userLabel(q.From)}, p.command, domain.AuditDenied, "confirmation by another user")
+		h.answerCallbackBestEffort(q, tr.T("control.not_yours"))
+		return
+	}
+	if time.Now().After(p.expires) {
+		h.audit(p.req, p.command, domain.AuditExpired, "")
+		h.answerCallbackBestEffort(q, tr.T("control.expired"))
+		h.editTextBestEffort(chatID, messageID, tr.T("control.expired"))
+		return
+	}
+
+	switch step {
+	case controlConfirm:
+		h.answerCallbackBestEffort(q, "")
+		h.editTextBestEffort(chatID, messageID, tr.T("control.sending", "action", controlActionText(tr, p.req)))
+		h.sendControl(ctx, tr, p, chatID, messageID)
+	case controlCancel:
+		h.audit(p.req, p.command, domain.AuditCancelled, "")
+		h.answerCallbackBestEffort(q, "")
+		h.editTextBestEffort(chatID, messageID, tr.T("control.cancelled", "action", controlActionText(tr, p.req)))
+	default:
+		h.controls.restore(nonce, p)
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+	}
+}
+
+// sendControl sends p to the core, waits for its acknowledgment and shows it in place of
+// message messageID.
+func (h *Handler) sendControl(ctx context.Context, tr i18n.Localizer, p pendingControl, chatID int64, messageID int) {
+	req := p.req
+	req.Time = time.Now().UTC()
+	ctx, cancel := context.WithTimeout(ctx, h.controlTimeout)
+	defer cancel()
+	reply, err := h.control.Control(ctx, req)
+	action := controlActionText(tr, req)
+	switch {
+	case err != nil:
+		h.audit(req, p.command, domain.AuditFailed, err.Error())
+		h.editTextBestEffort(chatID, messageID, tr.T("control.failed", "action", action, "err", err))
+	case !reply.OK:
+		h.audit(req, p.command, domain.AuditFailed, reply.Message)
+		h.editTextBestEffort(chatID, messageID, formatControlReply(tr, tr.T("control.refused", "action", action), reply))
+	default:
+		h.audit(req, p.command, domain.AuditOK, reply.Message)
+		h.editTextBestEffort(chatID, messageID, formatControlReply(tr, tr.T("control.ok", "action", action), reply))
+	}
+}
+
+// formatControlReply renders the core's acknowledgment under title: its message and trading state.
+func formatControlReply(tr i18n.Localizer, title string, r domain.ControlReply) string {
+	lines := []string{title}
+	if r.Message != "" {
+		lines = append(lines, r.Message)
+	}
+	lines = append(lines, "")
+	if r.Paused {
+		lines = append(lines, tr.T("control.paused"))
+	} else {
+		lines = append(lines, tr.T("control.running"))
+	}
+	if len(r.PausedStrategies) > 0 {
+		lines = append(lines, tr.T("control.paused_strategies", "strategies", strings.Join(r.PausedStrategies, ", ")))
+	}
+	lines = append(lines, tr.N("control.positions", r.OpenPositions))
+	return strings.Join(lines, "\n")
+}
+
+// controlActionText describes req, e.g. "Pause strategy scalper".
+func controlActionText(tr i18n.Localizer, req domain.ControlRequest) string {
+	switch {
+	case req.Action == domain.ControlFlatten || req.Action == domain.ControlStatus:
+		return tr.T("control.action." + string(req.Action))
+	case req.Strategy == "":
+		return tr.T("control.action." + string(req.Action) + "_all")
+	}
+	return tr.T("control.action."+string(req.Action), "strategy", req.Strategy)
+}
+
+// audit records an invocation of a control command; failures to record are not fatal to it.
+func (h *Handler) audit(req domain.ControlRequest, command string, outcome domain.AuditOutcome, detail string) {
+	if h.auditLog == nil {
+		return
+	}
+	e := domain.AuditEntry{Time: time.Now().UTC(), UserID: req.UserID, User: req.User, Command: command,
+		RequestID: req.ID, Outcome: outcome, Detail: detail}
+	if err := h.auditLog.Record(e); err != nil {
+		return
+	}
+}
+
+// auditDenied records a control command refused to msg's sender for lack of rights.
+func (h *Handler) auditDenied(msg *tgbotapi.Message) {
+	h.audit(domain.ControlRequest{UserID: msg.From.ID, User: userLabel(msg.From)}, commandText(msg), domain.AuditDenied, "")
+}
+
+func (h *Handler) editTextBestEffort(chatID int64, messageID int, text string) {
+	if _, err := h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, text)); err != nil {
+		return
+	}
+}
+
+// commandText returns the command of msg with its arguments, without the bot's username.
+func commandText(msg *tgbotapi.Message) string {
+	return strings.TrimSpace("/" + msg.Command() + " " + strings.TrimSpace(msg.CommandArguments()))
+}
+
+// newNonce returns a random 64-bit hex nonce.
+func newNonce() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:]) //nolint:errcheck // crypto/rand.Read never fails
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,176 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+type memControl struct {
+	reqs  []domain.ControlRequest
+	reply domain.ControlReply
+	err   error
+}
+
+func (m *memControl) Control(_ context.Context, req domain.ControlRequest) (domain.ControlReply, error) {
+	m.reqs = append(m.reqs, req)
+	if m.err != nil {
+		return domain.ControlReply{}, m.err
+	}
+	r := m.reply
+	r.RequestID = req.ID
+	return r, nil
+}
+
+type memAudit []domain.AuditEntry
+
+func (m *memAudit) Record(e domain.AuditEntry) error {
+	*m = append(*m, e)
+	return nil
+}
+
+func (m memAudit) outcomes() []string {
+	var out []string
+	for _, e := range m {
+		out = append(out, e.Command+" "+string(e.Outcome))
+	}
+	return out
+}
+
+func TestHandler_control(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	ctl := &memControl{reply: domain.ControlReply{OK: true, Message: "done", PausedStrategies: []string{"scalper"}, OpenPositions: 2}}
+	audit := &memAudit{}
+	cfg := &config.Config{UserIDs: []int64{7, 9}, ViewerIDs: []int64{8}}
+	h := NewHandler(bot, cfg, nil, WithControl(ctl, audit, time.Second))
+	ctx := context.Background()
+
+	lastText := func(method string) string {
+		calls := fake.Calls(method)
+		require.NotEmpty(t, calls)
+		return calls[len(calls)-1].params.Get("text")
+	}
+	// prompt sends cmd as admin 7 and returns the nonce of its confirmation buttons.
+	prompt := func(cmd string) string {
+		h.handleMessage(ctx, commandMessage(7, cmd))
+		sent := fake.Calls("sendMessage")
+		data := keyboardData(t, sent[len(sent)-1].params.Get("reply_markup"))
+		require.Len(t, data, 2)
+		nonce := strings.TrimPrefix(data[0], "ctl:ok:")
+		require.Equal(t, "ctl:no:"+nonce, data[1])
+		return nonce
+	}
+
+	// Viewers are refused, and the attempt is audited.
+	h.handleMessage(ctx, commandMessage(8, "/pause"))
+	require.Equal(t, "Access denied", lastText("sendMessage"))
+	require.Equal(t, []string{"/pause denied"}, audit.outcomes())
+
+	// Nothing is sent before the requester confirms; other admins cannot confirm.
+	nonce := prompt("/pause scalper")
+	require.Equal(t, "⚠️ Pause strategy scalper?\nConfirm within 60 s.", lastText("sendMessage"))
+	require.Empty(t, ctl.reqs)
+	h.handleCallback(ctx, signalCallback(9, 50, "ctl:ok:"+nonce))
+	require.Equal(t, "Only the admin who sent the command can confirm it", fake.Calls("answerCallbackQuery")[0].params.Get("text"))
+	require.Empty(t, ctl.reqs)
+
+	h.handleCallback(ctx, signalCallback(7, 50, "ctl:ok:"+nonce))
+	require.Len(t, ctl.reqs, 1)
+	req := ctl.reqs[0]
+	require.Equal(t, domain.ControlPause, req.Action)
+	require.Equal(t, "scalper", req.Strategy)
+	require.Equal(t, int64(7), req.UserID)
+	require.Len(t, req.ID, 32)
+	require.Equal(t, "✅ The core acknowledged: Pause strategy scalper\ndone\n\n▶️ Trading is running\nPaused strategies: scalper\n2 open positions",
+		lastText("editMessageText"))
+	require.Equal(t, "50", fake.Calls("editMessageText")[1].params.Get("message_id"))
+
+	// Nonces are single-use.
+	h.handleCallback(ctx, signalCallback(7, 50, "ctl:ok:"+nonce))
+	require.Len(t, ctl.reqs, 1)
+	require.Equal(t, "This confirmation has expired; send the command again", lastText("editMessageText"))
+
+	nonce = prompt("/pause FLATTEN")
+	require.Contains(t, lastText("sendMessage"), "EMERGENCY FLATTEN")
+	h.handleCallback(ctx, signalCallback(7, 51, "ctl:no:"+nonce))
+	require.Len(t, ctl.reqs, 1)
+	require.Equal(t, "Cancelled: EMERGENCY FLATTEN: pause all trading and close every open position", lastText("editMessageText"))
+
+	ctl.err = errors.New("control resume: context deadline exceeded")
+	nonce = prompt("/resume")
+	h.handleCallback(ctx, signalCallback(7, 52, "ctl:ok:"+nonce))
+	require.Equal(t, domain.ControlResume, ctl.reqs[1].Action)
+	require.Equal(t, "❌ No acknowledgment: Resume all trading\ncontrol resume: context deadline exceeded", lastText("editMessageText"))
+
+	// Confirmations expire.
+	nonce = prompt("/resume scalper")
+	h.controls.mu.Lock()
+	p := h.controls.pending[nonce]
+	p.expires = time.Now().Add(-time.Second)
+	h.controls.pending[nonce] = p
+	h.controls.mu.Unlock()
+	h.handleCallback(ctx, signalCallback(7, 53, "ctl:ok:"+nonce))
+	require.Len(t, ctl.reqs, 2)
+
+	// Status is read-only and sent right away.
+	ctl.err = nil
+	ctl.reply = domain.ControlReply{OK: false, Message: "core is starting", Paused: true, OpenPositions: 1}
+	h.handleMessage(ctx, commandMessage(7, "/status"))
+	require.Equal(t, domain.ControlStatus, ctl.reqs[2].Action)
+	require.Equal(t, "❌ The core refused: Status\ncore is starting\n\n⏸ Trading is paused\n1 open position", lastText("editMessageText"))
+
+	h.handleMessage(ctx, commandMessage(7, "/pause two words"))
+	require.Equal(t, "Usage: /pause [strategy|flatten]", lastText("sendMessage"))
+
+	require.Equal(t, []string{
+		"/pause denied",
+		"/pause scalper requested", "/pause scalper denied", "/pause scalper ok",
+		"/pause FLATTEN requested", "/pause FLATTEN cancelled",
+		"/resume requested", "/resume failed",
+		"/resume scalper requested", "/resume scalper expired",
+		"/status failed",
+	}, audit.outcomes())
+	require.Equal(t, int64(9), (*audit)[2].UserID, "the denied confirmation is attributed to who pressed")
+	require.Equal(t, "done", (*audit)[3].Detail)
+	require.Equal(t, req.ID, (*audit)[3].RequestID)
+}
+
+func TestHandler_control_sendFailure(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	ctl := &memControl{reply: domain.ControlReply{OK: true}}
+	audit := &memAudit{}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, nil, WithControl(ctl, audit, time.Second))
+	ctx := context.Background()
+
+	// Requests whose prompt or status message cannot be sent are closed as failed.
+	fake.failFrom = 1
+	h.handleMessage(ctx, commandMessage(7, "/pause"))
+	h.handleMessage(ctx, commandMessage(7, "/status"))
+	require.Empty(t, ctl.reqs)
+	require.Empty(t, h.controls.pending)
+	require.Equal(t, []string{"/pause requested", "/pause failed", "/status failed"}, audit.outcomes())
+	require.Contains(t, (*audit)[1].Detail, "send confirmation prompt")
+	require.Equal(t, (*audit)[0].RequestID, (*audit)[1].RequestID)
+	require.Contains(t, (*audit)[2].Detail, "send status message")
+}
+
+func TestHandler_controlCommands(t *testing.T) {
+	bot, _ := newFakeBot(t)
+	cfg := &config.Config{UserIDs: []int64{7}}
+	_, ok := NewHandler(bot, cfg, nil).findCommand("pause")
+	require.False(t, ok, "hidden without a controller")
+
+	h := NewHandler(bot, cfg, nil, WithControl(&memControl{}, nil, 0))
+	names := []string{}
+	for _, c := range h.commandsFor(scopePrivate, roleAdmin) {
+		names = append(names, c.name)
+	}
+	require.Subset(t, names, []string{"pause", "resume", "status"})
+	require.Equal(t, defaultControlTimeout, h.controlTimeout)
+}
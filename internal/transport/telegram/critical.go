@@ -0,0 +1,291 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// criticalTick is how often unacknowledged critical messages are checked for repeats and
+	// escalation.
+	criticalTick = 30 * time.Second
+	// criticalDedupeWindow is how long an acknowledged critical message is remembered, so a
+	// redelivery of it does not page the admins again.
+	criticalDedupeWindow = time.Hour
+)
+
+// criticalSend is one round of direct messages of a page.
+type criticalSend struct {
+	id    string
+	n     Notification
+	users []int64
+}
+
+// criticalState holds critical pages keyed by the ID in their "ack:<id>" buttons, in store or,
+// without one, in memory.
+type criticalState struct {
+	store ports.CriticalStore
+	mu    sync.Mutex
+	pages map[string]domain.CriticalPage
+}
+
+func (s *criticalState) list() ([]domain.CriticalPage, error) {
+	if s.store != nil {
+		return s.store.CriticalPages()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Collect(maps.Values(s.pages)), nil
+}
+
+func (s *criticalState) update(fn func(pages map[string]domain.CriticalPage)) error {
+	if s.store != nil {
+		return s.store.UpdateCriticalPages(fn)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pages == nil {
+		s.pages = map[string]domain.CriticalPage{}
+	}
+	fn(s.pages)
+	return nil
+}
+
+// raise stores n under a new ID, unless a page with key is pending or was acknowledged within
+// criticalDedupeWindow; raised is false then. Older acknowledged pages are dropped.
+func (s *criticalState) raise(key string, n Notification, now time.Time) (id string, raised bool, err error) {
+	err = s.update(func(pages map[string]domain.CriticalPage) {
+		maps.DeleteFunc(pages, func(_ string, p domain.CriticalPage) bool {
+			return p.AckedAt != nil && now.Sub(*p.AckedAt) >= criticalDedupeWindow
+		})
+		for _, p := range pages {
+			if p.Key == key {
+				return
+			}
+		}
+		id, raised = newNonce(), true
+		pages[id] = domain.CriticalPage{ID: id, Key: key, Text: n.Text, ParseMode: n.ParseMode, Raw: n.Raw, Raised: now, Paged: now}
+	})
+	return id, raised, err
+}
+
+// due returns the rounds to send at now. Pages not sent for repeat go again to admins, and to
+// escalation once escalated; pages unacknowledged for delay are escalated and go to escalation
+// right away. A non-positive repeat or delay disables repeats or escalation.
+func (s *criticalState) due(now time.Time, repeat, delay time.Duration, admins, escalation []int64) ([]criticalSend, error) {
+	pages, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(pages, func(p domain.CriticalPage) bool {
+		users, _ := criticalDue(p, now, repeat, delay, admins, escalation)
+		return len(users) > 0
+	}) {
+		return nil, nil
+	}
+	var out []criticalSend
+	err = s.update(func(pages map[string]domain.CriticalPage) {
+		for id, p := range pages {
+			users, escalate := criticalDue(p, now, repeat, delay, admins, escalation)
+			if len(users) == 0 {
+				continue
+			}
+			p.Paged, p.Escalated = now, p.Escalated || escalate
+			pages[id] = p
+			out = append(out, criticalSend{id: id, n: criticalNotification(p), users: users})
+		}
+	})
+	slices.SortFunc(out, func(a, b criticalSend) int { return strings.Compare(a.id, b.id) })
+	return out, err
+}
+
+// criticalDue returns who page p goes to at now and whether that escalates it; see due.
+func criticalDue(p domain.CriticalPage, now time.Time, repeat, delay time.Duration, admins, escalation []int64) (users []int64, escalate bool) {
+	if p.AckedAt != nil {
+		return nil, false
+	}
+	if repeat > 0 && now.Sub(p.Paged) >= repeat {
+		users = admins
+		if p.Escalated {
+			users = append(slices.Clone(admins), escalation...)
+		}
+	}
+	if !p.Escalated && delay > 0 && len(escalation) > 0 && now.Sub(p.Raised) >= delay {
+		escalate = true
+		users = append(slices.Clone(users), escalation...)
+	}
+	return dedupe(users), escalate
+}
+
+// record makes sent the latest copies of page id and returns the copies whose button should be
+// removed: those they replace, or sent itself when the page was acknowledged in the meantime.
+func (s *criticalState) record(id string, sent []domain.MessageRef) (stale []domain.MessageRef, err error) {
+	err = s.update(func(pages map[string]domain.CriticalPage) {
+		p, ok := pages[id]
+		if !ok || p.AckedAt != nil {
+			stale = sent
+			return
+		}
+		for _, m := range sent {
+			i := slices.IndexFunc(p.Messages, func(old domain.MessageRef) bool { return old.ChatID == m.ChatID })
+			if i < 0 {
+				p.Messages = append(p.Messages, m)
+				continue
+			}
+			stale = append(stale, p.Messages[i])
+			p.Messages[i] = m
+		}
+		pages[id] = p
+	})
+	return stale, err
+}
+
+// ack marks page id acknowledged at now and returns it; ok is false for unknown and already
+// acknowledged pages.
+func (s *criticalState) ack(id string, now time.Time) (page domain.CriticalPage, ok bool, err error) {
+	err = s.update(func(pages map[string]domain.CriticalPage) {
+		p, found := pages[id]
+		if !found || p.AckedAt != nil {
+			return
+		}
+		p.AckedAt = &now
+		pages[id] = p
+		page, ok = p, true
+	})
+	return page, ok, err
+}
+
+// criticalNotification returns the message of page p.
+func criticalNotification(p domain.CriticalPage) Notification {
+	return Notification{Text: p.Text, ParseMode: p.ParseMode, Raw: p.Raw}
+}
+
+// PageCritical sends n by DM to every admin with an Acknowledge button, regardless of quiet
+// hours. Until someone acknowledges it, it is sent again every CRITICAL_REPEAT minutes, and
+// after ESCALATION_DELAY minutes also to ESCALATION_USER_IDS. key identifies the queue message
+// n was rendered from: it is not paged again while its page is pending or recently
+// acknowledged. Failed DMs are returned joined.
+func (h *Handler) PageCritical(key string, n Notification) error {
+	n.Silent = false
+	id, raised, err := h.criticals.raise(key, n, time.Now())
+	if err != nil {
+		return fmt.Errorf("critical: %w", err)
+	}
+	if !raised {
+		return nil
+	}
+	return h.sendCritical(criticalSend{id: id, n: n, users: h.config().UserIDs})
+}
+
+// runCriticals repeats and escalates unacknowledged critical messages until ctx is canceled.
+func (h *Handler) runCriticals(ctx context.Context) {
+	t := time.NewTicker(criticalTick)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			h.repageCriticals(now)
+		}
+	}
+}
+
+// repageCriticals sends the rounds of unacknowledged critical messages due at now.
+func (h *Handler) repageCriticals(now time.Time) {
+	cfg := h.config()
+	repeat := time.Duration(cfg.CriticalRepeatMinutes) * time.Minute
+	delay := time.Duration(cfg.EscalationDelayMinutes) * time.Minute
+	sends, err := h.criticals.due(now, repeat, delay, cfg.UserIDs, cfg.EscalationUserIDs)
+	if err != nil {
+		h.logError("failed to load critical messages", "error", err)
+	}
+	for _, s := range sends {
+		_ = h.sendCritical(s) //nolint:errcheck // best effort: the next round sends it again
+	}
+}
+
+// sendCritical sends a round of page s. Each new copy replaces the previous one in its chat,
+// whose button is removed.
+func (h *Handler) sendCritical(s criticalSend) error {
+	var (
+		sent []domain.MessageRef
+		errs []error
+	)
+	for _, userID := range s.users {
+		tr := h.tr(&tgbotapi.User{ID: userID})
+		msg := notificationMessage(userID, s.n)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("critical.btn_ack"), "ack:"+s.id)))
+		messageID, err := h.sendNotification(msg, s.n)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("critical: dm %d: %w", userID, err))
+		}
+		if messageID != 0 {
+			sent = append(sent, domain.MessageRef{ChatID: userID, MessageID: messageID})
+		}
+	}
+	stale, err := h.criticals.record(s.id, sent)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("critical: %w", err))
+	}
+	for _, m := range stale {
+		h.editKeyboardBestEffort(m.ChatID, m.MessageID, nil)
+	}
+	return errors.Join(errs...)
+}
+
+// handleCriticalCallback acknowledges the critical message of an "ack:<id>" button: repeats
+// and escalation stop, and the latest copy in every chat shows who acknowledged it instead of
+// the button.
+func (h *Handler) handleCriticalCallback(q *tgbotapi.CallbackQuery, data string) {
+	tr := h.tr(q.From)
+	if !h.isAdmin(q.From.ID) && !slices.Contains(h.config().EscalationUserIDs, q.From.ID) {
+		h.answerCallbackBestEffort(q, tr.T("access_denied"))
+		return
+	}
+	p, ok, err := h.criticals.ack(strings.TrimPrefix(data, "ack:"), time.Now())
+	if err != nil {
+		h.answerCallbackBestEffort(q, tr.T("error", "err", err))
+		return
+	}
+	if !ok {
+		h.answerCallbackBestEffort(q, tr.T("critical.already_acked"))
+		return
+	}
+	by := userLabel(q.From)
+	for _, m := range p.Messages {
+		kb := ackedKeyboard(h.tr(&tgbotapi.User{ID: m.ChatID}), by)
+		h.editKeyboardBestEffort(m.ChatID, m.MessageID, &kb)
+	}
+	h.answerCallbackBestEffort(q, tr.T("critical.acked"))
+}
+
+// ackedKeyboard replaces the Acknowledge button with one naming who pressed it; pressing it
+// again only answers that the message is already acknowledged.
+func ackedKeyboard(tr i18n.Localizer, by string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("critical.acked_by", "user", by), "ack:-")))
+}
+
+// dedupe drops repeated IDs, keeping the first occurrence.
+func dedupe(ids []int64) []int64 {
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+// memCriticals is an in-memory ports.CriticalStore that outlives the handlers using it.
+type memCriticals map[string]domain.CriticalPage
+
+func (m memCriticals) CriticalPages() ([]domain.CriticalPage, error) {
+	return slices.Collect(maps.Values(m)), nil
+}
+
+func (m memCriticals) UpdateCriticalPages(fn func(pages map[string]domain.CriticalPage)) error {
+	fn(m)
+	return nil
+}
+
+func TestCriticalState_due(t *testing.T) {
+	var s criticalState
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	id, raised, err := s.raise("k1", Notification{Text: "disk full", Silent: true}, t0)
+	require.NoError(t, err)
+	require.True(t, raised)
+	admins, escalation := []int64{1, 2}, []int64{2, 3}
+	due := func(d time.Duration) []criticalSend {
+		sends, err := s.due(t0.Add(d), 5*time.Minute, 15*time.Minute, admins, escalation)
+		require.NoError(t, err)
+		return sends
+	}
+
+	require.Empty(t, due(4*time.Minute))
+	require.Equal(t, []criticalSend{{id: id, n: Notification{Text: "disk full"}, users: []int64{1, 2}}}, due(5*time.Minute))
+	require.Empty(t, due(9*time.Minute))
+	require.Equal(t, []int64{1, 2}, due(12 * time.Minute)[0].users)
+	// Escalation sends to the secondary list at once, without waiting for the next repeat.
+	require.Equal(t, []int64{2, 3}, due(15 * time.Minute)[0].users)
+	require.Empty(t, due(17*time.Minute))
+	require.Equal(t, []int64{1, 2, 3}, due(20 * time.Minute)[0].users)
+
+	// Only the latest copy per chat is kept; the ones it replaces lose their button.
+	stale, err := s.record(id, []domain.MessageRef{{ChatID: 1, MessageID: 10}, {ChatID: 2, MessageID: 11}})
+	require.NoError(t, err)
+	require.Empty(t, stale)
+	stale, err = s.record(id, []domain.MessageRef{{ChatID: 2, MessageID: 20}, {ChatID: 3, MessageID: 21}})
+	require.NoError(t, err)
+	require.Equal(t, []domain.MessageRef{{ChatID: 2, MessageID: 11}}, stale)
+
+	acked := t0.Add(21 * time.Minute)
+	p, ok, err := s.ack(id, acked)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []domain.MessageRef{{ChatID: 1, MessageID: 10}, {ChatID: 2, MessageID: 20}, {ChatID: 3, MessageID: 21}}, p.Messages)
+	require.Empty(t, due(time.Hour))
+	_, ok, err = s.ack(id, acked)
+	require.NoError(t, err)
+	require.False(t, ok)
+	// Copies sent while the page was being acknowledged lose their button too.
+	late := []domain.MessageRef{{ChatID: 1, MessageID: 30}}
+	stale, err = s.record(id, late)
+	require.NoError(t, err)
+	require.Equal(t, late, stale)
+
+	// A redelivery is not paged again until the acknowledgment is forgotten.
+	_, raised, err = s.raise("k1", Notification{Text: "disk full"}, acked.Add(criticalDedupeWindow-time.Second))
+	require.NoError(t, err)
+	require.False(t, raised)
+	_, raised, err = s.raise("k1", Notification{Text: "disk full"}, acked.Add(criticalDedupeWindow))
+	require.NoError(t, err)
+	require.True(t, raised)
+
+	// Without repeats or a secondary list nothing is sent again.
+	sends, err := s.due(t0.Add(2*time.Hour), 0, 15*time.Minute, admins, nil)
+	require.NoError(t, err)
+	require.Empty(t, sends)
+}
+
+func TestHandler_pageCritical(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	cfg := &config.Config{
+		UserIDs:                []int64{7, 8},
+		ViewerIDs:              []int64{10},
+		EscalationUserIDs:      []int64{9},
+		CriticalRepeatMinutes:  5,
+		EscalationDelayMinutes: 15,
+	}
+	h := NewHandler(bot, cfg, nil)
+	ctx := context.Background()
+
+	require.NoError(t, h.SendNotification(-100, Notification{Text: "backup done", Silent: true}))
+	require.Equal(t, "true", fake.Calls("sendMessage")[0].params.Get("disable_notification"))
+
+	start := time.Now()
+	require.NoError(t, h.PageCritical("k1", Notification{Text: "core down", Silent: true}))
+	require.NoError(t, h.PageCritical("k1", Notification{Text: "core down"}), "a redelivery is not paged again")
+	sent := fake.Calls("sendMessage")[1:]
+	require.Len(t, sent, 2)
+	var ack string
+	for i, m := range sent {
+		require.Equal(t, []string{"7", "8"}[i], m.params.Get("chat_id"))
+		require.Equal(t, "core down", m.params.Get("text"))
+		require.Empty(t, m.params.Get("disable_notification"), "critical messages ignore quiet hours")
+		data := keyboardData(t, m.params.Get("reply_markup"))
+		require.Len(t, data, 1)
+		ack = data[0]
+	}
+
+	h.repageCriticals(start.Add(4 * time.Minute))
+	require.Len(t, fake.Calls("sendMessage"), 3)
+	h.repageCriticals(start.Add(6 * time.Minute))
+	require.Len(t, fake.Calls("sendMessage"), 5)
+	h.repageCriticals(start.Add(16 * time.Minute))
+	sent = fake.Calls("sendMessage")
+	require.Len(t, sent, 8)
+	require.Equal(t, "9", sent[7].params.Get("chat_id"))
+	// Each repeat removes the button from the copy it replaces.
+	edits := fake.Calls("editMessageReplyMarkup")
+	require.Len(t, edits, 4)
+	for i, e := range edits {
+		require.Equal(t, []string{"7", "8", "7", "8"}[i], e.params.Get("chat_id"))
+		require.Empty(t, e.params.Get("reply_markup"))
+	}
+
+	// Viewers cannot acknowledge; anyone paged can, and every copy then names them.
+	h.handleCallback(ctx, signalCallback(10, 3, ack))
+	require.Equal(t, "Access denied", fake.Calls("answerCallbackQuery")[0].params.Get("text"))
+	h.handleCallback(ctx, signalCallback(9, 8, ack))
+	require.Equal(t, "Acknowledged", fake.Calls("answerCallbackQuery")[1].params.Get("text"))
+	edits = fake.Calls("editMessageReplyMarkup")[4:]
+	require.Len(t, edits, 3, "only the latest copy per chat is edited")
+	for _, e := range edits {
+		require.Equal(t, []string{"ack:-"}, keyboardData(t, e.params.Get("reply_markup")))
+		require.Contains(t, e.params.Get("reply_markup"), "Acknowledged by @bob")
+	}
+
+	h.repageCriticals(start.Add(time.Hour))
+	require.Len(t, fake.Calls("sendMessage"), 8)
+	h.handleCallback(ctx, signalCallback(7, 2, "ack:-"))
+	require.Equal(t, "Already acknowledged", fake.Calls("answerCallbackQuery")[2].params.Get("text"))
+	require.NoError(t, h.PageCritical("k1", Notification{Text: "core down"}))
+	require.Len(t, fake.Calls("sendMessage"), 8, "a recently acknowledged message is not paged again")
+}
+
+func TestHandler_pageCritical_restart(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	cfg := &config.Config{UserIDs: []int64{7}, CriticalRepeatMinutes: 5}
+	store := memCriticals{}
+	start := time.Now()
+	require.NoError(t, NewHandler(bot, cfg, nil, WithCriticals(store)).PageCritical("k1", Notification{Text: "core down"}))
+	ack := keyboardData(t, fake.Calls("sendMessage")[0].params.Get("reply_markup"))[0]
+
+	// A restarted handler keeps repeating the page and accepts its button.
+	h := NewHandler(bot, cfg, nil, WithCriticals(store))
+	require.NoError(t, h.PageCritical("k1", Notification{Text: "core down"}))
+	require.Len(t, fake.Calls("sendMessage"), 1)
+	h.repageCriticals(start.Add(6 * time.Minute))
+	require.Len(t, fake.Calls("sendMessage"), 2)
+	h.handleCallback(context.Background(), signalCallback(7, 3, ack))
+	require.Equal(t, "Acknowledged", fake.Calls("answerCallbackQuery")[0].params.Get("text"))
+	require.Len(t, fake.Calls("editMessageReplyMarkup"), 2)
+}
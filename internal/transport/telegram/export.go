@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxDocumentBytes is the Bot API upload limit for documents.
+const MaxDocumentBytes = 50 << 20
+
+var exportFormats = []string{"csv", "xlsx"}
+
+// SendDocument uploads the file at path to chatID under its base name.
+func (h *Handler) SendDocument(chatID int64, path, caption string) error {
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FilePath(path))
+	doc.Caption = caption
+	if _, err := h.bot.Send(doc); err != nil {
+		return fmt.Errorf("telegram send document: %w", err)
+	}
+	return nil
+}
+
+func (h *Handler) cmdExport(ctx context.Context, msg *tgbotapi.Message, args string) {
+	chatID := msg.Chat.ID
+	tr := h.tr(msg.From)
+	if h.exportUC == nil {
+		h.replyBestEffort(chatID, tr.T("export.unavailable"))
+		return
+	}
+	fields := strings.Fields(args)
+	if len(fields) < 2 || len(fields) > 5 {
+		h.replyBestEffort(chatID, tr.T("export.usage"))
+		return
+	}
+	accts := h.accountsFor(msg.From.ID)
+	if len(accts) == 0 {
+		h.replyBestEffort(chatID, tr.T("no_accounts"))
+		return
+	}
+	acct := accts[0]
+	if id, ok := h.defaultAccount(msg.From.ID); ok && id != domain.AllAccounts {
+		acct, _ = h.accountFor(msg.From.ID, id)
+	}
+	if len(fields) == 5 {
+		var ok bool
+		if acct, ok = h.accountFor(msg.From.ID, fields[4]); !ok {
+			h.replyBestEffort(chatID, tr.T("unknown_account", "account", fields[4]))
+			return
+		}
+	}
+	from, to := fields[0], fields[1]
+	if len(fields) == 2 {
+		h.sendExportPickerBestEffort(chatID, tr, acct, from, to)
+		return
+	}
+	kind := usecase.ExportKind(fields[2])
+	format := "csv"
+	if len(fields) >= 4 {
+		format = fields[3]
+	}
+	h.runExport(ctx, chatID, tr, acct, kind, format, h.period(msg.From.ID, from, to))
+}
+
+func (h *Handler) handleExportCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	chatID := q.Message.Chat.ID
+	tr := h.tr(q.From)
+	parts := strings.Split(data, ":")
+	if h.exportUC == nil {
+		h.answerCallbackBestEffort(q, tr.T("export.unavailable"))
+		return
+	}
+
+	switch {
+	case parts[0] == "export" && len(parts) == 4:
+		acct, from, to, ok := h.reportArgs(q.From.ID, parts[1:])
+		if !ok {
+			h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+			return
+		}
+		h.answerCallbackBestEffort(q, "")
+		h.sendExportPickerBestEffort(chatID, tr, acct, from, to)
+	case parts[0] == "exp" && len(parts) == 6:
+		acct, from, to, ok := h.reportArgs(q.From.ID, parts[3:])
+		if !ok {
+			h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+			return
+		}
+		h.answerCallbackBestEffort(q, tr.T("export.preparing"))
+		h.runExport(ctx, chatID, tr, acct, usecase.ExportKind(parts[1]), parts[2], h.period(q.From.ID, from, to))
+	default:
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+	}
+}
+
+func (h *Handler) sendExportPickerBestEffort(chatID int64, tr i18n.Localizer, acct domain.Account, from, to string) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, kind := range []usecase.ExportKind{usecase.ExportTrades, usecase.ExportDays} {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, format := range exportFormats {
+			label := fmt.Sprintf("%s %s", exportKindLabel(tr, kind), strings.ToUpper(format))
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("exp:%s:%s:%s:%s:%s", kind, format, acct.ID, from, to)))
+		}
+		rows = append(rows, row)
+	}
+	msg := tgbotapi.NewMessage(chatID, tr.T("export.pick", "from", from, "to", to))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := h.bot.Send(msg); err != nil {
+		return
+	}
+}
+
+func exportKindLabel(tr i18n.Localizer, kind usecase.ExportKind) string {
+	if kind == usecase.ExportTrades {
+		return tr.T("export.trades")
+	}
+	return tr.T("export.days")
+}
+
+func (h *Handler) runExport(ctx context.Context, chatID int64, tr i18n.Localizer, acct domain.Account, kind usecase.ExportKind, format string, p domain.Period) {
+	res, err := h.exportUC.Export(ctx, acct, kind, format, p)
+	if errors.Is(err, usecase.ErrNoData) {
+		h.replyBestEffort(chatID, tr.T("export.nothing", "from", p.From, "to", p.To))
+		return
+	}
+	if err != nil {
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+		return
+	}
+	defer func() {
+		if err := res.Cleanup(); err != nil {
+			return
+		}
+	}()
+
+	for i, f := range res.Files {
+		caption := ""
+		if len(res.Files) > 1 {
+			caption = tr.T("export.part", "part", i+1, "parts", len(res.Files))
+		}
+		if err := h.SendDocument(chatID, f.Path, caption); err != nil {
+			h.replyBestEffort(chatID, tr.T("error", "err", err))
+			return
+		}
+	}
+}
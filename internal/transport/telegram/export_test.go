@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/infra/export"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_exportCallback(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	src := &stubReports{points: []ports.PnLPoint{{Date: "2024-01-01", PnL: 1}, {Date: "2024-01-02", PnL: 2}}}
+	eu := usecase.NewExportUsecase(nil, src, export.NewFactory(t.TempDir(), MaxDocumentBytes))
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, nil, WithExport(eu))
+
+	h.handleCallback(context.Background(), callback(7, 7, "export::2024-01-01:2024-01-02"))
+	picker := fake.Calls("sendMessage")
+	require.Len(t, picker, 1)
+	require.Contains(t, picker[0].params.Get("reply_markup"), "exp:days:xlsx::2024-01-01:2024-01-02")
+
+	h.handleCallback(context.Background(), callback(7, 7, "exp:days:csv::2024-01-01:2024-01-02"))
+	docs := fake.Calls("sendDocument")
+	require.Len(t, docs, 1)
+	require.Equal(t, "7", docs[0].params.Get("chat_id"))
+}
+
+func TestHandler_cmdExport_usage(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, nil, WithExport(&usecase.ExportUsecase{}))
+
+	h.handleMessage(context.Background(), commandMessage(7, "/export 2024-01-01"))
+
+	msgs := fake.Calls("sendMessage")
+	require.Len(t, msgs, 1)
+	require.Equal(t, enTr.T("export.usage"), msgs[0].params.Get("text"))
+}
@@ -0,0 +1,223 @@
+// Package format builds Telegram message text that is safe to send with a parse mode. Text is
+// assembled from styled segments and rendered as MarkdownV2, HTML, or plain text with
+// message entities; every mode escapes user data the way the Bot API expects.
+package format
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Mode selects how a message is formatted.
+type Mode string
+
+const (
+	ModePlain      Mode = ""
+	ModeMarkdownV2 Mode = tgbotapi.ModeMarkdownV2
+	ModeHTML       Mode = tgbotapi.ModeHTML
+	ModeEntities   Mode = "entities"
+)
+
+// markdownV2Special are the characters MarkdownV2 requires escaped outside code.
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+// EscapeMarkdownV2 escapes s for use as MarkdownV2 text.
+func EscapeMarkdownV2(s string) string {
+	return escape(s, markdownV2Special)
+}
+
+// EscapeMarkdownV2Code escapes s for use inside MarkdownV2 code and pre blocks.
+func EscapeMarkdownV2Code(s string) string {
+	return escape(s, "`\\")
+}
+
+// EscapeHTML escapes s for use as HTML text or an attribute value.
+func EscapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+func escape(s, special string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type style int
+
+const (
+	stylePlain style = iota
+	styleBold
+	styleItalic
+	styleCode
+	stylePre
+	styleLink
+)
+
+type segment struct {
+	style style
+	text  string
+	url   string // link target
+}
+
+// Text is a message under construction. The zero value is empty and ready to use; methods
+// append a segment and return the receiver for chaining.
+type Text struct {
+	segments []segment
+}
+
+func (t *Text) add(s segment) *Text {
+	if s.text != "" {
+		t.segments = append(t.segments, s)
+	}
+	return t
+}
+
+// Plain appends unstyled text.
+func (t *Text) Plain(s string) *Text { return t.add(segment{style: stylePlain, text: s}) }
+
+// Bold appends bold text.
+func (t *Text) Bold(s string) *Text { return t.add(segment{style: styleBold, text: s}) }
+
+// Italic appends italic text.
+func (t *Text) Italic(s string) *Text { return t.add(segment{style: styleItalic, text: s}) }
+
+// Code appends inline monospace text.
+func (t *Text) Code(s string) *Text { return t.add(segment{style: styleCode, text: s}) }
+
+// Pre appends a preformatted block.
+func (t *Text) Pre(s string) *Text { return t.add(segment{style: stylePre, text: s}) }
+
+// Link appends s linking to url.
+func (t *Text) Link(s, url string) *Text { return t.add(segment{style: styleLink, text: s, url: url}) }
+
+// Line appends a line break.
+func (t *Text) Line() *Text { return t.Plain("\n") }
+
+// Message is rendered text with what Telegram needs to interpret it.
+type Message struct {
+	Text      string
+	ParseMode string
+	Entities  []tgbotapi.MessageEntity
+}
+
+// Render formats t in mode; unknown modes render plain text.
+func (t *Text) Render(mode Mode) Message {
+	switch mode {
+	case ModeMarkdownV2:
+		return Message{Text: t.markdownV2(), ParseMode: tgbotapi.ModeMarkdownV2}
+	case ModeHTML:
+		return Message{Text: t.html(), ParseMode: tgbotapi.ModeHTML}
+	case ModeEntities:
+		text, entities := t.entities()
+		return Message{Text: text, Entities: entities}
+	default:
+		return Message{Text: t.String()}
+	}
+}
+
+// String returns the text without formatting.
+func (t *Text) String() string {
+	var b strings.Builder
+	for _, s := range t.segments {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+func (t *Text) markdownV2() string {
+	var b strings.Builder
+	for _, s := range t.segments {
+		switch s.style {
+		case styleBold:
+			b.WriteString("*" + EscapeMarkdownV2(s.text) + "*")
+		case styleItalic:
+			b.WriteString("_" + EscapeMarkdownV2(s.text) + "_")
+		case styleCode:
+			b.WriteString("`" + EscapeMarkdownV2Code(s.text) + "`")
+		case stylePre:
+			b.WriteString("```\n" + EscapeMarkdownV2Code(s.text) + "\n```")
+		case styleLink:
+			b.WriteString("[" + EscapeMarkdownV2(s.text) + "](" + escape(s.url, `)\`) + ")")
+		default:
+			b.WriteString(EscapeMarkdownV2(s.text))
+		}
+	}
+	return b.String()
+}
+
+func (t *Text) html() string {
+	var b strings.Builder
+	for _, s := range t.segments {
+		text := EscapeHTML(s.text)
+		switch s.style {
+		case styleBold:
+			b.WriteString("<b>" + text + "</b>")
+		case styleItalic:
+			b.WriteString("<i>" + text + "</i>")
+		case styleCode:
+			b.WriteString("<code>" + text + "</code>")
+		case stylePre:
+			b.WriteString("<pre>" + text + "</pre>")
+		case styleLink:
+			b.WriteString(`<a href="` + EscapeHTML(s.url) + `">` + text + "</a>")
+		default:
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+var entityTypes = map[style]string{
+	styleBold:   "bold",
+	styleItalic: "italic",
+	styleCode:   "code",
+	stylePre:    "pre",
+	styleLink:   "text_link",
+}
+
+// entities returns the plain text and its entities; offsets and lengths are in UTF-16 code
+// units as the Bot API requires.
+func (t *Text) entities() (string, []tgbotapi.MessageEntity) {
+	var b strings.Builder
+	var out []tgbotapi.MessageEntity
+	offset := 0
+	for _, s := range t.segments {
+		n := len(utf16.Encode([]rune(s.text)))
+		if typ, ok := entityTypes[s.style]; ok {
+			out = append(out, tgbotapi.MessageEntity{Type: typ, Offset: offset, Length: n, URL: s.url})
+		}
+		b.WriteString(s.text)
+		offset += n
+	}
+	return b.String(), out
+}
+
+var (
+	htmlTag          = regexp.MustCompile(`<[^>]*>`)
+	markdownV2Escape = regexp.MustCompile(`\\(.)`)
+	markdownV2Marker = regexp.MustCompile("(^|[^\\\\])[*_~|`]+")
+)
+
+// Strip turns text formatted for parseMode into readable plain text: HTML tags are dropped and
+// entities decoded, MarkdownV2 markers and escapes removed. It is a best-effort fallback for
+// text Telegram refused to parse, not a parser.
+func Strip(text, parseMode string) string {
+	switch parseMode {
+	case tgbotapi.ModeHTML:
+		return html.UnescapeString(htmlTag.ReplaceAllString(text, ""))
+	case tgbotapi.ModeMarkdownV2:
+		text = markdownV2Marker.ReplaceAllString(text, "$1")
+		return markdownV2Escape.ReplaceAllString(text, "$1")
+	}
+	return text
+}
@@ -0,0 +1,52 @@
+package format
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscape(t *testing.T) {
+	require.Equal(t, `1000\_PEPE\_USDT \+2\.5% \(x\)\!`, EscapeMarkdownV2("1000_PEPE_USDT +2.5% (x)!"))
+	require.Equal(t, "a\\`b\\\\c_d", EscapeMarkdownV2Code("a`b\\c_d"))
+	require.Equal(t, "&lt;b&gt; &amp; &#34;q&#34;", EscapeHTML(`<b> & "q"`))
+}
+
+func TestText_Render(t *testing.T) {
+	var txt Text
+	txt.Bold("BTC_USDT").Plain(" +1.5% ").Italic("a*b").Line().
+		Code("x`y").Plain(" ").Link("docs (v2)", "https://e.x/a)b").Plain(" <&>")
+
+	require.Equal(t, Message{
+		Text:      "*BTC\\_USDT* \\+1\\.5% _a\\*b_\n`x\\`y` [docs \\(v2\\)](https://e.x/a\\)b) <&\\>",
+		ParseMode: tgbotapi.ModeMarkdownV2,
+	}, txt.Render(ModeMarkdownV2))
+
+	require.Equal(t, Message{
+		Text:      "<b>BTC_USDT</b> +1.5% <i>a*b</i>\n<code>x`y</code> <a href=\"https://e.x/a)b\">docs (v2)</a> &lt;&amp;&gt;",
+		ParseMode: tgbotapi.ModeHTML,
+	}, txt.Render(ModeHTML))
+
+	plain := "BTC_USDT +1.5% a*b\nx`y docs (v2) <&>"
+	require.Equal(t, Message{Text: plain}, txt.Render(ModePlain))
+	require.Equal(t, plain, txt.String())
+}
+
+func TestText_entitiesUTF16(t *testing.T) {
+	var txt Text
+	txt.Plain("🟢 ").Bold("BTC").Plain(" ").Link("chart", "https://e.x").Pre("")
+	m := txt.Render(ModeEntities)
+	require.Equal(t, "🟢 BTC chart", m.Text)
+	require.Empty(t, m.ParseMode)
+	require.Equal(t, []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 3, Length: 3},
+		{Type: "text_link", Offset: 7, Length: 5, URL: "https://e.x"},
+	}, m.Entities)
+}
+
+func TestStrip(t *testing.T) {
+	require.Equal(t, `BTC & <ETH> 1.5 tail`, Strip(`<b>BTC</b> &amp; &lt;ETH&gt; <i>1.5 tail`, tgbotapi.ModeHTML))
+	require.Equal(t, "BTC_USDT +1.5 (x) bold", Strip(`BTC\_USDT \+1\.5 \(x\) *bold`, tgbotapi.ModeMarkdownV2))
+	require.Equal(t, "*as is*", Strip("*as is*", ""))
+}
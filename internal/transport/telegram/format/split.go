@@ -0,0 +1,299 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxLength is the Bot API limit on message text, in UTF-16 code units.
+const MaxLength = 4096
+
+// numberReserve leaves room for the "(i/n)" part header.
+const numberReserve = 16
+
+// Len returns the length of s as Telegram counts it, in UTF-16 code units.
+func Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// Split breaks m into parts of at most limit UTF-16 code units, in order. It cuts at paragraph
+// breaks, then line breaks, and only inside a line that alone exceeds the limit. Formatting
+// that spans a cut is closed at the end of one part and reopened at the start of the next:
+// HTML tags, MarkdownV2 markers, or entities. With more than one part each starts with "(i/n)".
+func Split(m Message, limit int) []Message {
+	if Len(m.Text) <= limit {
+		return []Message{m}
+	}
+	budget := limit - numberReserve
+	var st markupState
+	switch m.ParseMode {
+	case tgbotapi.ModeHTML:
+		st = htmlState{}
+	case tgbotapi.ModeMarkdownV2:
+		st = markdownState{}
+	default:
+		st = plainState{}
+	}
+	units := splitUnits(m.Text, budget*3/4, st.safeCut)
+	ranges := chunks(units, st, budget)
+
+	parts := make([]Message, 0, len(ranges))
+	before := st
+	offset := 0 // UTF-16 offset of the current range in m.Text
+	for i, r := range ranges {
+		content := strings.Join(units[r.from:r.to], "")
+		after := r.state
+		trimmed := strings.TrimRight(content, "\n")
+		header := fmt.Sprintf("(%d/%d)\n", i+1, len(ranges))
+		if m.ParseMode == tgbotapi.ModeMarkdownV2 {
+			header = EscapeMarkdownV2(header)
+		}
+		p := Message{Text: header + before.open() + trimmed + after.close(), ParseMode: m.ParseMode}
+		if len(m.Entities) > 0 {
+			p.Entities = clipEntities(m.Entities, offset, offset+Len(trimmed), Len(header))
+		}
+		parts = append(parts, p)
+		before = after
+		offset += Len(content)
+	}
+	return parts
+}
+
+// clipEntities returns the parts of entities within [from, to), rebased to start at shift.
+func clipEntities(entities []tgbotapi.MessageEntity, from, to, shift int) []tgbotapi.MessageEntity {
+	var out []tgbotapi.MessageEntity
+	for _, e := range entities {
+		start, end := max(e.Offset, from), min(e.Offset+e.Length, to)
+		if start >= end {
+			continue
+		}
+		e.Offset, e.Length = start-from+shift, end-start
+		out = append(out, e)
+	}
+	return out
+}
+
+type chunkRange struct {
+	from, to int         // units[from:to]
+	state    markupState // formatting open after the last unit
+}
+
+// chunks packs units greedily into ranges whose rendered length fits budget, preferring to end
+// a range after a blank line when that keeps it at least half full.
+func chunks(units []string, initial markupState, budget int) []chunkRange {
+	states := make([]markupState, len(units))
+	st := initial
+	for i, u := range units {
+		st = st.advance(u)
+		states[i] = st
+	}
+
+	var out []chunkRange
+	before := initial
+	for from := 0; from < len(units); {
+		size := Len(before.open())
+		to, paragraph := from, -1
+		for to < len(units) {
+			n := size + Len(units[to]) + Len(states[to].close())
+			if n > budget && to > from {
+				break
+			}
+			size += Len(units[to])
+			to++
+			if to < len(units) && units[to-1] == "\n" && size >= budget/2 {
+				paragraph = to
+			}
+		}
+		if to < len(units) && paragraph > from {
+			to = paragraph
+		}
+		out = append(out, chunkRange{from: from, to: to, state: states[to-1]})
+		before = states[to-1]
+		from = to
+	}
+	return out
+}
+
+// splitUnits splits text into lines, each keeping its "\n", and cuts lines longer than max
+// into pieces, preferably at spaces, at offsets cut accepts.
+func splitUnits(text string, max int, cut func(s string, n int) int) []string {
+	var out []string
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for Len(line) > max {
+			n := byteOffset(line, max)
+			if sp := strings.LastIndexByte(line[:n], ' '); sp > n/2 {
+				n = sp + 1
+			}
+			if c := cut(line, n); c > 0 {
+				n = c
+			}
+			out = append(out, line[:n])
+			line = line[n:]
+		}
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// byteOffset returns the byte offset in s after at most n UTF-16 code units.
+func byteOffset(s string, n int) int {
+	units := 0
+	for i, r := range s {
+		units += utf16.RuneLen(r)
+		if units > n {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// markupState is the formatting open at some point of a message. States are values: advance
+// returns a new state.
+type markupState interface {
+	advance(s string) markupState
+	open() string  // markup reopening the formatting
+	close() string // markup closing the formatting
+	// safeCut moves a cut at byte n of s back so it does not split markup; 0 means keep n.
+	safeCut(s string, n int) int
+}
+
+type plainState struct{}
+
+func (plainState) advance(string) markupState { return plainState{} }
+func (plainState) open() string               { return "" }
+func (plainState) close() string              { return "" }
+func (plainState) safeCut(string, int) int    { return 0 }
+
+var htmlTagName = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*>`)
+
+// htmlState is the stack of open HTML tags, outermost first.
+type htmlState []string
+
+func (s htmlState) advance(text string) markupState {
+	out := append(htmlState(nil), s...)
+	for _, m := range htmlTagName.FindAllStringSubmatch(text, -1) {
+		if m[1] == "" {
+			out = append(out, m[0])
+			continue
+		}
+		for i := len(out) - 1; i >= 0; i-- {
+			if tagName(out[i]) == strings.ToLower(m[2]) {
+				out = append(out[:i], out[i+1:]...)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func tagName(tag string) string {
+	return strings.ToLower(htmlTagName.FindStringSubmatch(tag)[2])
+}
+
+func (s htmlState) open() string { return strings.Join(s, "") }
+
+func (s htmlState) close() string {
+	var b strings.Builder
+	for i := len(s) - 1; i >= 0; i-- {
+		b.WriteString("</" + tagName(s[i]) + ">")
+	}
+	return b.String()
+}
+
+func (htmlState) safeCut(s string, n int) int {
+	head := s[:n]
+	if lt := strings.LastIndexByte(head, '<'); lt > strings.LastIndexByte(head, '>') && lt > 0 {
+		return lt
+	}
+	if amp := strings.LastIndexByte(head, '&'); amp > strings.LastIndexByte(head, ';') && amp > 0 {
+		return amp
+	}
+	return 0
+}
+
+// markdownState is the stack of open MarkdownV2 markers, outermost first.
+type markdownState []string
+
+var markdownMarkers = []string{"```", "||", "__", "`", "*", "_", "~"}
+
+func (s markdownState) advance(text string) markupState {
+	out := append(markdownState(nil), s...)
+	for i := 0; i < len(text); {
+		if text[i] == '\\' {
+			i += 2
+			continue
+		}
+		top := ""
+		if len(out) > 0 {
+			top = out[len(out)-1]
+		}
+		matched := ""
+		for _, m := range markdownMarkers {
+			if strings.HasPrefix(text[i:], m) {
+				matched = m
+				break
+			}
+		}
+		// Inside code only its own closing marker counts.
+		if (top == "```" || top == "`") && matched != top {
+			matched = ""
+		}
+		switch {
+		case matched == "":
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		case matched == top:
+			out = out[:len(out)-1]
+		default:
+			out = append(out, matched)
+		}
+		i += len(matched)
+	}
+	return out
+}
+
+func (s markdownState) open() string {
+	var b strings.Builder
+	for _, m := range s {
+		b.WriteString(m)
+		if m == "```" {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func (s markdownState) close() string {
+	var b strings.Builder
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == "```" {
+			b.WriteString("\n")
+		}
+		b.WriteString(s[i])
+	}
+	return b.String()
+}
+
+// safeCut keeps an escaping backslash together with the character it escapes.
+func (markdownState) safeCut(s string, n int) int {
+	slashes := 0
+	for i := n - 1; i >= 0 && s[i] == '\\'; i-- {
+		slashes++
+	}
+	if slashes%2 == 1 {
+		return n - 1
+	}
+	return 0
+}
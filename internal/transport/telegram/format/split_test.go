@@ -0,0 +1,109 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit_short(t *testing.T) {
+	m := Message{Text: "hello", ParseMode: tgbotapi.ModeHTML}
+	require.Equal(t, []Message{m}, Split(m, MaxLength))
+}
+
+func TestSplit_lines(t *testing.T) {
+	var lines []string
+	for i := 0; i < 300; i++ {
+		lines = append(lines, strings.Repeat("x", 29))
+	}
+	text := strings.Join(lines, "\n")
+	parts := Split(Message{Text: text}, MaxLength)
+	require.Len(t, parts, 3)
+
+	var joined []string
+	for i, p := range parts {
+		require.LessOrEqual(t, Len(p.Text), MaxLength)
+		header, body, ok := strings.Cut(p.Text, "\n")
+		require.True(t, ok)
+		require.Equal(t, []string{"(1/3)", "(2/3)", "(3/3)"}[i], header)
+		for _, l := range strings.Split(body, "\n") {
+			require.Len(t, l, 29, "lines are never cut")
+		}
+		joined = append(joined, body)
+	}
+	require.Equal(t, text, strings.Join(joined, "\n"))
+}
+
+func TestSplit_paragraphs(t *testing.T) {
+	para := strings.Repeat("line of text\n", 100) // 1300 chars
+	text := para + "\n" + para + "\n" + para + "\n" + para
+	parts := Split(Message{Text: text}, MaxLength)
+	require.Len(t, parts, 2)
+	require.True(t, strings.HasSuffix(parts[0].Text, "line of text"))
+	require.True(t, strings.HasPrefix(parts[1].Text, "(2/2)\nline of text"))
+	require.Equal(t, 2, strings.Count(parts[0].Text, "\n\n"), "cut falls on the paragraph break")
+}
+
+func TestSplit_longLine(t *testing.T) {
+	text := strings.Repeat("word ", 2000)
+	parts := Split(Message{Text: text}, MaxLength)
+	require.Len(t, parts, 3)
+	for _, p := range parts {
+		require.LessOrEqual(t, Len(p.Text), MaxLength)
+		_, body, _ := strings.Cut(p.Text, "\n")
+		require.True(t, strings.HasPrefix(body, "word"), "cut at a space")
+	}
+}
+
+func TestSplit_html(t *testing.T) {
+	text := "<b>Dump</b>\n<pre>" + strings.Repeat("a &amp; b\n", 500) + "</pre>\ndone"
+	parts := Split(Message{Text: text, ParseMode: tgbotapi.ModeHTML}, MaxLength)
+	require.Len(t, parts, 2)
+	require.True(t, strings.HasSuffix(parts[0].Text, "a &amp; b</pre>"))
+	require.True(t, strings.HasPrefix(parts[1].Text, "(2/2)\n<pre>a &amp; b"))
+	require.True(t, strings.HasSuffix(parts[1].Text, "</pre>\ndone"))
+	for _, p := range parts {
+		require.Equal(t, tgbotapi.ModeHTML, p.ParseMode)
+		require.Equal(t, strings.Count(p.Text, "<pre>"), strings.Count(p.Text, "</pre>"))
+	}
+}
+
+func TestSplit_htmlLongLine(t *testing.T) {
+	text := strings.Repeat(`<a href="https://example.com/x">link</a> &amp;`, 200)
+	parts := Split(Message{Text: text, ParseMode: tgbotapi.ModeHTML}, 1000)
+	require.Greater(t, len(parts), 1)
+	for _, p := range parts {
+		_, body, _ := strings.Cut(p.Text, "\n")
+		require.Equal(t, strings.Count(body, "<a "), strings.Count(body, "</a>"), body)
+		require.NotRegexp(t, `&[a-z]*$|<[^>]*$`, body)
+	}
+}
+
+func TestSplit_markdownV2(t *testing.T) {
+	text := "*Dump*\n```\n" + strings.Repeat("x\\_y\n", 1000) + "```\n_end_"
+	parts := Split(Message{Text: text, ParseMode: tgbotapi.ModeMarkdownV2}, MaxLength)
+	require.Len(t, parts, 2)
+	require.True(t, strings.HasPrefix(parts[0].Text, "\\(1/2\\)\n*Dump*\n```\n"))
+	require.True(t, strings.HasSuffix(parts[0].Text, "x\\_y\n```"))
+	require.True(t, strings.HasPrefix(parts[1].Text, "\\(2/2\\)\n```\nx\\_y"))
+	require.True(t, strings.HasSuffix(parts[1].Text, "```\n_end_"))
+}
+
+func TestSplit_entities(t *testing.T) {
+	var txt Text
+	txt.Bold("Title").Line().Pre(strings.Repeat("0123456789\n", 500)).Plain("tail")
+	m := txt.Render(ModeEntities)
+	parts := Split(m, MaxLength)
+	require.Len(t, parts, 2)
+
+	require.Equal(t, []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 6, Length: 5},
+		{Type: "pre", Offset: 12, Length: Len(parts[0].Text) - 12},
+	}, parts[0].Entities)
+	require.Equal(t, "pre", parts[1].Entities[0].Type)
+	require.Equal(t, 6, parts[1].Entities[0].Offset)
+	require.True(t, strings.HasSuffix(parts[1].Text, "0123456789\ntail"))
+	require.Equal(t, Len(parts[1].Text)-6-4, parts[1].Entities[0].Length)
+}
@@ -11,35 +11,156 @@ import (
 	"time"
 
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type userFlowState struct {
-	mu    sync.Mutex
-	From  string
-	To    string
-	Step  int // 0 none, 1 waiting from, 2 waiting to
-	Year  int
-	Month int
+	mu      sync.Mutex
+	Account string
+	From    string
+	To      string
+	Step    int // 0 none, 1 waiting from, 2 waiting to
+	Year    int
+	Month   int
 }
 
 // Handler processes Telegram updates and forwards queue messages to group chats.
 type Handler struct {
 	bot      *tgbotapi.BotAPI
 	cfg      *config.Config
+	cfgMu    sync.RWMutex
 	reportUC *usecase.ReportUsecase
-	states   map[int64]*userFlowState
-	statesMu sync.Mutex
+	exportUC *usecase.ExportUsecase
+
+	conversionUC  *usecase.ConversionUsecase
+	prefs         ports.PreferencesStore
+	signals       ports.SignalStore
+	signalUC      *usecase.SignalUsecase
+	signalCmds    ports.SignalCommandPublisher
+	signalActions signalActionState
+	portfolioUC   *usecase.PortfolioUsecase
+	alertUC       *usecase.AlertUsecase
+	catalog       *i18n.Catalog
+	logger        ports.Logger
+	templates     map[string]*notificationTemplate // keyed by queue name
+	templatesMu   sync.RWMutex
+	states        map[int64]*userFlowState
+	statesMu      sync.Mutex
+	commands      []command
+
+	control        ports.CoreController
+	controlTimeout time.Duration
+	controls       controlState
+	auditLog       ports.AuditLog
+
+	criticals criticalState
+
+	syncedUsers []int64
+	syncedMu    sync.Mutex
+
+	chatLocks   map[int64]*chatLock
+	chatLocksMu sync.Mutex
+}
+
+// Option enables an optional Handler feature.
+type Option func(*Handler)
+
+// WithExport enables CSV/XLSX report exports.
+func WithExport(eu *usecase.ExportUsecase) Option {
+	return func(h *Handler) { h.exportUC = eu }
+}
+
+// WithConversion enables converting displayed report totals to DISPLAY_CURRENCY.
+func WithConversion(cu *usecase.ConversionUsecase) Option {
+	return func(h *Handler) { h.conversionUC = cu }
+}
+
+// WithPreferences enables per-user settings stored in prefs.
+func WithPreferences(prefs ports.PreferencesStore) Option {
+	return func(h *Handler) { h.prefs = prefs }
+}
+
+// WithSignals indexes posted signal messages in store so lifecycle events reply to them.
+func WithSignals(store ports.SignalStore) Option {
+	return func(h *Handler) { h.signals = store }
+}
+
+// WithCriticals keeps critical pages in store so they survive restarts.
+func WithCriticals(store ports.CriticalStore) Option {
+	return func(h *Handler) { h.criticals.store = store }
+}
+
+// WithSignalStats enables /signals stats over the signal ledger.
+func WithSignalStats(su *usecase.SignalUsecase) Option {
+	return func(h *Handler) { h.signalUC = su }
+}
+
+// WithSignalActions adds action buttons to signal cards whose commands are sent with pub.
+// It needs WithSignals.
+func WithSignalActions(pub ports.SignalCommandPublisher) Option {
+	return func(h *Handler) { h.signalCmds = pub }
+}
+
+// WithPortfolio enables /positions and /balance with the live portfolio loaded by pu.
+func WithPortfolio(pu *usecase.PortfolioUsecase) Option {
+	return func(h *Handler) { h.portfolioUC = pu }
+}
+
+// WithAlerts enables /alert to manage user alerts in au; fired alerts are delivered through
+// NotifyAlert.
+func WithAlerts(au *usecase.AlertUsecase) Option {
+	return func(h *Handler) { h.alertUC = au }
+}
+
+// WithLogger logs failures the user is only told about in general terms, such as failed
+// currency conversions.
+func WithLogger(logger ports.Logger) Option {
+	return func(h *Handler) { h.logger = logger }
+}
+
+// WithControl enables the admin commands /pause, /resume and /status, sent to the trading core
+// with ctl. The core must acknowledge within timeout (10 seconds when not positive). Every
+// invocation is recorded in audit.
+func WithControl(ctl ports.CoreController, audit ports.AuditLog, timeout time.Duration) Option {
+	return func(h *Handler) {
+		h.control, h.auditLog, h.controlTimeout = ctl, audit, timeout
+		if timeout <= 0 {
+			h.controlTimeout = defaultControlTimeout
+		}
+	}
 }
 
 // NewHandler constructs a Handler for the given bot, config, and report use case.
-func NewHandler(bot *tgbotapi.BotAPI, cfg *config.Config, ru *usecase.ReportUsecase) *Handler {
-	return &Handler{bot: bot, cfg: cfg, reportUC: ru, states: make(map[int64]*userFlowState)}
+func NewHandler(bot *tgbotapi.BotAPI, cfg *config.Config, ru *usecase.ReportUsecase, opts ...Option) *Handler {
+	h := &Handler{bot: bot, cfg: cfg, reportUC: ru, catalog: i18n.Default(), states: make(map[int64]*userFlowState)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.registerCommands()
+	return h
+}
+
+// UpdateConfig swaps the configuration used for access checks and command publishing.
+func (h *Handler) UpdateConfig(cfg *config.Config) {
+	h.cfgMu.Lock()
+	defer h.cfgMu.Unlock()
+	h.cfg = cfg
 }
 
-// Run starts long-polling for updates in a background goroutine.
+func (h *Handler) config() *config.Config {
+	h.cfgMu.RLock()
+	defer h.cfgMu.RUnlock()
+	return h.cfg
+}
+
+// Run starts long-polling for updates and the repeats of unacknowledged critical messages in
+// background goroutines.
 func (h *Handler) Run(ctx context.Context) {
+	go h.runCriticals(ctx)
 	go func() {
 		u := tgbotapi.NewUpdate(0)
 		u.Timeout = 30
@@ -67,16 +188,21 @@ func (h *Handler) Run(ctx context.Context) {
 	}()
 }
 
-// SendToGroup sends a plain text message to a chat or group.
+// SendToGroup sends a plain text message to a chat or group, split into parts if it is long.
 func (h *Handler) SendToGroup(chatID int64, text string) error {
-	msg := tgbotapi.NewMessage(chatID, text)
-	_, err := h.bot.Send(msg)
-	if err != nil {
+	if _, err := h.sendFormatted(tgbotapi.NewMessage(chatID, text)); err != nil {
 		return fmt.Errorf("telegram send to group: %w", err)
 	}
 	return nil
 }
 
+// logError logs msg when a logger is set.
+func (h *Handler) logError(msg string, keysAndValues ...any) {
+	if h.logger != nil {
+		h.logger.Error(msg, keysAndValues...)
+	}
+}
+
 func (h *Handler) replyBestEffort(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	if _, err := h.bot.Send(msg); err != nil {
@@ -91,20 +217,20 @@ func (h *Handler) answerCallbackBestEffort(q *tgbotapi.CallbackQuery, text strin
 	}
 }
 
-func (h *Handler) sendMenuBestEffort(chatID int64) {
-	if err := h.sendMenu(chatID); err != nil {
+func (h *Handler) sendMenuBestEffort(chatID int64, tr i18n.Localizer) {
+	if err := h.sendMenu(chatID, tr); err != nil {
 		return
 	}
 }
 
-func (h *Handler) sendCalendarBestEffort(chatID int64, step int, year int, month time.Month) {
-	if err := h.sendCalendar(chatID, step, year, month); err != nil {
+func (h *Handler) sendCalendarBestEffort(chatID int64, tr i18n.Localizer, step int, year int, month time.Month) {
+	if err := h.sendCalendar(chatID, tr, step, year, month); err != nil {
 		return
 	}
 }
 
 func (h *Handler) isAdmin(id int64) bool {
-	return slices.Contains(h.cfg.UserIDs, id)
+	return slices.Contains(h.config().UserIDs, id)
 }
 
 func (h *Handler) getState(userID int64) *userFlowState {
@@ -118,36 +244,76 @@ func (h *Handler) getState(userID int64) *userFlowState {
 	return st
 }
 
-func (h *Handler) handleMessage(_ context.Context, msg *tgbotapi.Message) {
+func (h *Handler) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
+	if msg.From == nil {
+		return
+	}
 	userID := msg.From.ID
 	chatID := msg.Chat.ID
+	tr := h.tr(msg.From)
 
-	if !h.isAdmin(userID) {
-		h.replyBestEffort(chatID, "Access denied")
+	if cmd, ok := h.findCommand(msg.Command()); ok {
+		if h.roleOf(userID) < cmd.role {
+			if cmd.audit {
+				h.auditDenied(msg)
+			}
+			h.replyBestEffort(chatID, tr.T("access_denied"))
+			return
+		}
+		// Private commands would show portfolio data or prompts to the whole group.
+		if cmd.scope&chatScopeOf(msg.Chat) == 0 {
+			h.replyBestEffort(chatID, tr.T("private_only"))
+			return
+		}
+		cmd.run(ctx, msg, strings.TrimSpace(msg.CommandArguments()))
 		return
 	}
 
-	text := strings.TrimSpace(msg.Text)
-	st := h.getState(userID)
-	st.mu.Lock()
-	defer st.mu.Unlock()
-
-	if text == "/start" {
-		h.sendMenuBestEffort(chatID)
-		st.Step = 0
+	if !msg.Chat.IsPrivate() {
+		return
+	}
+	if h.roleOf(userID) < roleViewer {
+		h.replyBestEffort(chatID, tr.T("access_denied"))
 		return
 	}
 
-	h.replyBestEffort(chatID, "Unknown input. Use /start to open menu")
+	h.replyBestEffort(chatID, tr.T("unknown_input"))
 }
 
 func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery) {
 	data := q.Data
 	userID := q.From.ID
 	chatID := q.Message.Chat.ID
+	tr := h.tr(q.From)
 
-	if !h.isAdmin(userID) {
-		h.answerCallbackBestEffort(q, "Access denied")
+	// The escalation list may include users without access to the bot.
+	if strings.HasPrefix(data, "ack:") {
+		h.handleCriticalCallback(q, data)
+		return
+	}
+
+	if h.roleOf(userID) < roleViewer {
+		h.answerCallbackBestEffort(q, tr.T("access_denied"))
+		return
+	}
+
+	if strings.HasPrefix(data, "sa:") {
+		h.handleSignalActionCallback(ctx, q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "ctl:") {
+		h.handleControlCallback(ctx, q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "pf:") {
+		h.handlePortfolioCallback(ctx, q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "al:") {
+		h.handleAlertCallback(q, data)
 		return
 	}
 
@@ -156,11 +322,40 @@ func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery)
 	defer st.mu.Unlock()
 
 	if data == "menu:total_profit_loss" {
-		st.Step = 1
+		st.Step = 0
 		st.From = ""
 		st.To = ""
-		today := time.Now()
-		h.sendCalendarBestEffort(chatID, 1, today.Year(), today.Month())
+		accts := h.accountsFor(userID)
+		if len(accts) == 0 {
+			h.replyBestEffort(chatID, tr.T("no_accounts"))
+			return
+		}
+		id, ok := h.defaultAccount(userID)
+		if !ok && len(accts) > 1 {
+			h.sendAccountPickerBestEffort(chatID, tr, accts)
+			return
+		}
+		if !ok {
+			id = accts[0].ID
+		}
+		st.Account = id
+		st.Step = 1
+		today := time.Now().In(h.location(userID))
+		h.sendCalendarBestEffort(chatID, tr, 1, today.Year(), today.Month())
+		return
+	}
+
+	if strings.HasPrefix(data, "acct:") {
+		id := strings.TrimPrefix(data, "acct:")
+		if _, ok := h.accountFor(userID, id); !ok && id != domain.AllAccounts {
+			h.answerCallbackBestEffort(q, tr.T("access_denied"))
+			return
+		}
+		st.Account = id
+		st.Step = 1
+		h.answerCallbackBestEffort(q, "")
+		today := time.Now().In(h.location(userID))
+		h.sendCalendarBestEffort(chatID, tr, 1, today.Year(), today.Month())
 		return
 	}
 
@@ -173,35 +368,81 @@ func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery)
 		case "1":
 			st.From = date
 			st.Step = 2
-			h.answerCallbackBestEffort(q, "Start date selected: "+date)
-			today := time.Now()
-			h.sendCalendarBestEffort(chatID, 2, today.Year(), today.Month())
+			h.answerCallbackBestEffort(q, tr.T("calendar.from_selected", "date", date))
+			today := time.Now().In(h.location(userID))
+			h.sendCalendarBestEffort(chatID, tr, 2, today.Year(), today.Month())
 		case "2":
 			st.To = date
 			st.Step = 0
-			h.answerCallbackBestEffort(q, "End date selected: "+date)
+			h.answerCallbackBestEffort(q, tr.T("calendar.to_selected", "date", date))
 
-			rep, err := h.reportUC.GetReport(ctx, st.From, st.To)
+			if st.Account == domain.AllAccounts {
+				reps, err := h.reportUC.GetAggregateReport(ctx, h.accountsFor(userID), h.period(userID, st.From, st.To))
+				if err != nil {
+					h.replyBestEffort(chatID, tr.T("error", "err", err))
+					return
+				}
+				reps, note := h.displayReports(ctx, tr, h.displayCurrency(userID), reps)
+				h.replyBestEffort(chatID, withNote(formatReports(tr, h.moneyFor(userID), reps), note))
+				return
+			}
+
+			acct, ok := h.accountFor(userID, st.Account)
+			if !ok {
+				h.replyBestEffort(chatID, tr.T("access_denied"))
+				return
+			}
+			rep, err := h.reportUC.GetReport(ctx, acct, h.period(userID, st.From, st.To))
 			if err != nil {
-				h.replyBestEffort(chatID, fmt.Sprintf("error: %v", err))
+				h.replyBestEffort(chatID, tr.T("error", "err", err))
 				return
 			}
 
-			h.replyBestEffort(chatID, fmt.Sprintf("Report from %s to %s. Income: %.2f, Expense: %.2f", rep.From, rep.To, rep.Income, rep.Expense))
+			h.sendReportBestEffort(ctx, chatID, q.From, rep)
 		}
 		return
 	}
 
+	if strings.HasPrefix(data, "export:") || strings.HasPrefix(data, "exp:") {
+		h.handleExportCallback(ctx, q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "cmp:") {
+		h.handleCompareCallback(ctx, q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "chart:") {
+		h.handleChartCallback(ctx, q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "bd:") {
+		h.handleBreakdownCallback(ctx, q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "set:") {
+		h.handleSettingsCallback(q, data)
+		return
+	}
+
+	if strings.HasPrefix(data, "risk:") {
+		h.handleRiskCallback(ctx, q, data)
+		return
+	}
+
 	if strings.HasPrefix(data, "month:") {
 		parts := strings.Split(data, ":")
 		yearMonth := strings.Split(parts[1], "-")
 		y, err := strconv.Atoi(yearMonth[0])
 		if err != nil {
-			h.replyBestEffort(chatID, fmt.Sprintf("convert error: %v", err))
+			h.replyBestEffort(chatID, tr.T("error", "err", err))
 		}
 		m, err := strconv.Atoi(yearMonth[1])
 		if err != nil {
-			h.replyBestEffort(chatID, fmt.Sprintf("convert error: %v", err))
+			h.replyBestEffort(chatID, tr.T("error", "err", err))
 		}
 		step := parts[3]
 
@@ -210,23 +451,23 @@ func (h *Handler) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery)
 		t := time.Date(y, time.Month(m), 1, 0, 0, 0, 0, time.UTC)
 		newYear, newMonth = t.Year(), t.Month()
 
-		h.answerCallbackBestEffort(q, "Month changed")
+		h.answerCallbackBestEffort(q, tr.T("calendar.month_changed"))
 		i, err := strconv.Atoi(step)
 		if err != nil {
-			h.replyBestEffort(chatID, fmt.Sprintf("convert error: %v", err))
+			h.replyBestEffort(chatID, tr.T("error", "err", err))
 		}
-		h.sendCalendarBestEffort(chatID, i, newYear, newMonth)
+		h.sendCalendarBestEffort(chatID, tr, i, newYear, newMonth)
 		return
 	}
 
-	h.answerCallbackBestEffort(q, "Unknown action")
+	h.answerCallbackBestEffort(q, tr.T("unknown_action"))
 }
 
-func (h *Handler) sendMenu(chatID int64) error {
-	msg := tgbotapi.NewMessage(chatID, "Choose action:")
+func (h *Handler) sendMenu(chatID int64, tr i18n.Localizer) error {
+	msg := tgbotapi.NewMessage(chatID, tr.T("menu.choose"))
 	kb := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Total Profit/Loss %", "menu:total_profit_loss"),
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("menu.total_pnl"), "menu:total_profit_loss"),
 		),
 	)
 	msg.ReplyMarkup = kb
@@ -237,7 +478,7 @@ func (h *Handler) sendMenu(chatID int64) error {
 	return nil
 }
 
-func (h *Handler) sendCalendar(chatID int64, step int, year int, month time.Month) error {
+func (h *Handler) sendCalendar(chatID int64, tr i18n.Localizer, step int, year int, month time.Month) error {
 	daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
 
 	var rows [][]tgbotapi.InlineKeyboardButton
@@ -260,12 +501,12 @@ func (h *Handler) sendCalendar(chatID int64, step int, year int, month time.Mont
 	nextMonth := time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC)
 	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 		tgbotapi.NewInlineKeyboardButtonData("◀", fmt.Sprintf("month:%d-%d:prev:%d", prevMonth.Year(), prevMonth.Month(), step)),
-		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s %d", month.String(), year), "noop"),
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s %d", tr.T(fmt.Sprintf("month.%d", month)), year), "noop"),
 		tgbotapi.NewInlineKeyboardButtonData("▶", fmt.Sprintf("month:%d-%d:next:%d", nextMonth.Year(), nextMonth.Month(), step)),
 	))
 
 	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
-	msg := tgbotapi.NewMessage(chatID, "Select date:")
+	msg := tgbotapi.NewMessage(chatID, tr.T("calendar.select_date"))
 	msg.ReplyMarkup = kb
 	_, err := h.bot.Send(msg)
 	if err != nil {
@@ -1,9 +1,16 @@
 package telegram
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,3 +34,102 @@ func TestHandler_getState(t *testing.T) {
 	st3 := h.getState(200)
 	require.NotSame(t, st1, st3)
 }
+
+type apiCall struct {
+	method string
+	params url.Values
+}
+
+// fakeTelegram is an in-process Bot API endpoint that records every request. With
+// rejectMarkup set, messages with a parse mode fail as unparsable; with failFrom set, the
+// failFrom-th and later sendMessage requests fail.
+type fakeTelegram struct {
+	mu           sync.Mutex
+	calls        []apiCall
+	nextID       int
+	rejectMarkup bool
+	failFrom     int
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		_ = r.ParseMultipartForm(32 << 20) //nolint:errcheck // test fake
+	} else {
+		_ = r.ParseForm() //nolint:errcheck // test fake
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, apiCall{method: method, params: r.Form})
+	f.nextID++
+	id := f.nextID
+	reject := f.rejectMarkup && r.Form.Get("parse_mode") != ""
+	fail := f.failFrom > 0 && method == "sendMessage" && len(f.callsLocked(method)) >= f.failFrom
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if fail {
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":500,"description":"Internal Server Error"}`)) //nolint:errcheck // test fake
+		return
+	}
+	if reject {
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities: unsupported start tag"}`)) //nolint:errcheck // test fake
+		return
+	}
+	switch method {
+	case "getMe":
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"username":"test_bot"}}`)) //nolint:errcheck // test fake
+	case "sendMessage", "sendPhoto", "sendDocument", "editMessageText":
+		_, _ = fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d,"chat":{"id":%s}}}`, id, orZero(r.Form.Get("chat_id"))) //nolint:errcheck // test fake
+	default:
+		_, _ = w.Write([]byte(`{"ok":true,"result":true}`)) //nolint:errcheck // test fake
+	}
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// Calls returns recorded requests for method, or all requests when method is empty.
+func (f *fakeTelegram) Calls(method string) []apiCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.callsLocked(method)
+}
+
+func (f *fakeTelegram) callsLocked(method string) []apiCall {
+	var out []apiCall
+	for _, c := range f.calls {
+		if method == "" || c.method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func newFakeBot(t *testing.T) (*tgbotapi.BotAPI, *fakeTelegram) {
+	t.Helper()
+	fake := &fakeTelegram{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", srv.URL+"/bot%s/%s")
+	require.NoError(t, err)
+	return bot, fake
+}
+
+// commandMessage builds a private-chat message from userID whose text starts with a bot command.
+func commandMessage(userID int64, text string) *tgbotapi.Message {
+	cmdLen := len(text)
+	if i := strings.IndexByte(text, ' '); i >= 0 {
+		cmdLen = i
+	}
+	return &tgbotapi.Message{
+		From:     &tgbotapi.User{ID: userID},
+		Chat:     &tgbotapi.Chat{ID: userID, Type: "private"},
+		Text:     text,
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: cmdLen}},
+	}
+}
@@ -0,0 +1,20 @@
+package telegram
+
+import (
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// tr returns the localizer of u: their language setting, else the Telegram client language.
+func (h *Handler) tr(u *tgbotapi.User) i18n.Localizer {
+	lang := h.preferences(u.ID).Language
+	if lang == "" {
+		lang = u.LanguageCode
+	}
+	return h.catalog.Localizer(lang)
+}
+
+// groupTr returns the localizer for group chats, which have no single reader.
+func (h *Handler) groupTr() i18n.Localizer {
+	return h.catalog.Localizer(i18n.DefaultLocale)
+}
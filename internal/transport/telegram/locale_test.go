@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_locale(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	prefs := memPreferences{}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, nil, WithPreferences(prefs))
+
+	msg := commandMessage(7, "/start")
+	msg.From.LanguageCode = "uk"
+	h.handleMessage(context.Background(), msg)
+
+	prefs[7] = domain.Preferences{Language: "ru"}
+	h.handleMessage(context.Background(), msg)
+
+	msg.From.LanguageCode = "fr"
+	prefs[7] = domain.Preferences{}
+	h.handleMessage(context.Background(), msg)
+
+	msgs := fake.Calls("sendMessage")
+	require.Len(t, msgs, 3)
+	require.Equal(t, "Оберіть дію:", msgs[0].params.Get("text"))
+	require.Equal(t, "Выберите действие:", msgs[1].params.Get("text"))
+	require.Equal(t, "Choose action:", msgs[2].params.Get("text"))
+}
+
+func TestFormatRisk_plural(t *testing.T) {
+	ru := NewHandler(nil, &config.Config{}, nil).catalog.Localizer("ru")
+	got := formatRisk(ru, enMoney, &domain.RiskMetrics{From: "2024-01-01", To: "2024-01-22", Days: 22, LongestLosingStreak: 5})
+	require.Contains(t, got, "(22 дня)")
+	require.Contains(t, got, "Самая длинная серия убытков: 5 дней")
+}
@@ -0,0 +1,153 @@
+package telegram
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+)
+
+// numberLocale holds digit grouping and decimal separators and the currency symbol position.
+type numberLocale struct {
+	group       string
+	decimal     string
+	symbolFirst bool
+}
+
+// numberLocales are the supported NUMBER_LOCALE values; unknown values fall back to "en".
+var numberLocales = map[string]numberLocale{
+	"en": {group: ",", decimal: ".", symbolFirst: true},
+	"ru": {group: "\u00a0", decimal: ",", symbolFirst: false},
+	"uk": {group: "\u00a0", decimal: ",", symbolFirst: false},
+	"de": {group: ".", decimal: ",", symbolFirst: false},
+}
+
+// currencySymbols replaces the ticker of fiat currencies; other tickers are printed as is.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"RUB": "₽",
+	"UAH": "₴",
+}
+
+// defaultDecimals are built-in display decimals per asset, overridden by CURRENCY_DECIMALS.
+// Assets not listed use two decimals.
+var defaultDecimals = map[string]int{
+	"BTC": 8,
+	"ETH": 6,
+}
+
+// moneyFormat renders amounts with locale separators, sign and currency.
+type moneyFormat struct {
+	locale   numberLocale
+	decimals map[string]int
+}
+
+func newMoneyFormat(cfg *config.Config) moneyFormat {
+	loc, ok := numberLocales[cfg.NumberLocale]
+	if !ok {
+		loc = numberLocales["en"]
+	}
+	return moneyFormat{locale: loc, decimals: cfg.CurrencyDecimals}
+}
+
+func (h *Handler) money() moneyFormat {
+	return newMoneyFormat(h.config())
+}
+
+// moneyFor returns the money format of userID: their number format preference, else NUMBER_LOCALE.
+func (h *Handler) moneyFor(userID int64) moneyFormat {
+	f := h.money()
+	if loc, ok := numberLocales[h.preferences(userID).NumberLocale]; ok {
+		f.locale = loc
+	}
+	return f
+}
+
+func (f moneyFormat) decimalsOf(currency string) int {
+	if d, ok := f.decimals[currency]; ok {
+		return d
+	}
+	if d, ok := defaultDecimals[currency]; ok {
+		return d
+	}
+	return 2
+}
+
+// Number renders v with digit grouping and a leading minus for negative values.
+func (f moneyFormat) Number(v float64, decimals int) string {
+	s, neg := f.digits(v, decimals)
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// Amount renders v in currency, e.g. "-$1,234.56" or "1 234,56 USDT". An empty currency
+// renders the bare number.
+func (f moneyFormat) Amount(v float64, currency string) string {
+	s, neg := f.digits(v, f.decimalsOf(currency))
+	sign := ""
+	if neg {
+		sign = "-"
+	}
+	return f.withCurrency(sign, s, currency)
+}
+
+// Signed is Amount with an explicit "+" for positive values.
+func (f moneyFormat) Signed(v float64, currency string) string {
+	s, neg := f.digits(v, f.decimalsOf(currency))
+	sign := "+"
+	if neg {
+		sign = "-"
+	}
+	return f.withCurrency(sign, s, currency)
+}
+
+// Percent renders v as a signed percentage, e.g. "+3.97%" or "-1.5%".
+func (f moneyFormat) Percent(v float64, decimals int) string {
+	s, neg := f.digits(v, decimals)
+	if neg {
+		return "-" + s + "%"
+	}
+	if math.Round(v*math.Pow10(decimals)) > 0 {
+		return "+" + s + "%"
+	}
+	return s + "%"
+}
+
+func (f moneyFormat) withCurrency(sign, digits, currency string) string {
+	if currency == "" {
+		return sign + digits
+	}
+	sym, ok := currencySymbols[currency]
+	switch {
+	case ok && f.locale.symbolFirst:
+		return sign + sym + digits
+	case ok:
+		return sign + digits + " " + sym
+	default:
+		return sign + digits + " " + currency
+	}
+}
+
+// digits formats |v| rounded to decimals with locale separators; neg is false for values that
+// round to zero so "-0.00" is never printed.
+func (f moneyFormat) digits(v float64, decimals int) (s string, neg bool) {
+	raw := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	intPart, frac, _ := strings.Cut(raw, ".")
+
+	var b strings.Builder
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(f.locale.group)
+		}
+		b.WriteRune(d)
+	}
+	if frac != "" {
+		b.WriteString(f.locale.decimal + frac)
+	}
+	return b.String(), v < 0 && strings.Trim(raw, "0.") != ""
+}
@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyFormat(t *testing.T) {
+	en := newMoneyFormat(&config.Config{NumberLocale: "en", CurrencyDecimals: map[string]int{"SOL": 4}})
+	uk := newMoneyFormat(&config.Config{NumberLocale: "uk"})
+	de := newMoneyFormat(&config.Config{NumberLocale: "de"})
+	unknown := newMoneyFormat(&config.Config{NumberLocale: "xx"})
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"en grouping", en.Amount(1234567.891, ""), "1,234,567.89"},
+		{"en symbol", en.Amount(-1234.5, "USD"), "-$1,234.50"},
+		{"en ticker", en.Amount(1000, "USDT"), "1,000.00 USDT"},
+		{"en signed", en.Signed(12.345, "EUR"), "+€12.35"},
+		{"en signed negative", en.Signed(-0.5, ""), "-0.50"},
+		{"negative zero", en.Amount(-0.001, ""), "0.00"},
+		{"signed zero", en.Signed(0, ""), "+0.00"},
+		{"asset decimals", en.Amount(0.123456789, "BTC"), "0.12345679 BTC"},
+		{"configured decimals", en.Amount(1.5, "SOL"), "1.5000 SOL"},
+		{"uk", uk.Amount(-1234567.5, "UAH"), "-1\u00a0234\u00a0567,50 ₴"},
+		{"de", de.Amount(1234.5, "EUR"), "1.234,50 €"},
+		{"small", en.Amount(999.999, ""), "1,000.00"},
+		{"unknown locale", unknown.Amount(1234, ""), "1,234.00"},
+		{"number", en.Number(-1234.567, 1), "-1,234.6"},
+		{"number no decimals", en.Number(1234, 0), "1,234"},
+		{"percent", en.Percent(3.971, 2), "+3.97%"},
+		{"percent negative", uk.Percent(-1.5, 1), "-1,5%"},
+		{"percent zero", en.Percent(0.001, 2), "0.00%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.got)
+		})
+	}
+}
+
+// enMoney and enTr are the default formatter and localizer used by formatting tests.
+var (
+	enMoney = newMoneyFormat(&config.Config{NumberLocale: "en"})
+	enTr    = i18n.Default().Localizer(i18n.DefaultLocale)
+)
@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrPartialDelivery marks errors after part of a delivery succeeded: direct messages after the
+// group post, or later parts of a long message after its first. The queue message should not
+// be redelivered for them, since that would repeat what was delivered.
+var ErrPartialDelivery = errors.New("partial delivery")
+
+// subscriber is a viewer who receives a notification category by DM.
+type subscriber struct {
+	userID int64
+	quiet  bool // within quiet hours: deliver silently
+}
+
+// subscribers returns the viewers subscribed to category c, in user ID order.
+func (h *Handler) subscribers(c domain.NotificationCategory) ([]subscriber, error) {
+	if h.prefs == nil {
+		return nil, nil
+	}
+	all, err := h.prefs.AllPreferences()
+	if err != nil {
+		return nil, fmt.Errorf("notify %s: %w", c, err)
+	}
+	now := time.Now()
+	var out []subscriber
+	for _, userID := range slices.Sorted(maps.Keys(all)) {
+		p := all[userID]
+		if !p.Subscribed(c) || h.roleOf(userID) < roleViewer {
+			continue
+		}
+		out = append(out, subscriber{userID: userID, quiet: p.Quiet(now, h.defaultLocation())})
+	}
+	return out, nil
+}
+
+// dm builds the direct message of n to sub.
+func (sub subscriber) dm(n Notification) tgbotapi.MessageConfig {
+	msg := notificationMessage(sub.userID, n)
+	msg.DisableNotification = n.Silent || sub.quiet
+	return msg
+}
+
+// NotifySubscribers sends n by DM to every viewer subscribed to category c, silently during
+// their quiet hours or when n is silent. Failed DMs do not stop the others and are returned joined.
+func (h *Handler) NotifySubscribers(c domain.NotificationCategory, n Notification) error {
+	subs, err := h.subscribers(c)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sub := range subs {
+		if _, err := h.sendNotification(sub.dm(n), n); err != nil {
+			errs = append(errs, fmt.Errorf("notify %s: dm %d: %w", c, sub.userID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,298 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// portfolioPageRows is how many positions or balances a page of /positions and /balance lists.
+const portfolioPageRows = 15
+
+// Portfolio views in "pf:<view>:<account>:<page>" callbacks, where account is the index of the
+// account among the user's accounts or AllAccounts, so that any account ID fits the 64 bytes.
+const (
+	viewPositions = "p"
+	viewBalances  = "b"
+)
+
+// cmdPositions handles /positions [account|all].
+func (h *Handler) cmdPositions(ctx context.Context, msg *tgbotapi.Message, args string) {
+	h.sendPortfolio(ctx, msg, viewPositions, args)
+}
+
+// cmdBalance handles /balance [account|all].
+func (h *Handler) cmdBalance(ctx context.Context, msg *tgbotapi.Message, args string) {
+	h.sendPortfolio(ctx, msg, viewBalances, args)
+}
+
+func (h *Handler) sendPortfolio(ctx context.Context, msg *tgbotapi.Message, view, args string) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	tr := h.tr(msg.From)
+	if len(h.accountsFor(userID)) == 0 {
+		h.replyBestEffort(chatID, tr.T("portfolio.no_accounts"))
+		return
+	}
+	id, ok := h.portfolioAccount(userID, args)
+	if !ok {
+		h.replyBestEffort(chatID, tr.T("portfolio.unknown_account", "account", args))
+		return
+	}
+	text, kb, err := h.portfolioPage(ctx, tr, userID, view, id, 0)
+	if err != nil {
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+		return
+	}
+	out := tgbotapi.NewMessage(chatID, text)
+	out.ParseMode = tgbotapi.ModeHTML
+	out.ReplyMarkup = kb
+	if _, err := h.bot.Send(out); err != nil {
+		return
+	}
+}
+
+// portfolioAccount resolves the account argument of /positions and /balance: an account ID or
+// "all"; without one, the user's default account, their only account, or all of them.
+func (h *Handler) portfolioAccount(userID int64, arg string) (string, bool) {
+	accts := h.accountsFor(userID)
+	switch {
+	case len(accts) == 0:
+		return "", false
+	case strings.EqualFold(arg, "all"):
+		return domain.AllAccounts, true
+	case arg != "":
+		_, ok := h.accountFor(userID, arg)
+		return arg, ok
+	}
+	if id, ok := h.defaultAccount(userID); ok {
+		return id, true
+	}
+	if len(accts) == 1 {
+		return accts[0].ID, true
+	}
+	return domain.AllAccounts, true
+}
+
+// handlePortfolioCallback serves "pf:<view>:<account>:<page>" from the page and Refresh buttons:
+// the portfolio is fetched again and the pressed message is edited in place.
+func (h *Handler) handlePortfolioCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	tr := h.tr(q.From)
+	parts := strings.Split(data, ":")
+	if len(parts) != 4 || h.portfolioUC == nil || (parts[1] != viewPositions && parts[1] != viewBalances) {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	view := parts[1]
+	page, err := strconv.Atoi(parts[3])
+	if err != nil || page < 0 {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	id, ok := h.portfolioRefAccount(q.From.ID, parts[2])
+	if !ok {
+		h.answerCallbackBestEffort(q, tr.T("access_denied"))
+		return
+	}
+
+	text, kb, err := h.portfolioPage(ctx, tr, q.From.ID, view, id, page)
+	if err != nil {
+		h.answerCallbackBestEffort(q, tr.T("error", "err", err))
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(q.Message.Chat.ID, q.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = &kb
+	if _, err := h.bot.Send(edit); err != nil && !isNotModified(err) {
+		h.answerCallbackBestEffort(q, tr.T("error", "err", err))
+		return
+	}
+	h.answerCallbackBestEffort(q, tr.T("portfolio.refreshed"))
+}
+
+// portfolioPage fetches view of account id (or all accounts of userID) and renders page of it.
+func (h *Handler) portfolioPage(ctx context.Context, tr i18n.Localizer, userID int64, view, id string, page int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	accts := h.accountsFor(userID)
+	if id != domain.AllAccounts {
+		acct, _ := h.accountFor(userID, id)
+		accts = []domain.Account{acct}
+	}
+	f := h.moneyFor(userID)
+	multi := len(accts) > 1
+
+	var (
+		title, empty string
+		rows         []string
+		footer       []string
+	)
+	switch view {
+	case viewPositions:
+		ps, err := h.portfolioUC.Positions(ctx, accts)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		title, empty = tr.T("portfolio.positions_title"), tr.T("portfolio.no_positions")
+		rows = positionRows(f, ps, multi)
+		totals := unrealizedByCurrency(ps)
+		for _, cur := range slices.Sorted(maps.Keys(totals)) {
+			footer = append(footer, tr.T("portfolio.upnl_total", "amount", f.Signed(totals[cur], cur)))
+		}
+	default:
+		bs, err := h.portfolioUC.Balances(ctx, accts)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		title, empty = tr.T("portfolio.balances_title"), tr.T("portfolio.no_balances")
+		rows = balanceRows(f, bs, multi)
+	}
+	if len(accts) == 1 && accts[0].ID != "" {
+		title = accts[0].Label() + ": " + title
+	}
+
+	pages := max(1, (len(rows)-1+portfolioPageRows-1)/portfolioPageRows)
+	page = min(page, pages-1)
+	if pages > 1 {
+		title += fmt.Sprintf(" (%d/%d)", page+1, pages)
+	}
+	lines := []string{"<b>" + html.EscapeString(title) + "</b>"}
+	if len(rows) <= 1 {
+		lines = append(lines, html.EscapeString(empty))
+	} else {
+		body := rows[1+page*portfolioPageRows : min(len(rows), 1+(page+1)*portfolioPageRows)]
+		lines = append(lines, "<pre>"+html.EscapeString(rows[0]+"\n"+strings.Join(body, "\n"))+"</pre>")
+	}
+	for _, l := range footer {
+		lines = append(lines, html.EscapeString(l))
+	}
+	updated := time.Now().In(h.location(userID)).Format("15:04:05")
+	lines = append(lines, "<i>"+html.EscapeString(tr.T("portfolio.updated", "time", updated))+"</i>")
+	return strings.Join(lines, "\n"), portfolioKeyboard(tr, view, h.portfolioRef(userID, id), page, pages), nil
+}
+
+// portfolioRef returns how account id of userID is referenced in portfolio callbacks.
+func (h *Handler) portfolioRef(userID int64, id string) string {
+	if id == domain.AllAccounts {
+		return id
+	}
+	return strconv.Itoa(slices.IndexFunc(h.accountsFor(userID), func(a domain.Account) bool { return a.ID == id }))
+}
+
+// portfolioRefAccount resolves a reference of portfolioRef to an account ID of userID.
+func (h *Handler) portfolioRefAccount(userID int64, ref string) (string, bool) {
+	if ref == domain.AllAccounts {
+		return ref, true
+	}
+	accts := h.accountsFor(userID)
+	i, err := strconv.Atoi(ref)
+	if err != nil || i < 0 || i >= len(accts) {
+		return "", false
+	}
+	return accts[i].ID, true
+}
+
+// portfolioKeyboard returns the Refresh button between previous/next page buttons.
+func portfolioKeyboard(tr i18n.Localizer, view, ref string, page, pages int) tgbotapi.InlineKeyboardMarkup {
+	data := func(p int) string { return fmt.Sprintf("pf:%s:%s:%d", view, ref, p) }
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr.T("portfolio.btn_prev"), data(page-1)))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr.T("portfolio.btn_refresh"), data(page)))
+	if page < pages-1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr.T("portfolio.btn_next"), data(page+1)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// positionRows renders ps as an aligned table; the first row is the column header. The
+// account column is shown only with multi.
+func positionRows(f moneyFormat, ps []domain.Position, multi bool) []string {
+	cells := [][]string{{"ACCOUNT", "SYMBOL", "SIDE", "SIZE", "ENTRY", "MARK", "UPNL", "LIQ%"}}
+	for _, p := range ps {
+		liq := "—"
+		if d := p.LiquidationDistance(); !math.IsNaN(d) {
+			liq = f.Number(d*100, 1)
+		}
+		cells = append(cells, []string{p.Account, p.Symbol, strings.ToUpper(string(p.Side)), quantity(f, p.Size),
+			price(f, p.EntryPrice), price(f, p.MarkPrice), f.Signed(p.UnrealizedPnL, ""), liq})
+	}
+	return alignRows(cells, multi, 3)
+}
+
+// balanceRows renders bs as an aligned table; the first row is the column header. The account
+// column is shown only with multi.
+func balanceRows(f moneyFormat, bs []domain.Balance, multi bool) []string {
+	cells := [][]string{{"ACCOUNT", "ASSET", "TOTAL", "AVAILABLE"}}
+	for _, b := range bs {
+		cells = append(cells, []string{b.Account, b.Asset, quantity(f, b.Total), quantity(f, b.Available)})
+	}
+	return alignRows(cells, multi, 2)
+}
+
+// alignRows pads cells into columns; the first column is dropped unless withFirst, and columns
+// from numeric on are right-aligned.
+func alignRows(cells [][]string, withFirst bool, numeric int) []string {
+	if !withFirst {
+		for i := range cells {
+			cells[i] = cells[i][1:]
+		}
+		numeric--
+	}
+	widths := make([]int, len(cells[0]))
+	for _, row := range cells {
+		for i, c := range row {
+			widths[i] = max(widths[i], len([]rune(c)))
+		}
+	}
+	out := make([]string, 0, len(cells))
+	for _, row := range cells {
+		parts := make([]string, len(row))
+		for i, c := range row {
+			if i >= numeric {
+				parts[i] = fmt.Sprintf("%*s", widths[i], c)
+			} else {
+				parts[i] = fmt.Sprintf("%-*s", widths[i], c)
+			}
+		}
+		out = append(out, strings.TrimRight(strings.Join(parts, " "), " "))
+	}
+	return out
+}
+
+// unrealizedByCurrency sums the unrealized PnL of ps per currency.
+func unrealizedByCurrency(ps []domain.Position) map[string]float64 {
+	out := map[string]float64{}
+	for _, p := range ps {
+		out[p.Currency] += p.UnrealizedPnL
+	}
+	return out
+}
+
+// price renders a price with more decimals the smaller it is.
+func price(f moneyFormat, v float64) string {
+	switch a := math.Abs(v); {
+	case a >= 100:
+		return f.Number(v, 2)
+	case a >= 1:
+		return f.Number(v, 4)
+	default:
+		return f.Number(v, 6)
+	}
+}
+
+// quantity renders a size or asset amount with the decimals it has, up to 8.
+func quantity(f moneyFormat, v float64) string {
+	s := strconv.FormatFloat(v, 'f', 8, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	_, frac, _ := strings.Cut(s, ".")
+	return f.Number(v, len(frac))
+}
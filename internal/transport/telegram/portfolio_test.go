@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPortfolio struct {
+	positions map[string][]ports.PositionResult
+	balances  map[string][]ports.BalanceResult
+}
+
+func (s *stubPortfolio) FetchPositions(_ context.Context, account string) ([]ports.PositionResult, error) {
+	return s.positions[account], nil
+}
+
+func (s *stubPortfolio) FetchBalances(_ context.Context, account string) ([]ports.BalanceResult, error) {
+	return s.balances[account], nil
+}
+
+func TestPositionRows(t *testing.T) {
+	ps := []domain.Position{
+		{Account: "main", Symbol: "BTCUSDT", Side: domain.SideLong, Size: 0.5, EntryPrice: 60000, MarkPrice: 61000, UnrealizedPnL: 500, LiquidationPrice: 48800},
+		{Account: "alt", Symbol: "DOGEUSDT", Side: domain.SideShort, Size: 1200, EntryPrice: 0.125, MarkPrice: 0.12, UnrealizedPnL: 6},
+	}
+	require.Equal(t, []string{
+		"SYMBOL   SIDE   SIZE     ENTRY      MARK    UPNL LIQ%",
+		"BTCUSDT  LONG    0.5 60,000.00 61,000.00 +500.00 20.0",
+		"DOGEUSDT SHORT 1,200  0.125000  0.120000   +6.00    —",
+	}, positionRows(enMoney, ps, false))
+	require.Equal(t, "ACCOUNT SYMBOL   SIDE   SIZE     ENTRY      MARK    UPNL LIQ%", positionRows(enMoney, ps, true)[0])
+
+	bs := []domain.Balance{{Account: "main", Asset: "USDT", Total: 1234.5, Available: 1000}}
+	require.Equal(t, []string{
+		"ACCOUNT ASSET   TOTAL AVAILABLE",
+		"main    USDT  1,234.5     1,000",
+	}, balanceRows(enMoney, bs, true))
+}
+
+func TestHandler_portfolio(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	src := &stubPortfolio{
+		positions: map[string][]ports.PositionResult{
+			"main": {{Symbol: "BTCUSDT", Side: "long", Size: 1, EntryPrice: 100, MarkPrice: 110, UnrealizedPnL: 10}},
+			"alt":  {{Symbol: "ETHUSDC", Side: "short", Size: 2, EntryPrice: 50, MarkPrice: 55, UnrealizedPnL: -10}},
+		},
+		balances: map[string][]ports.BalanceResult{},
+	}
+	for i := range 20 {
+		src.balances["main"] = append(src.balances["main"], ports.BalanceResult{Asset: fmt.Sprintf("A%02d", i), Total: 1})
+	}
+	cfg := &config.Config{
+		UserIDs:  []int64{7},
+		Accounts: []domain.Account{{ID: "main", Quote: "USDT"}, {ID: "alt", Quote: "USDC"}},
+	}
+	h := NewHandler(bot, cfg, nil, WithPortfolio(usecase.NewPortfolioUsecase(src)))
+	ctx := context.Background()
+
+	// Without an argument a user with several accounts sees all of them.
+	h.handleMessage(ctx, commandMessage(7, "/positions"))
+	sent := fake.Calls("sendMessage")
+	require.Len(t, sent, 1)
+	text := sent[0].params.Get("text")
+	require.Equal(t, "HTML", sent[0].params.Get("parse_mode"))
+	require.Contains(t, text, "<b>Open positions</b>\n<pre>ACCOUNT SYMBOL")
+	require.Contains(t, text, "alt     ETHUSDC")
+	require.Contains(t, text, "Unrealized PnL: -10.00 USDC\nUnrealized PnL: +10.00 USDT\n<i>Updated ")
+	require.Equal(t, []string{"pf:p:*:0"}, keyboardData(t, sent[0].params.Get("reply_markup")))
+
+	h.handleMessage(ctx, commandMessage(7, "/balance main"))
+	sent = fake.Calls("sendMessage")
+	require.Len(t, sent, 2)
+	require.Contains(t, sent[1].params.Get("text"), "<b>main: Balances (1/2)</b>")
+	require.Equal(t, []string{"pf:b:0:0", "pf:b:0:1"}, keyboardData(t, sent[1].params.Get("reply_markup")))
+
+	// Page buttons fetch again and edit the message in place.
+	h.handleCallback(ctx, signalCallback(7, 50, "pf:b:0:1"))
+	edits := fake.Calls("editMessageText")
+	require.Len(t, edits, 1)
+	require.Equal(t, "50", edits[0].params.Get("message_id"))
+	require.Contains(t, edits[0].params.Get("text"), "(2/2)")
+	require.Equal(t, 5, strings.Count(edits[0].params.Get("text"), "\nA"))
+	require.Equal(t, []string{"pf:b:0:0", "pf:b:0:1"}, keyboardData(t, edits[0].params.Get("reply_markup")))
+	require.Equal(t, "Refreshed", fake.Calls("answerCallbackQuery")[0].params.Get("text"))
+
+	h.handleMessage(ctx, commandMessage(7, "/positions nope"))
+	require.Equal(t, "Unknown account: nope", fake.Calls("sendMessage")[2].params.Get("text"))
+
+	src.positions["main"] = nil
+	h.handleCallback(ctx, signalCallback(7, 51, "pf:p:0:3"))
+	require.Contains(t, fake.Calls("editMessageText")[1].params.Get("text"), "<b>main: Open positions</b>\nNo open positions\n<i>Updated ")
+	h.handleCallback(ctx, signalCallback(7, 51, "pf:p:2:0"))
+	require.Equal(t, "Access denied", fake.Calls("answerCallbackQuery")[2].params.Get("text"))
+
+	// Account IDs of any length or with colons are referenced by index.
+	long := strings.Repeat("x", 60) + ":sub"
+	src.balances[long] = []ports.BalanceResult{{Asset: "BTC", Total: 1}}
+	h.UpdateConfig(&config.Config{UserIDs: []int64{7}, Accounts: []domain.Account{{ID: "main"}, {ID: long}}})
+	h.handleMessage(ctx, commandMessage(7, "/balance "+long))
+	require.Equal(t, []string{"pf:b:1:0"}, keyboardData(t, fake.Calls("sendMessage")[3].params.Get("reply_markup")))
+	h.handleCallback(ctx, signalCallback(7, 52, "pf:b:1:0"))
+	require.Contains(t, fake.Calls("editMessageText")[2].params.Get("text"), "BTC")
+
+	// Users whose role sees no account are told so.
+	h.UpdateConfig(&config.Config{UserIDs: []int64{7}, Accounts: []domain.Account{{ID: "main"}},
+		AccountRoles: map[string][]string{"admin": nil}})
+	h.handleMessage(ctx, commandMessage(7, "/positions"))
+	require.Equal(t, "No accounts are available to you", fake.Calls("sendMessage")[4].params.Get("text"))
+}
@@ -0,0 +1,241 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram/format"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SendReports posts formatted reports to chatID under the catalog message titleKey in bold,
+// converted to DISPLAY_CURRENCY when configured.
+func (h *Handler) SendReports(ctx context.Context, chatID int64, titleKey string, reps []domain.Report) error {
+	tr := h.groupTr()
+	reps, note := h.displayReports(ctx, tr, h.config().DisplayCurrency, reps)
+	var t format.Text
+	t.Bold(tr.T(titleKey)).Line().Plain(withNote(formatReports(tr, h.money(), reps), note))
+	m := t.Render(format.ModeEntities)
+	return h.SendNotification(chatID, Notification{Text: m.Text, Entities: m.Entities})
+}
+
+// displayCurrency returns the currency reports for userID are converted to: their /settings
+// choice, else DISPLAY_CURRENCY; empty for the native currencies.
+func (h *Handler) displayCurrency(userID int64) string {
+	switch cur := h.preferences(userID).DisplayCurrency; cur {
+	case "":
+		return h.config().DisplayCurrency
+	case domain.NativeCurrencies:
+		return ""
+	default:
+		return cur
+	}
+}
+
+// displayReports converts reps to cur. On a rate failure the error is logged and reps are
+// returned in their native currencies with a note, localized with tr, saying so.
+func (h *Handler) displayReports(ctx context.Context, tr i18n.Localizer, cur string, reps []domain.Report) ([]domain.Report, string) {
+	if cur == "" || h.conversionUC == nil {
+		return reps, ""
+	}
+	out, err := h.conversionUC.Convert(ctx, reps, cur)
+	if err != nil {
+		h.logError("failed to convert reports", "currency", cur, "error", err)
+		return reps, tr.T("report.not_converted", "currency", cur)
+	}
+	return out, ""
+}
+
+// withNote appends note on a line of its own when there is one.
+func withNote(text, note string) string {
+	if note == "" {
+		return text
+	}
+	return text + "\n" + note
+}
+
+// SendPhoto uploads a PNG image with an optional caption to chatID.
+func (h *Handler) SendPhoto(chatID int64, png []byte, caption string) error {
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: png})
+	photo.Caption = caption
+	if _, err := h.bot.Send(photo); err != nil {
+		return fmt.Errorf("telegram send photo: %w", err)
+	}
+	return nil
+}
+
+func formatReport(tr i18n.Localizer, f moneyFormat, rep *domain.Report) string {
+	var prefix string
+	switch rep.Account {
+	case "":
+	case domain.AllAccounts:
+		prefix = tr.T("accounts.all") + ": "
+	default:
+		prefix = rep.Account + ": "
+	}
+	return prefix + tr.T("report.line", "from", rep.From, "to", rep.To, "zone", zoneSuffix(rep.TimeZone),
+		"income", f.Amount(rep.Income, rep.Currency), "expense", f.Amount(rep.Expense, rep.Currency))
+}
+
+// formatReports renders one line per report, e.g. per-currency aggregates.
+func formatReports(tr i18n.Localizer, f moneyFormat, reps []domain.Report) string {
+	if len(reps) == 0 {
+		return tr.T("report.none")
+	}
+	lines := make([]string, 0, len(reps))
+	for i := range reps {
+		lines = append(lines, formatReport(tr, f, &reps[i]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// zoneSuffix renders a non-UTC time zone after a date range, e.g. " (Europe/Kyiv)".
+func zoneSuffix(zone string) string {
+	if zone == "" {
+		return ""
+	}
+	return " (" + zone + ")"
+}
+
+// reportArgs parses "<prefix>:<account>:<from>:<to>" callback data and checks account access.
+func (h *Handler) reportArgs(userID int64, parts []string) (acct domain.Account, from, to string, ok bool) {
+	if len(parts) != 3 {
+		return domain.Account{}, "", "", false
+	}
+	acct, ok = h.accountFor(userID, parts[0])
+	return acct, parts[1], parts[2], ok
+}
+
+// reportKeyboard holds follow-up actions for a report reply.
+func (h *Handler) reportKeyboard(tr i18n.Localizer, rep *domain.Report) tgbotapi.InlineKeyboardMarkup {
+	args := fmt.Sprintf("%s:%s:%s", rep.Account, rep.From, rep.To)
+	row := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("report.btn_chart"), "chart:"+args),
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("report.btn_compare"), "cmp:"+args),
+	)
+	if h.exportUC != nil {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(tr.T("report.btn_export"), "export:"+args))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("report.btn_breakdown"), "bd:"+args),
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("report.btn_risk"), "risk:"+args),
+	))
+}
+
+func (h *Handler) sendReportBestEffort(ctx context.Context, chatID int64, u *tgbotapi.User, rep *domain.Report) {
+	tr := h.tr(u)
+	shown, note := h.displayReports(ctx, tr, h.displayCurrency(u.ID), []domain.Report{*rep})
+	msg := tgbotapi.NewMessage(chatID, withNote(formatReport(tr, h.moneyFor(u.ID), &shown[0]), note))
+	msg.ReplyMarkup = h.reportKeyboard(tr, rep)
+	if _, err := h.bot.Send(msg); err != nil {
+		return
+	}
+}
+
+func (h *Handler) handleChartCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	chatID := q.Message.Chat.ID
+	tr := h.tr(q.From)
+	acct, from, to, ok := h.reportArgs(q.From.ID, strings.Split(data, ":")[1:])
+	if !ok {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	h.answerCallbackBestEffort(q, tr.T("chart.rendering"))
+
+	p := h.period(q.From.ID, from, to)
+	img, err := h.reportUC.GetPnLChart(ctx, acct, p)
+	if errors.Is(err, usecase.ErrNoData) {
+		h.replyBestEffort(chatID, tr.T("no_pnl_data", "from", from, "to", to))
+		return
+	}
+	if err != nil {
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+		return
+	}
+	if err := h.SendPhoto(chatID, img, tr.T("chart.caption", "from", from, "to", to, "zone", zoneSuffix(p.Zone()))); err != nil {
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+	}
+}
+
+func (h *Handler) handleCompareCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	chatID := q.Message.Chat.ID
+	tr := h.tr(q.From)
+	parts := strings.Split(data, ":")
+
+	switch len(parts) {
+	case 4:
+		acct, from, to, ok := h.reportArgs(q.From.ID, parts[1:])
+		if !ok {
+			h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+			return
+		}
+		h.answerCallbackBestEffort(q, "")
+		args := fmt.Sprintf("%s:%s:%s", acct.ID, from, to)
+		msg := tgbotapi.NewMessage(chatID, tr.T("compare.pick", "from", from, "to", to))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("compare.btn_previous"), fmt.Sprintf("cmp:%s:%s", domain.ComparePrevious, args)),
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("compare.btn_year_ago"), fmt.Sprintf("cmp:%s:%s", domain.CompareYearAgo, args)),
+		))
+		if _, err := h.bot.Send(msg); err != nil {
+			return
+		}
+	case 5:
+		acct, from, to, ok := h.reportArgs(q.From.ID, parts[2:])
+		if !ok {
+			h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+			return
+		}
+		h.answerCallbackBestEffort(q, tr.T("compare.running"))
+		cmp, err := h.reportUC.Compare(ctx, acct, h.period(q.From.ID, from, to), domain.ComparisonMode(parts[1]))
+		if err != nil {
+			h.replyBestEffort(chatID, tr.T("error", "err", err))
+			return
+		}
+		h.replyBestEffort(chatID, formatComparison(tr, h.moneyFor(q.From.ID), cmp))
+	default:
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+	}
+}
+
+func formatComparison(tr i18n.Localizer, f moneyFormat, c *domain.ReportComparison) string {
+	var b strings.Builder
+	if c.Current.Account != "" {
+		b.WriteString(c.Current.Account + ": ")
+	}
+	cur := c.Current.Currency
+	line := func(key string, current, previous float64) string {
+		return tr.T(key, "current", f.Amount(current, cur), "previous", f.Amount(previous, cur), "delta", formatDelta(tr, f, current, previous, cur))
+	}
+	b.WriteString(tr.T("compare.title", "from", c.Current.From, "to", c.Current.To, "prev_from", c.Previous.From, "prev_to", c.Previous.To,
+		"zone", zoneSuffix(c.Current.TimeZone)) + "\n")
+	b.WriteString(line("compare.income", c.Current.Income, c.Previous.Income) + "\n")
+	b.WriteString(line("compare.expense", c.Current.Expense, c.Previous.Expense) + "\n")
+	b.WriteString(line("compare.net", c.Current.Net(), c.Previous.Net()))
+	return b.String()
+}
+
+// formatDelta renders the change from prev to cur with a direction marker, absolute and relative delta.
+func formatDelta(tr i18n.Localizer, f moneyFormat, cur, prev float64, currency string) string {
+	d := domain.Sum(cur, -prev)
+	marker := "▬"
+	switch {
+	case d > 0:
+		marker = "▲"
+	case d < 0:
+		marker = "▼"
+	}
+	if prev == 0 {
+		return fmt.Sprintf("%s %s (%s)", marker, f.Signed(d, currency), tr.T("not_available"))
+	}
+	pct := f.Number(d/math.Abs(prev)*100, 1)
+	if d >= 0 {
+		pct = "+" + pct
+	}
+	return fmt.Sprintf("%s %s (%s%%)", marker, f.Signed(d, currency), pct)
+}
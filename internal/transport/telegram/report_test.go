@@ -0,0 +1,201 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type stubReports struct {
+	points    []ports.PnLPoint
+	breakdown ports.BreakdownResult
+	accounts  []string
+	mu        sync.Mutex
+	froms     []time.Time
+}
+
+func (s *stubReports) FetchReport(_ context.Context, _ string, from, _ time.Time) (*ports.ReportResult, error) {
+	s.mu.Lock()
+	s.froms = append(s.froms, from)
+	s.mu.Unlock()
+	return &ports.ReportResult{Income: 10, Expense: 4}, nil
+}
+
+func (s *stubReports) FetchDailyPnL(_ context.Context, account string, _, _ time.Time) ([]ports.PnLPoint, error) {
+	s.accounts = append(s.accounts, account)
+	return s.points, nil
+}
+
+func (s *stubReports) FetchBreakdown(_ context.Context, _ string, _, _ time.Time) (*ports.BreakdownResult, error) {
+	return &s.breakdown, nil
+}
+
+type stubRenderer struct{}
+
+func (stubRenderer) RenderPnL([]domain.DailyPnL) ([]byte, error) { return []byte("\x89PNG"), nil }
+
+func callback(userID, chatID int64, data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:      "cb",
+		From:    &tgbotapi.User{ID: userID},
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}},
+		Data:    data,
+	}
+}
+
+func TestHandler_chartCallback(t *testing.T) {
+	t.Run("sends photo", func(t *testing.T) {
+		bot, fake := newFakeBot(t)
+		src := &stubReports{points: []ports.PnLPoint{{Date: "2024-01-01", PnL: 1}}}
+		h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, usecase.NewReportUsecase(src, src, src, stubRenderer{}))
+
+		h.handleCallback(context.Background(), callback(7, 7, "chart::2024-01-01:2024-01-31"))
+
+		photos := fake.Calls("sendPhoto")
+		require.Len(t, photos, 1)
+		require.Equal(t, "7", photos[0].params.Get("chat_id"))
+		require.Contains(t, photos[0].params.Get("caption"), "2024-01-01")
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		bot, fake := newFakeBot(t)
+		src := &stubReports{}
+		h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, usecase.NewReportUsecase(src, src, src, stubRenderer{}))
+
+		h.handleCallback(context.Background(), callback(7, 7, "chart::2024-01-01:2024-01-31"))
+
+		require.Empty(t, fake.Calls("sendPhoto"))
+		msgs := fake.Calls("sendMessage")
+		require.Len(t, msgs, 1)
+		require.Contains(t, msgs[0].params.Get("text"), "No PnL data")
+	})
+
+	t.Run("account access", func(t *testing.T) {
+		bot, fake := newFakeBot(t)
+		src := &stubReports{points: []ports.PnLPoint{{Date: "2024-01-01", PnL: 1}}}
+		cfg := &config.Config{
+			UserIDs:      []int64{7},
+			ViewerIDs:    []int64{8},
+			Accounts:     []domain.Account{{ID: "main"}, {ID: "alt"}},
+			AccountRoles: map[string][]string{"viewer": {"main"}},
+		}
+		h := NewHandler(bot, cfg, usecase.NewReportUsecase(src, src, src, stubRenderer{}))
+
+		h.handleCallback(context.Background(), callback(8, 8, "chart:alt:2024-01-01:2024-01-31"))
+		require.Empty(t, fake.Calls("sendPhoto"))
+
+		h.handleCallback(context.Background(), callback(8, 8, "chart:main:2024-01-01:2024-01-31"))
+		h.handleCallback(context.Background(), callback(7, 7, "chart:alt:2024-01-01:2024-01-31"))
+		require.Len(t, fake.Calls("sendPhoto"), 2)
+		require.Equal(t, []string{"main", "alt"}, src.accounts)
+	})
+}
+
+func TestHandler_reportKeyboard(t *testing.T) {
+	rep := &domain.Report{Account: "main", From: "2024-01-01", To: "2024-01-31"}
+
+	kb := NewHandler(nil, &config.Config{}, nil).reportKeyboard(enTr, rep)
+	require.Len(t, kb.InlineKeyboard[0], 2)
+	require.Equal(t, "chart:main:2024-01-01:2024-01-31", *kb.InlineKeyboard[0][0].CallbackData)
+	require.Equal(t, "cmp:main:2024-01-01:2024-01-31", *kb.InlineKeyboard[0][1].CallbackData)
+	require.Equal(t, "bd:main:2024-01-01:2024-01-31", *kb.InlineKeyboard[1][0].CallbackData)
+	require.Equal(t, "risk:main:2024-01-01:2024-01-31", *kb.InlineKeyboard[1][1].CallbackData)
+
+	kb = NewHandler(nil, &config.Config{}, nil, WithExport(&usecase.ExportUsecase{})).reportKeyboard(enTr, rep)
+	require.Equal(t, "export:main:2024-01-01:2024-01-31", *kb.InlineKeyboard[0][2].CallbackData)
+}
+
+func TestFormatReports(t *testing.T) {
+	got := formatReports(enTr, enMoney, []domain.Report{
+		{From: "2024-01-01", To: "2024-01-31", Income: 10, Expense: 4},
+		{Account: "main", Currency: "USDT", From: "2024-01-01", To: "2024-01-31", Income: 10, Expense: 4},
+		{Account: domain.AllAccounts, Currency: "BTC", From: "2024-01-01", To: "2024-01-31", Income: 1, Expense: 0.5},
+	})
+	require.Equal(t, "Report from 2024-01-01 to 2024-01-31. Income: 10.00, Expense: 4.00\n"+
+		"main: Report from 2024-01-01 to 2024-01-31. Income: 10.00 USDT, Expense: 4.00 USDT\n"+
+		"All accounts: Report from 2024-01-01 to 2024-01-31. Income: 1.00000000 BTC, Expense: 0.50000000 BTC", got)
+}
+
+func TestFormatComparison(t *testing.T) {
+	got := formatComparison(enTr, enMoney, &domain.ReportComparison{
+		Mode:     domain.ComparePrevious,
+		Current:  domain.Report{From: "2024-02-01", To: "2024-02-29", Income: 120, Expense: 40},
+		Previous: domain.Report{From: "2024-01-03", To: "2024-01-31", Income: 100, Expense: 40},
+	})
+	require.Equal(t, "Report 2024-02-01 — 2024-02-29 vs 2024-01-03 — 2024-01-31\n"+
+		"Income: 120.00 vs 100.00 ▲ +20.00 (+20.0%)\n"+
+		"Expense: 40.00 vs 40.00 ▬ +0.00 (+0.0%)\n"+
+		"Net: 80.00 vs 60.00 ▲ +20.00 (+33.3%)", got)
+
+	require.Equal(t, "▼ -5.00 (-50.0%)", formatDelta(enTr, enMoney, -15, -10, ""))
+	require.Equal(t, "▲ +3.00 (n/a)", formatDelta(enTr, enMoney, 3, 0, ""))
+}
+
+type stubRates map[string]float64
+
+func (s stubRates) FetchRate(_ context.Context, base, quote string) (float64, error) {
+	r, ok := s[base+"/"+quote]
+	if !ok {
+		return 0, errors.New("no rate")
+	}
+	return r, nil
+}
+
+func TestHandler_SendReports_displayCurrency(t *testing.T) {
+	reps := []domain.Report{
+		{Account: domain.AllAccounts, Currency: "USDT", From: "2024-01-01", To: "2024-01-31", Income: 1000, Expense: 100},
+		{Account: domain.AllAccounts, Currency: "BTC", From: "2024-01-01", To: "2024-01-31", Income: 0.1},
+	}
+
+	bot, fake := newFakeBot(t)
+	cfg := &config.Config{DisplayCurrency: "USD"}
+	h := NewHandler(bot, cfg, nil, WithConversion(usecase.NewConversionUsecase(stubRates{"USDT/USD": 1, "BTC/USD": 60000})))
+	require.NoError(t, h.SendReports(context.Background(), -1, "digest.daily", reps))
+	require.Equal(t, "Daily PnL\nAll accounts: Report from 2024-01-01 to 2024-01-31. Income: $7,000.00, Expense: $100.00",
+		fake.Calls("sendMessage")[0].params.Get("text"))
+	require.JSONEq(t, `[{"type":"bold","offset":0,"length":9}]`, fake.Calls("sendMessage")[0].params.Get("entities"))
+
+	// Without a rate the native currencies are shown with a note.
+	h = NewHandler(bot, cfg, nil, WithConversion(usecase.NewConversionUsecase(stubRates{})))
+	require.NoError(t, h.SendReports(context.Background(), -1, "digest.daily", reps))
+	text := fake.Calls("sendMessage")[1].params.Get("text")
+	require.Contains(t, text, "1,000.00 USDT")
+	require.True(t, strings.HasSuffix(text, "\n⚠️ No USD rate available, amounts are in their original currencies"), text)
+}
+
+func TestHandler_displayCurrency(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	prefs := memPreferences{}
+	cfg := &config.Config{UserIDs: []int64{7}, DisplayCurrency: "USD"}
+	h := NewHandler(bot, cfg, nil, WithPreferences(prefs),
+		WithConversion(usecase.NewConversionUsecase(stubRates{"USDT/USD": 1, "USDT/EUR": 0.5})))
+	require.Equal(t, "USD", h.displayCurrency(7))
+
+	h.handleCallback(context.Background(), callback(7, 7, "set:cur"))
+	require.Equal(t, []string{"set:cur:-", "set:cur:native", "set:cur:USD", "set:cur:USDT", "set:cur:EUR", "set:cur:BTC", "set:menu"},
+		keyboardData(t, fake.Calls("editMessageText")[0].params.Get("reply_markup")))
+	h.handleCallback(context.Background(), callback(7, 7, "set:cur:EUR"))
+	require.Equal(t, "EUR", h.displayCurrency(7))
+	require.Contains(t, fake.Calls("editMessageText")[1].params.Get("text"), "Display currency: EUR")
+
+	rep := domain.Report{Currency: "USDT", From: "2024-01-01", To: "2024-01-31", Income: 10}
+	h.sendReportBestEffort(context.Background(), 7, &tgbotapi.User{ID: 7}, &rep)
+	require.Contains(t, fake.Calls("sendMessage")[0].params.Get("text"), "Income: €5.00")
+
+	h.handleMessage(context.Background(), commandMessage(7, "/settings currency native"))
+	require.Empty(t, h.displayCurrency(7))
+	h.handleMessage(context.Background(), commandMessage(7, "/settings currency $$"))
+	require.Contains(t, fake.Calls("sendMessage")[2].params.Get("text"), "Unknown currency $$")
+	h.handleMessage(context.Background(), commandMessage(7, "/settings currency -"))
+	require.Equal(t, "USD", h.displayCurrency(7))
+}
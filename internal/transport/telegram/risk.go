@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (h *Handler) handleRiskCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	chatID := q.Message.Chat.ID
+	tr := h.tr(q.From)
+	acct, from, to, ok := h.reportArgs(q.From.ID, strings.Split(data, ":")[1:])
+	if !ok {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	h.answerCallbackBestEffort(q, "")
+
+	m, err := h.reportUC.GetRisk(ctx, acct, h.period(q.From.ID, from, to))
+	if errors.Is(err, usecase.ErrNoData) {
+		h.replyBestEffort(chatID, tr.T("no_pnl_data", "from", from, "to", to))
+		return
+	}
+	if err != nil {
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+		return
+	}
+	h.replyBestEffort(chatID, formatRisk(tr, h.moneyFor(q.From.ID), m))
+}
+
+func formatRisk(tr i18n.Localizer, f moneyFormat, m *domain.RiskMetrics) string {
+	var b strings.Builder
+	if m.Account != "" {
+		b.WriteString(m.Account + ": ")
+	}
+	lines := []string{
+		tr.T("risk.title", "from", m.From, "to", m.To, "zone", zoneSuffix(m.TimeZone), "days", tr.N("days", m.Days)),
+		tr.T("risk.max_drawdown", "amount", f.Amount(m.MaxDrawdown, m.Currency), "pct", formatRatio(tr, m.MaxDrawdownPct, "%.1f%%")),
+		tr.T("risk.sharpe", "value", formatRatio(tr, m.Sharpe, "%.2f")),
+		tr.T("risk.sortino", "value", formatRatio(tr, m.Sortino, "%.2f")),
+		tr.T("risk.profit_factor", "value", formatRatio(tr, m.ProfitFactor, "%.2f")),
+		tr.T("risk.average", "win", f.Amount(m.AvgWin, m.Currency), "loss", f.Amount(m.AvgLoss, m.Currency)),
+		tr.T("risk.losing_streak", "days", tr.N("days", m.LongestLosingStreak)),
+	}
+	b.WriteString(strings.Join(lines, "\n"))
+	return b.String()
+}
+
+// formatRatio renders v with format, or "n/a" / "∞" for undefined and unbounded ratios.
+func formatRatio(tr i18n.Localizer, v float64, format string) string {
+	switch {
+	case math.IsNaN(v):
+		return tr.T("not_available")
+	case math.IsInf(v, 1):
+		return "∞"
+	case math.IsInf(v, -1):
+		return "-∞"
+	}
+	return fmt.Sprintf(format, v)
+}
@@ -0,0 +1,41 @@
+package telegram
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatRisk(t *testing.T) {
+	got := formatRisk(enTr, enMoney, &domain.RiskMetrics{
+		Account: "main", From: "2024-01-01", To: "2024-01-31", Days: 31,
+		MaxDrawdown: 12.5, MaxDrawdownPct: math.NaN(),
+		Sharpe: 1.234, Sortino: math.NaN(), ProfitFactor: math.Inf(1),
+		AvgWin: 4, AvgLoss: -2.5, LongestLosingStreak: 3,
+	})
+	require.Equal(t, "main: Risk 2024-01-01 — 2024-01-31 (31 days)\n"+
+		"Max drawdown: 12.50 (n/a)\n"+
+		"Sharpe: 1.23\n"+
+		"Sortino: n/a\n"+
+		"Profit factor: ∞\n"+
+		"Avg win: 4.00, avg loss: -2.50\n"+
+		"Longest losing streak: 3 days", got)
+}
+
+func TestHandler_riskCallback(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	src := &stubReports{points: []ports.PnLPoint{{Date: "2024-01-01", PnL: 10}, {Date: "2024-01-02", PnL: -4}}}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, usecase.NewReportUsecase(src, src, src, stubRenderer{}))
+
+	h.handleCallback(context.Background(), callback(7, 7, "risk::2024-01-01:2024-01-02"))
+
+	msgs := fake.Calls("sendMessage")
+	require.Len(t, msgs, 1)
+	require.Contains(t, msgs[0].params.Get("text"), "Max drawdown: 4.00 (40.0%)")
+}
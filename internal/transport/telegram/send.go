@@ -0,0 +1,164 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram/format"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// documentName is the file name of long messages sent as a document.
+const documentName = "message.txt"
+
+// isParseError reports whether Telegram rejected a message because its markup did not parse.
+func isParseError(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Message, "can't parse entities")
+}
+
+// isNotModified reports whether Telegram refused an edit that would not change the message.
+func isNotModified(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Message, "message is not modified")
+}
+
+// chatLock is the send lock of one chat, shared by refs senders.
+type chatLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockChat serializes multi-part sends to one chat so parts of different messages do not
+// interleave. The returned func unlocks; locks nobody holds or waits for are dropped.
+func (h *Handler) lockChat(chatID int64) (unlock func()) {
+	h.chatLocksMu.Lock()
+	if h.chatLocks == nil {
+		h.chatLocks = make(map[int64]*chatLock)
+	}
+	l, ok := h.chatLocks[chatID]
+	if !ok {
+		l = &chatLock{}
+		h.chatLocks[chatID] = l
+	}
+	l.refs++
+	h.chatLocksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		h.chatLocksMu.Lock()
+		defer h.chatLocksMu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(h.chatLocks, chatID)
+		}
+	}
+}
+
+// sendFormatted sends msg, split into numbered parts when it exceeds Telegram's length limit,
+// or as a .txt document when it needs more than LONG_MESSAGE_MAX_PARTS parts. Parts are sent
+// in order and the first failure stops the rest; once the first part is sent, that failure wraps
+// ErrPartialDelivery, as sending msg again would repeat the parts delivered. Only the first part
+// replies to a message and carries the keyboard. It returns the ID of the first message sent,
+// also after a partial delivery. The markup of msg must be
+// built by the bot: parts Telegram cannot parse are resent with it stripped.
+func (h *Handler) sendFormatted(msg tgbotapi.MessageConfig) (int, error) {
+	return h.sendSplit(msg, format.Strip)
+}
+
+// sendNotification sends msg built from n like sendFormatted; raw notifications that do not
+// parse are resent verbatim, since stripping would also drop characters like "_" and "*" that
+// were never markup.
+func (h *Handler) sendNotification(msg tgbotapi.MessageConfig, n Notification) (int, error) {
+	if n.Raw {
+		return h.sendSplit(msg, func(text, _ string) string { return text })
+	}
+	return h.sendFormatted(msg)
+}
+
+// sendSplit implements sendFormatted; plain turns the text of a part Telegram cannot parse
+// into plain text.
+func (h *Handler) sendSplit(msg tgbotapi.MessageConfig, plain func(text, parseMode string) string) (int, error) {
+	parts := format.Split(format.Message{Text: msg.Text, ParseMode: msg.ParseMode, Entities: msg.Entities}, format.MaxLength)
+
+	defer h.lockChat(msg.ChatID)()
+
+	if maxParts := h.config().LongMessageMaxParts; maxParts > 0 && len(parts) > maxParts {
+		return h.sendAsDocument(msg, plain)
+	}
+	first := 0
+	for i, p := range parts {
+		part := msg
+		part.Text, part.ParseMode, part.Entities = p.Text, p.ParseMode, p.Entities
+		if i > 0 {
+			part.ReplyToMessageID, part.ReplyMarkup = 0, nil
+		}
+		sent, err := h.sendPart(part, plain)
+		switch {
+		case err == nil:
+		case len(parts) == 1:
+			return 0, err
+		case i == 0:
+			return 0, fmt.Errorf("part 1/%d: %w", len(parts), err)
+		default:
+			return first, fmt.Errorf("%w: part %d/%d: %w", ErrPartialDelivery, i+1, len(parts), err)
+		}
+		if i == 0 {
+			first = sent.MessageID
+		}
+	}
+	return first, nil
+}
+
+// sendPart sends one message; if Telegram cannot parse its markup, it is resent as plain text
+// made by plain so the content is not lost.
+func (h *Handler) sendPart(msg tgbotapi.MessageConfig, plain func(text, parseMode string) string) (tgbotapi.Message, error) {
+	sent, err := h.bot.Send(msg)
+	if err == nil || msg.ParseMode == "" || !isParseError(err) {
+		return sent, err
+	}
+	msg.Text = plain(msg.Text, msg.ParseMode)
+	msg.ParseMode = ""
+	if sent, err = h.bot.Send(msg); err != nil {
+		return sent, fmt.Errorf("plain text fallback: %w", err)
+	}
+	return sent, nil
+}
+
+// sendAsDocument sends the text of msg, made plain by plain, as a .txt file captioned with its
+// first line.
+func (h *Handler) sendAsDocument(msg tgbotapi.MessageConfig, plain func(text, parseMode string) string) (int, error) {
+	text := plain(msg.Text, msg.ParseMode)
+	doc := tgbotapi.NewDocument(msg.ChatID, tgbotapi.FileBytes{Name: documentName, Bytes: []byte(text)})
+	doc.Caption = documentCaption(text)
+	doc.DisableNotification = msg.DisableNotification
+	doc.ReplyToMessageID = msg.ReplyToMessageID
+	doc.AllowSendingWithoutReply = msg.AllowSendingWithoutReply
+	sent, err := h.bot.Send(doc)
+	if err != nil {
+		return 0, fmt.Errorf("send as document: %w", err)
+	}
+	return sent.MessageID, nil
+}
+
+// captionLimit keeps document captions well under Telegram's 1024-character limit.
+const captionLimit = 200
+
+func documentCaption(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if r := []rune(line); len(r) > captionLimit {
+		line = string(r[:captionLimit-1]) + "…"
+	}
+	return line
+}
+
+// notificationMessage builds the message config of n for chatID.
+func notificationMessage(chatID int64, n Notification) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, n.Text)
+	msg.ParseMode = n.ParseMode
+	msg.Entities = n.Entities
+	msg.DisableNotification = n.Silent
+	return msg
+}
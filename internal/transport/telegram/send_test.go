@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// longDump is a system dump of n numbered lines.
+func longDump(prefix string, n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = prefix + strings.Repeat(".", 40)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestHandler_SendToGroup_split(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{}, nil)
+
+	text := longDump("line", 250)
+	require.NoError(t, h.SendToGroup(-100, text))
+
+	calls := fake.Calls("sendMessage")
+	require.Len(t, calls, 3)
+	var bodies []string
+	for i, c := range calls {
+		header, body, _ := strings.Cut(c.params.Get("text"), "\n")
+		require.Equal(t, []string{"(1/3)", "(2/3)", "(3/3)"}[i], header)
+		bodies = append(bodies, body)
+	}
+	require.Equal(t, text, strings.Join(bodies, "\n"))
+}
+
+func TestHandler_SendToGroup_ordering(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{}, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for _, p := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- h.SendToGroup(-100, longDump(p, 250))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Empty(t, h.chatLocks, "unused chat locks are dropped")
+
+	calls := fake.Calls("sendMessage")
+	require.Len(t, calls, 9)
+	for i := 0; i < len(calls); i += 3 {
+		_, first, _ := strings.Cut(calls[i].params.Get("text"), "\n")
+		for j, c := range calls[i : i+3] {
+			header, body, _ := strings.Cut(c.params.Get("text"), "\n")
+			require.Equal(t, []string{"(1/3)", "(2/3)", "(3/3)"}[j], header)
+			require.Equal(t, first[:1], body[:1], "parts of one message are not interleaved")
+		}
+	}
+}
+
+func TestHandler_SendNotification_partial(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{}, nil)
+	fake.mu.Lock()
+	fake.failFrom = 3
+	fake.mu.Unlock()
+
+	err := h.SendNotification(-100, Notification{Text: longDump("p", 250)})
+	require.ErrorIs(t, err, ErrPartialDelivery, "parts 1 and 2 are delivered")
+	require.ErrorContains(t, err, "part 3/3")
+
+	err = h.SendNotification(-100, Notification{Text: longDump("q", 250)})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrPartialDelivery, "nothing was delivered")
+}
+
+func TestHandler_SendNotification_document(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{LongMessageMaxParts: 2}, nil)
+
+	require.NoError(t, h.SendNotification(-100, Notification{Text: "<b>Dump</b>\n" + longDump("x", 250), ParseMode: tgbotapi.ModeHTML}))
+	require.Empty(t, fake.Calls("sendMessage"))
+	docs := fake.Calls("sendDocument")
+	require.Len(t, docs, 1)
+	require.Equal(t, "Dump", docs[0].params.Get("caption"))
+
+	require.NoError(t, h.SendNotification(-100, Notification{Text: longDump("y", 150)}))
+	require.Len(t, fake.Calls("sendMessage"), 2, "two parts are within the limit")
+}
+
+func TestHandler_SendNotification_partFallback(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{}, nil)
+	fake.mu.Lock()
+	fake.rejectMarkup = true
+	fake.mu.Unlock()
+
+	require.NoError(t, h.SendNotification(-100, Notification{Text: "<pre>" + longDump("z", 150) + "</pre>", ParseMode: tgbotapi.ModeHTML}))
+	calls := fake.Calls("sendMessage")
+	require.Len(t, calls, 4)
+	require.Empty(t, calls[1].params.Get("parse_mode"))
+	require.True(t, strings.HasPrefix(calls[1].params.Get("text"), "(1/2)\nz."))
+	require.True(t, strings.HasPrefix(calls[3].params.Get("text"), "(2/2)\nz."))
+}
+
+func TestDocumentCaption(t *testing.T) {
+	require.Equal(t, "first", documentCaption("\n first\nsecond"))
+	require.Len(t, []rune(documentCaption(strings.Repeat("я", 500))), captionLimit)
+}
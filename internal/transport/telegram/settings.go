@@ -0,0 +1,412 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// timezoneChoices are offered as buttons; any IANA zone can be set with /settings timezone.
+var timezoneChoices = []string{
+	"UTC",
+	"Europe/London",
+	"Europe/Warsaw",
+	"Europe/Kyiv",
+	"Europe/Moscow",
+	"Asia/Dubai",
+	"Asia/Singapore",
+	"Asia/Tokyo",
+	"America/New_York",
+	"America/Los_Angeles",
+}
+
+// languageNames labels the selectable languages in their own language, one per catalog locale.
+var languageNames = map[string]string{"en": "English", "ru": "Русский", "uk": "Українська"}
+
+// numberLocaleChoices orders numberLocales in the number format picker.
+var numberLocaleChoices = []string{"en", "ru", "uk", "de"}
+
+// displayCurrencyChoices are offered as buttons; any ticker can be set with /settings currency.
+var displayCurrencyChoices = []string{"USD", "USDT", "EUR", "BTC"}
+
+// currencyPattern keeps display currencies to plain tickers.
+var currencyPattern = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
+
+// quietHoursChoices are offered as buttons; other windows can be set with /settings quiet.
+var quietHoursChoices = []domain.QuietHours{{Start: 22, End: 7}, {Start: 23, End: 8}, {Start: 0, End: 8}}
+
+// unsetValue in a "set:<field>:<value>" callback resets the field to the bot default.
+const unsetValue = "-"
+
+// preferences returns the stored preferences of userID, or zero Preferences without a store.
+func (h *Handler) preferences(userID int64) domain.Preferences {
+	if h.prefs == nil {
+		return domain.Preferences{}
+	}
+	p, err := h.prefs.Preferences(userID)
+	if err != nil {
+		return domain.Preferences{}
+	}
+	return p
+}
+
+// defaultLocation returns SCHEDULE_TIMEZONE, or UTC when unset.
+func (h *Handler) defaultLocation() *time.Location {
+	if loc := h.config().ScheduleLocation; loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// location returns the time zone of userID: their preference, else SCHEDULE_TIMEZONE, else UTC.
+func (h *Handler) location(userID int64) *time.Location {
+	return h.preferences(userID).Location(h.defaultLocation())
+}
+
+// period returns the inclusive date range from..to in the time zone of userID.
+func (h *Handler) period(userID int64, from, to string) domain.Period {
+	return domain.Period{From: from, To: to, Location: h.location(userID)}
+}
+
+func (h *Handler) cmdSettings(_ context.Context, msg *tgbotapi.Message, args string) {
+	chatID := msg.Chat.ID
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		text, kb := h.settingsMenu(msg.From)
+		out := tgbotapi.NewMessage(chatID, text)
+		out.ReplyMarkup = kb
+		if _, err := h.bot.Send(out); err != nil {
+			return
+		}
+		return
+	}
+	tr := h.tr(msg.From)
+	if len(fields) != 2 {
+		h.replyBestEffort(chatID, tr.T("settings.usage"))
+		return
+	}
+	var err error
+	switch fields[0] {
+	case "timezone":
+		err = h.applySetting(tr, msg.From.ID, "tz", fields[1])
+	case "quiet":
+		err = h.applySetting(tr, msg.From.ID, "quiet", fields[1])
+	case "currency":
+		err = h.applySetting(tr, msg.From.ID, "cur", fields[1])
+	default:
+		h.replyBestEffort(chatID, tr.T("settings.usage"))
+		return
+	}
+	if err != nil {
+		h.replyBestEffort(chatID, err.Error())
+		return
+	}
+	text, _ := h.settingsMenu(msg.From)
+	h.replyBestEffort(chatID, tr.T("settings.saved")+"\n"+text)
+}
+
+// handleSettingsCallback serves "set:menu", "set:<field>" (value picker) and
+// "set:<field>:<value>" (store value), editing the pressed message in place.
+func (h *Handler) handleSettingsCallback(q *tgbotapi.CallbackQuery, data string) {
+	userID := q.From.ID
+	field, value, hasValue := strings.Cut(strings.TrimPrefix(data, "set:"), ":")
+	if hasValue {
+		if err := h.applySetting(h.tr(q.From), userID, field, value); err != nil {
+			h.answerCallbackBestEffort(q, err.Error())
+			return
+		}
+		// Localized after saving, so a language change applies to the reply.
+		h.answerCallbackBestEffort(q, h.tr(q.From).T("settings.saved"))
+		if field == "notify" {
+			text, kb := h.notifyPicker(q.From)
+			h.editSettingsBestEffort(q, text, kb)
+			return
+		}
+		text, kb := h.settingsMenu(q.From)
+		h.editSettingsBestEffort(q, text, kb)
+		return
+	}
+
+	tr := h.tr(q.From)
+	var (
+		text string
+		kb   tgbotapi.InlineKeyboardMarkup
+	)
+	switch field {
+	case "menu":
+		text, kb = h.settingsMenu(q.From)
+	case "tz":
+		text = tr.T("settings.pick_timezone")
+		kb = pickerKeyboard(tr, field, timezoneChoices, func(tz string) string { return tz })
+	case "lang":
+		text = tr.T("settings.pick_language")
+		kb = pickerKeyboard(tr, field, append([]string{unsetValue}, h.catalog.Locales()...), func(l string) string {
+			if l == unsetValue {
+				return tr.T("settings.telegram_language")
+			}
+			return languageNames[l]
+		})
+	case "acct":
+		text = tr.T("settings.pick_account")
+		accts := h.accountsFor(userID)
+		ids := []string{unsetValue}
+		for _, a := range accts {
+			ids = append(ids, a.ID)
+		}
+		if len(accts) > 1 {
+			ids = append(ids, domain.AllAccounts)
+		}
+		kb = pickerKeyboard(tr, field, ids, func(id string) string { return h.accountName(tr, userID, id) })
+	case "num":
+		text = tr.T("settings.pick_number_format")
+		kb = pickerKeyboard(tr, field, append([]string{unsetValue}, numberLocaleChoices...), func(l string) string {
+			if l == unsetValue {
+				return tr.T("settings.default_number_format", "sample", h.money().Number(1234.56, 2))
+			}
+			return moneyFormat{locale: numberLocales[l]}.Number(1234.56, 2)
+		})
+	case "notify":
+		text, kb = h.notifyPicker(q.From)
+	case "cur":
+		text = tr.T("settings.pick_display_currency")
+		kb = pickerKeyboard(tr, field, append([]string{unsetValue, domain.NativeCurrencies}, displayCurrencyChoices...), func(c string) string {
+			switch c {
+			case unsetValue:
+				return tr.T("settings.default_display_currency", "value", currencyName(tr, h.config().DisplayCurrency))
+			case domain.NativeCurrencies:
+				return tr.T("settings.native_currencies")
+			}
+			return c
+		})
+	case "quiet":
+		text = tr.T("settings.pick_quiet_hours")
+		choices := []string{"off"}
+		for _, qh := range quietHoursChoices {
+			choices = append(choices, fmt.Sprintf("%d-%d", qh.Start, qh.End))
+		}
+		kb = pickerKeyboard(tr, field, choices, func(c string) string {
+			if c == "off" {
+				return tr.T("settings.off")
+			}
+			qh, _ := parseQuietHours(tr, c) //nolint:errcheck // choices are valid
+			return formatQuietHours(qh)
+		})
+	default:
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	h.answerCallbackBestEffort(q, "")
+	h.editSettingsBestEffort(q, text, kb)
+}
+
+// applySetting validates and stores value for field; unsetValue resets it to the bot default.
+// Errors are localized with tr for the user.
+func (h *Handler) applySetting(tr i18n.Localizer, userID int64, field, value string) error {
+	if h.prefs == nil {
+		return errors.New(tr.T("settings.unavailable"))
+	}
+	var fn func(*domain.Preferences)
+	switch field {
+	case "tz":
+		loc, err := time.LoadLocation(value)
+		if err != nil || value == "" || value == "Local" {
+			return errors.New(tr.T("settings.unknown_timezone", "value", value))
+		}
+		fn = func(p *domain.Preferences) { p.Timezone = loc.String() }
+	case "lang":
+		if value != unsetValue && !slices.Contains(h.catalog.Locales(), value) {
+			return errors.New(tr.T("settings.unknown_language", "value", value))
+		}
+		fn = func(p *domain.Preferences) { p.Language = strings.TrimPrefix(value, unsetValue) }
+	case "acct":
+		if _, ok := h.accountFor(userID, value); !ok && value != unsetValue && value != domain.AllAccounts {
+			return errors.New(tr.T("unknown_account", "account", value))
+		}
+		fn = func(p *domain.Preferences) { p.Account = strings.TrimPrefix(value, unsetValue) }
+	case "num":
+		if _, ok := numberLocales[value]; !ok && value != unsetValue {
+			return errors.New(tr.T("settings.unknown_number_format", "value", value))
+		}
+		fn = func(p *domain.Preferences) { p.NumberLocale = strings.TrimPrefix(value, unsetValue) }
+	case "notify":
+		c := domain.NotificationCategory(value)
+		if !slices.Contains(domain.NotificationCategories, c) {
+			return errors.New(tr.T("settings.unknown_category", "value", value))
+		}
+		fn = func(p *domain.Preferences) {
+			if i := slices.Index(p.Notify, c); i >= 0 {
+				p.Notify = slices.Delete(p.Notify, i, i+1)
+				return
+			}
+			p.Notify = append(p.Notify, c)
+		}
+	case "cur":
+		cur := strings.ToUpper(value)
+		switch {
+		case value == unsetValue:
+			cur = ""
+		case value == domain.NativeCurrencies:
+			cur = domain.NativeCurrencies
+		case !currencyPattern.MatchString(cur):
+			return errors.New(tr.T("settings.unknown_currency", "value", value))
+		}
+		fn = func(p *domain.Preferences) { p.DisplayCurrency = cur }
+	case "quiet":
+		var qh *domain.QuietHours
+		if value != "off" {
+			v, err := parseQuietHours(tr, value)
+			if err != nil {
+				return err
+			}
+			qh = &v
+		}
+		fn = func(p *domain.Preferences) { p.QuietHours = qh }
+	default:
+		return errors.New(tr.T("unknown_action"))
+	}
+	if err := h.prefs.UpdatePreferences(userID, fn); err != nil {
+		return errors.New(tr.T("error", "err", err))
+	}
+	return nil
+}
+
+func (h *Handler) settingsMenu(u *tgbotapi.User) (string, tgbotapi.InlineKeyboardMarkup) {
+	tr := h.tr(u)
+	p := h.preferences(u.ID)
+
+	lang := tr.T("settings.telegram_language")
+	if p.Language != "" {
+		lang = languageNames[p.Language]
+	}
+	notify := tr.T("settings.off")
+	if len(p.Notify) > 0 {
+		names := make([]string, 0, len(p.Notify))
+		for _, c := range domain.NotificationCategories {
+			if p.Subscribed(c) {
+				names = append(names, tr.T("notify."+string(c)))
+			}
+		}
+		notify = strings.Join(names, ", ")
+	}
+	quiet := tr.T("settings.off")
+	if p.QuietHours != nil {
+		quiet = formatQuietHours(*p.QuietHours)
+	}
+
+	lines := []string{
+		tr.T("settings.title"),
+		tr.T("settings.timezone", "value", h.location(u.ID)),
+		tr.T("settings.language", "value", lang),
+		tr.T("settings.account", "value", h.accountName(tr, u.ID, p.Account)),
+		tr.T("settings.number_format", "value", h.moneyFor(u.ID).Number(1234.56, 2)),
+		tr.T("settings.notifications", "value", notify),
+		tr.T("settings.quiet_hours", "value", quiet),
+	}
+	if h.conversionUC != nil {
+		lines = append(lines, tr.T("settings.display_currency", "value", currencyName(tr, h.displayCurrency(u.ID))))
+	}
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_timezone"), "set:tz"),
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_language"), "set:lang"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_account"), "set:acct"),
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_number_format"), "set:num"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_notifications"), "set:notify"),
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_quiet_hours"), "set:quiet"),
+		),
+	)
+	if h.conversionUC != nil {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_display_currency"), "set:cur")))
+	}
+	return strings.Join(lines, "\n"), kb
+}
+
+// notifyPicker lists notification categories with their DM state; a button toggles one.
+func (h *Handler) notifyPicker(u *tgbotapi.User) (string, tgbotapi.InlineKeyboardMarkup) {
+	tr := h.tr(u)
+	p := h.preferences(u.ID)
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(domain.NotificationCategories)+1)
+	for _, c := range domain.NotificationCategories {
+		mark := "⬜"
+		if p.Subscribed(c) {
+			mark = "✅"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark+" "+tr.T("notify."+string(c)), "set:notify:"+string(c)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_back"), "set:menu")))
+	return tr.T("settings.pick_notifications"), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// pickerKeyboard lays values out two per row as "set:<field>:<value>" buttons plus a Back button.
+func pickerKeyboard(tr i18n.Localizer, field string, values []string, label func(string) string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, (len(values)+1)/2+1)
+	for i := 0; i < len(values); i += 2 {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, v := range values[i:min(i+2, len(values))] {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label(v), "set:"+field+":"+v))
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(tr.T("settings.btn_back"), "set:menu")))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// currencyName labels a display currency; empty means the native currencies.
+func currencyName(tr i18n.Localizer, cur string) string {
+	if cur == "" {
+		return tr.T("settings.native_currencies")
+	}
+	return cur
+}
+
+func (h *Handler) accountName(tr i18n.Localizer, userID int64, id string) string {
+	switch id {
+	case "", unsetValue:
+		return tr.T("settings.ask_account")
+	case domain.AllAccounts:
+		return tr.T("accounts.all")
+	}
+	if a, ok := h.accountFor(userID, id); ok {
+		return a.Label()
+	}
+	return id
+}
+
+func (h *Handler) editSettingsBestEffort(q *tgbotapi.CallbackQuery, text string, kb tgbotapi.InlineKeyboardMarkup) {
+	msg := tgbotapi.NewEditMessageTextAndMarkup(q.Message.Chat.ID, q.Message.MessageID, text, kb)
+	if _, err := h.bot.Send(msg); err != nil {
+		return
+	}
+}
+
+// parseQuietHours parses "HH-HH" in local hours 0..23, e.g. "22-07".
+func parseQuietHours(tr i18n.Localizer, s string) (domain.QuietHours, error) {
+	from, to, ok := strings.Cut(s, "-")
+	start, err1 := strconv.Atoi(from)
+	end, err2 := strconv.Atoi(to)
+	if !ok || err1 != nil || err2 != nil || start < 0 || start > 23 || end < 0 || end > 23 || start == end {
+		return domain.QuietHours{}, errors.New(tr.T("settings.invalid_quiet_hours", "value", s))
+	}
+	return domain.QuietHours{Start: start, End: end}, nil
+}
+
+func formatQuietHours(q domain.QuietHours) string {
+	return fmt.Sprintf("%02d:00–%02d:00", q.Start, q.End)
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+type memPreferences map[int64]domain.Preferences
+
+func (m memPreferences) Preferences(userID int64) (domain.Preferences, error) {
+	return m[userID], nil
+}
+
+func (m memPreferences) AllPreferences() (map[int64]domain.Preferences, error) {
+	return m, nil
+}
+
+func (m memPreferences) UpdatePreferences(userID int64, fn func(*domain.Preferences)) error {
+	p := m[userID]
+	fn(&p)
+	m[userID] = p
+	return nil
+}
+
+func TestHandler_settingsTimezone(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	src := &stubReports{}
+	prefs := memPreferences{}
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	cfg := &config.Config{UserIDs: []int64{7}, ScheduleLocation: time.UTC}
+	h := NewHandler(bot, cfg, usecase.NewReportUsecase(src, src, src, stubRenderer{}), WithPreferences(prefs))
+
+	h.handleMessage(context.Background(), commandMessage(7, "/settings timezone Mars/Olympus"))
+	require.Empty(t, prefs[7].Timezone)
+	require.Contains(t, fake.Calls("sendMessage")[0].params.Get("text"), "Unknown timezone")
+
+	h.handleCallback(context.Background(), callback(7, 7, "set:tz"))
+	require.Contains(t, fake.Calls("editMessageText")[0].params.Get("reply_markup"), "set:tz:Europe/Kyiv")
+
+	h.handleCallback(context.Background(), callback(7, 7, "set:tz:Europe/Kyiv"))
+	require.Equal(t, "Europe/Kyiv", prefs[7].Timezone)
+	require.Equal(t, kyiv, h.location(7))
+	require.Equal(t, time.UTC, h.location(8))
+
+	h.handleCallback(context.Background(), callback(7, 7, "cmp:prev::2024-01-01:2024-01-31"))
+	require.Contains(t, src.froms, time.Date(2024, 1, 1, 0, 0, 0, 0, kyiv))
+	msgs := fake.Calls("sendMessage")
+	require.Contains(t, msgs[len(msgs)-1].params.Get("text"), "(Europe/Kyiv)")
+}
+
+func TestHandler_settingsCallbacks(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	prefs := memPreferences{}
+	cfg := &config.Config{UserIDs: []int64{7}, Accounts: []domain.Account{{ID: "main"}, {ID: "alt"}}}
+	h := NewHandler(bot, cfg, nil, WithPreferences(prefs))
+
+	for _, data := range []string{"set:lang:ru", "set:acct:alt", "set:num:de", "set:notify:system", "set:notify:signals", "set:notify:system", "set:quiet:22-7"} {
+		h.handleCallback(context.Background(), callback(7, 7, data))
+	}
+	require.Equal(t, domain.Preferences{
+		Language:     "ru",
+		Account:      "alt",
+		NumberLocale: "de",
+		Notify:       []domain.NotificationCategory{domain.NotifySignals},
+		QuietHours:   &domain.QuietHours{Start: 22, End: 7},
+	}, prefs[7])
+	require.Equal(t, "1.234,56", h.moneyFor(7).Number(1234.56, 2))
+	id, ok := h.defaultAccount(7)
+	require.True(t, ok)
+	require.Equal(t, "alt", id)
+
+	h.handleCallback(context.Background(), callback(7, 7, "set:acct:nope"))
+	h.handleCallback(context.Background(), callback(7, 7, "set:lang:-"))
+	require.Equal(t, "alt", prefs[7].Account)
+	require.Empty(t, prefs[7].Language)
+
+	h.handleMessage(context.Background(), commandMessage(7, "/settings quiet 23-23"))
+	require.Contains(t, fake.Calls("sendMessage")[0].params.Get("text"), "Invalid quiet hours")
+	h.handleMessage(context.Background(), commandMessage(7, "/settings quiet off"))
+	require.Nil(t, prefs[7].QuietHours)
+}
+
+func TestHandler_NotifySubscribers(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	hour := time.Now().UTC().Hour()
+	quiet := &domain.QuietHours{Start: hour, End: (hour + 2) % 24} // now, even if the hour turns
+	prefs := memPreferences{
+		7: {Notify: []domain.NotificationCategory{domain.NotifySystem}},
+		8: {Notify: []domain.NotificationCategory{domain.NotifySystem}, QuietHours: quiet},
+		9: {Notify: []domain.NotificationCategory{domain.NotifySignals}},
+		5: {Notify: []domain.NotificationCategory{domain.NotifySystem}}, // not authorized
+	}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7, 8, 9}}, nil, WithPreferences(prefs))
+
+	require.NoError(t, h.NotifySubscribers(domain.NotifySystem, Notification{Text: "disk full"}))
+
+	msgs := fake.Calls("sendMessage")
+	require.Len(t, msgs, 2)
+	require.Equal(t, "7", msgs[0].params.Get("chat_id"))
+	require.Empty(t, msgs[0].params.Get("disable_notification"))
+	require.Equal(t, "8", msgs[1].params.Get("chat_id"))
+	require.Equal(t, "true", msgs[1].params.Get("disable_notification"))
+}
@@ -0,0 +1,281 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram/format"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// signalCard renders sig as a card for the notification group.
+func (h *Handler) signalCard(sig domain.Signal) Notification {
+	m := formatSignal(h.groupTr(), h.money(), sig, h.defaultLocation()).Render(format.ModeEntities)
+	return Notification{Text: m.Text, Entities: m.Entities}
+}
+
+// formatSignal renders a signal card: side and symbol, strategy, entry, stop-loss and
+// take-profit levels with their distance from the entry and reward-to-risk ratio.
+func formatSignal(tr i18n.Localizer, f moneyFormat, s domain.Signal, loc *time.Location) *format.Text {
+	decimals := priceDecimals(s.Entry)
+	price := func(v float64) string { return f.Number(v, decimals) }
+
+	var t format.Text
+	t.Plain(sideEmoji(string(s.Side)) + " ").Bold(tr.T("signal."+string(s.Side)) + " " + s.Symbol)
+	if s.Leverage > 0 {
+		t.Plain(" ×" + strconv.FormatFloat(s.Leverage, 'f', -1, 64))
+	}
+	var meta []string
+	if s.Strategy != "" {
+		meta = append(meta, tr.T("signal.strategy", "strategy", s.Strategy))
+	}
+	if s.Confidence > 0 {
+		meta = append(meta, tr.T("signal.confidence", "pct", f.Number(s.Confidence*100, 0)+"%"))
+	}
+	if len(meta) > 0 {
+		t.Line().Plain(strings.Join(meta, " · "))
+	}
+	t.Line().Plain(tr.T("signal.entry", "price", price(s.Entry)))
+	t.Line().Plain(tr.T("signal.stop_loss", "price", price(s.StopLoss), "pct", f.Percent(s.DistancePct(s.StopLoss), 2)))
+	for i, tp := range s.TakeProfits {
+		n := ""
+		if len(s.TakeProfits) > 1 {
+			n = strconv.Itoa(i + 1)
+		}
+		t.Line().Plain(tr.T("signal.take_profit", "n", n, "price", price(tp), "pct", f.Percent(s.DistancePct(tp), 2),
+			"rr", f.Number(s.RiskReward(tp), 2)))
+	}
+	t.Line().Plain("🕒 " + s.Time.In(loc).Format("2006-01-02 15:04 MST"))
+	return &t
+}
+
+// priceDecimals shows prices with at least five significant digits, between 2 and 8 decimals.
+func priceDecimals(v float64) int {
+	if v <= 0 {
+		return 2
+	}
+	return min(max(4-int(math.Floor(math.Log10(v))), 2), 8)
+}
+
+// signalNotification renders sig with the template of queue, or as a card when the queue has
+// no template or the template fails.
+func (h *Handler) signalNotification(queue string, sig domain.Signal, payload []byte) Notification {
+	if h.HasTemplate(queue) {
+		if n, err := h.RenderNotification(queue, payload); err == nil {
+			return n
+		}
+	}
+	return h.signalCard(sig)
+}
+
+// PostSignal posts sig from queue to the group chatID and DMs subscribers. With a signal store
+// the messages are indexed by signal ID for lifecycle replies, and chats that already show the
+// signal are skipped, so a redelivered signal is not posted twice. Errors of the DMs alone
+// wrap ErrPartialDelivery.
+func (h *Handler) PostSignal(chatID int64, queue string, sig domain.Signal, payload []byte) error {
+	n := h.signalNotification(queue, sig, payload)
+	if h.signals == nil || sig.ID == "" {
+		err := h.SendNotification(chatID, n)
+		if err != nil && !errors.Is(err, ErrPartialDelivery) {
+			return err
+		}
+		if err := errors.Join(err, h.NotifySubscribers(domain.NotifySignals, n)); err != nil {
+			return fmt.Errorf("%w: %w", ErrPartialDelivery, err)
+		}
+		return nil
+	}
+
+	rec, _, err := h.signals.Signal(sig.ID)
+	if err != nil {
+		return err
+	}
+	if rec.Archived {
+		return nil // a redelivery of a long finished signal
+	}
+	rec.Signal, rec.Queue, rec.Payload = sig, queue, payload
+	post := func(msg tgbotapi.MessageConfig) error {
+		if _, ok := rec.Message(msg.ChatID); ok {
+			return nil
+		}
+		if kb := h.signalKeyboard(rec, msg.ChatID); kb != nil {
+			msg.ReplyMarkup = kb
+		}
+		// A partially delivered message is recorded too, so it is not posted again.
+		id, sendErr := h.sendFormatted(msg)
+		if sendErr != nil && id == 0 {
+			return sendErr
+		}
+		if err := h.signals.UpdateSignal(sig.ID, func(r *domain.SignalRecord) {
+			r.Signal, r.Queue, r.Payload = sig, queue, payload
+			r.Messages = append(r.Messages, domain.MessageRef{ChatID: msg.ChatID, MessageID: id})
+		}); err != nil {
+			return err
+		}
+		return sendErr
+	}
+	var errs []error
+	if err := post(notificationMessage(chatID, n)); err != nil {
+		if !errors.Is(err, ErrPartialDelivery) {
+			return fmt.Errorf("post signal %s: %w", sig.ID, err)
+		}
+		errs = append(errs, fmt.Errorf("post signal %s: %w", sig.ID, err))
+	}
+	subs, err := h.subscribers(domain.NotifySignals)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPartialDelivery, errors.Join(append(errs, err)...))
+	}
+	for _, sub := range subs {
+		if err := post(sub.dm(n)); err != nil {
+			errs = append(errs, fmt.Errorf("signal %s: dm %d: %w", sig.ID, sub.userID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrPartialDelivery, errors.Join(errs...))
+	}
+	return nil
+}
+
+// PostSignalEvent posts a lifecycle event to the group chatID and DMs subscribers, as a reply to
+// the signal's message in each chat that has one. Events of unknown signals are posted on their
+// own; an event already recorded is ignored. With SIGNAL_EDIT_CARDS the signal cards are edited
+// to show the new status, and cards of signals the event ends lose their action buttons.
+// Errors of the DMs and edits alone wrap ErrPartialDelivery.
+func (h *Handler) PostSignalEvent(chatID int64, queue string, ev domain.SignalEvent) error {
+	var rec domain.SignalRecord
+	known := false
+	if h.signals != nil {
+		var err error
+		if rec, known, err = h.signals.Signal(ev.SignalID); err != nil {
+			return err
+		}
+	}
+	if known {
+		if rec.HasEvent(ev) {
+			return nil
+		}
+		if rec.Queue == "" {
+			rec.Queue = queue // records from before queues were stored
+		}
+		if ev.Type == domain.SignalTakeProfit && ev.Level > len(rec.Signal.TakeProfits) {
+			return fmt.Errorf("%w: level %d of signal %s with %d take-profit levels", domain.ErrInvalidSignal,
+				ev.Level, ev.SignalID, len(rec.Signal.TakeProfits))
+		}
+	}
+
+	var sig *domain.Signal
+	if known {
+		sig = &rec.Signal
+	}
+	m := formatSignalEvent(h.groupTr(), h.money(), sig, ev)
+	send := func(msg tgbotapi.MessageConfig) error {
+		msg.Text = m.Text
+		msg.Entities = m.Entities
+		if ref, ok := rec.Message(msg.ChatID); ok {
+			msg.ReplyToMessageID = ref.MessageID
+			msg.AllowSendingWithoutReply = true
+		}
+		_, err := h.sendFormatted(msg)
+		return err
+	}
+	var errs []error
+	if err := send(tgbotapi.NewMessage(chatID, "")); err != nil {
+		if !errors.Is(err, ErrPartialDelivery) {
+			return fmt.Errorf("post signal event %s: %w", ev.SignalID, err)
+		}
+		errs = append(errs, fmt.Errorf("post signal event %s: %w", ev.SignalID, err))
+	}
+	if known {
+		err := h.signals.UpdateSignal(ev.SignalID, func(r *domain.SignalRecord) { r.Events = append(r.Events, ev) })
+		if err != nil {
+			return err
+		}
+		rec.Events = append(rec.Events, ev)
+	}
+
+	subs, err := h.subscribers(domain.NotifySignals)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, sub := range subs {
+		if err := send(sub.dm(Notification{})); err != nil {
+			errs = append(errs, fmt.Errorf("signal %s: dm %d: %w", ev.SignalID, sub.userID, err))
+		}
+	}
+	if known && (h.config().SignalEditCards || h.signalActionsEnabled() && rec.Done()) {
+		errs = append(errs, h.editSignalCards(rec))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrPartialDelivery, err)
+	}
+	return nil
+}
+
+// signalRecordCard renders rec as it was posted, followed by its latest status with
+// SIGNAL_EDIT_CARDS and the actions operators took on it.
+func (h *Handler) signalRecordCard(rec domain.SignalRecord) Notification {
+	tr := h.groupTr()
+	var lines []string
+	if last, ok := rec.Last(); ok && h.config().SignalEditCards {
+		lines = append(lines, formatSignalStatus(tr, last))
+	}
+	for _, a := range rec.Actions {
+		lines = append(lines, formatSignalAction(tr, a)...)
+	}
+	return appendLines(h.signalNotification(rec.Queue, rec.Signal, rec.Payload), lines)
+}
+
+// editSignalCards re-renders the cards of rec with its latest status, actions and buttons.
+func (h *Handler) editSignalCards(rec domain.SignalRecord) error {
+	n := h.signalRecordCard(rec)
+	var errs []error
+	for _, ref := range rec.Messages {
+		edit := tgbotapi.NewEditMessageText(ref.ChatID, ref.MessageID, n.Text)
+		edit.ParseMode, edit.Entities = n.ParseMode, n.Entities
+		edit.ReplyMarkup = h.signalKeyboard(rec, ref.ChatID)
+		if _, err := h.bot.Send(edit); err != nil && !isNotModified(err) {
+			errs = append(errs, fmt.Errorf("edit signal %s in %d: %w", rec.Signal.ID, ref.ChatID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// formatSignalEvent renders a lifecycle event. Without the signal (sig is nil) it is named by
+// ID and the result in percent is omitted.
+func formatSignalEvent(tr i18n.Localizer, f moneyFormat, sig *domain.Signal, ev domain.SignalEvent) format.Message {
+	name, result, decimals := ev.SignalID, "", priceDecimals(ev.Price)
+	if sig != nil {
+		name, decimals = sig.Symbol, priceDecimals(sig.Entry)
+		if ev.Type != domain.SignalCancelled {
+			result = " (" + f.Percent(sig.ProfitPct(ev.Price), 2) + ")"
+		}
+	}
+	var t format.Text
+	t.Plain(signalEventEmoji[ev.Type] + " ").Bold(name).Plain(": " + signalStatusLabel(tr, ev))
+	if ev.Type != domain.SignalCancelled {
+		t.Plain(" " + tr.T("signal.event.at", "price", f.Number(ev.Price, decimals)) + result)
+	}
+	return t.Render(format.ModeEntities)
+}
+
+var signalEventEmoji = map[domain.SignalEventType]string{
+	domain.SignalTakeProfit: "🎯",
+	domain.SignalStopLoss:   "🛑",
+	domain.SignalClosed:     "🏁",
+	domain.SignalCancelled:  "🚫",
+}
+
+// signalStatusLabel names the status ev leaves the signal in, e.g. "TP1 hit".
+func signalStatusLabel(tr i18n.Localizer, ev domain.SignalEvent) string {
+	return tr.T("signal.status."+string(ev.Type), "level", strconv.Itoa(ev.Level))
+}
+
+// formatSignalStatus renders the status line appended to an edited card.
+func formatSignalStatus(tr i18n.Localizer, last domain.SignalEvent) string {
+	return tr.T("signal.status", "status", signalStatusLabel(tr, last))
+}
@@ -0,0 +1,375 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram/format"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// signalCommandTimeout bounds publishing a signal command and waiting for its confirmation.
+	signalCommandTimeout = 10 * time.Second
+	// signalConfirmTTL is how long a signal action waits for its confirmation.
+	signalConfirmTTL = 60 * time.Second
+)
+
+// callbackDataLimit is Telegram's limit on inline button callback data, in bytes.
+const callbackDataLimit = 64
+
+// Signal action callback steps: "sa:ask:<code>:<signal id>" asks to confirm an action, and
+// "sa:ok:<nonce>:<signal id>" or "sa:no:<nonce>:<signal id>" answers that.
+const (
+	signalActionAsk     = "ask"
+	signalActionConfirm = "ok"
+	signalActionCancel  = "no"
+)
+
+// signalActionCodes are the short callback data codes of signal actions.
+var signalActionCodes = map[domain.SignalActionType]string{
+	domain.SignalAcknowledge:     "ack",
+	domain.SignalSkip:            "skip",
+	domain.SignalCloseNow:        "close",
+	domain.SignalMoveToBreakeven: "be",
+}
+
+func signalActionOf(code string) (domain.SignalActionType, bool) {
+	for a, c := range signalActionCodes {
+		if c == code {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+func signalActionData(step, arg, signalID string) string {
+	return "sa:" + step + ":" + arg + ":" + signalID
+}
+
+// pendingSignalAction is a signal action waiting for the admin who asked for it to confirm it.
+type pendingSignalAction struct {
+	signalID string
+	action   domain.SignalActionType
+	userID   int64
+	expires  time.Time
+}
+
+// signalActionState holds signal actions awaiting confirmation, keyed by their single-use
+// nonce. A signal has at most one: asking again replaces it, so two admins cannot both confirm.
+type signalActionState struct {
+	mu      sync.Mutex
+	pending map[string]pendingSignalAction
+}
+
+// put stores p under a new nonce and returns it, dropping expired actions and any other action
+// pending on the same signal.
+func (s *signalActionState) put(p pendingSignalAction, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		s.pending = map[string]pendingSignalAction{}
+	}
+	for n, old := range s.pending {
+		if old.signalID == p.signalID || now.After(old.expires) {
+			delete(s.pending, n)
+		}
+	}
+	nonce := newNonce()
+	s.pending[nonce] = p
+	return nonce
+}
+
+// take removes and returns the action of nonce.
+func (s *signalActionState) take(nonce string) (pendingSignalAction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pending[nonce]
+	delete(s.pending, nonce)
+	return p, ok
+}
+
+// restore puts back the action of nonce after a press that did not use it up, unless another
+// one was asked for the signal in the meantime.
+func (s *signalActionState) restore(nonce string, p pendingSignalAction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.pending {
+		if other.signalID == p.signalID {
+			return
+		}
+	}
+	s.pending[nonce] = p
+}
+
+func (h *Handler) signalActionsEnabled() bool {
+	return h.signalCmds != nil && h.signals != nil
+}
+
+// signalKeyboard returns the action buttons of rec in chatID, or nil when there are none:
+// buttons are shown in groups and admins' DMs until the signal is done, and only for signal IDs
+// that fit into callback data.
+func (h *Handler) signalKeyboard(rec domain.SignalRecord, chatID int64) *tgbotapi.InlineKeyboardMarkup {
+	if !h.signalActionsEnabled() || rec.Done() || (chatID > 0 && h.roleOf(chatID) < roleAdmin) {
+		return nil
+	}
+	if len(signalActionData(signalActionConfirm, strings.Repeat("0", len(newNonce())), rec.Signal.ID)) > callbackDataLimit {
+		return nil
+	}
+	tr := h.groupTr()
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, a := range domain.SignalActionTypes {
+		btn := tgbotapi.NewInlineKeyboardButtonData(tr.T("signal.action."+string(a)), signalActionData(signalActionAsk, signalActionCodes[a], rec.Signal.ID))
+		if i%2 == 0 {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+		} else {
+			rows[len(rows)-1] = append(rows[len(rows)-1], btn)
+		}
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &kb
+}
+
+// signalConfirmKeyboard asks to confirm action a on signal signalID, pending under nonce.
+func signalConfirmKeyboard(tr i18n.Localizer, a domain.SignalActionType, nonce, signalID string) *tgbotapi.InlineKeyboardMarkup {
+	kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("signal.action.confirm", "action", tr.T("signal.action."+string(a))),
+			signalActionData(signalActionConfirm, nonce, signalID)),
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("signal.action.cancel"), signalActionData(signalActionCancel, nonce, signalID)),
+	))
+	return &kb
+}
+
+// handleSignalActionCallback runs a signal action button: the first press asks for confirmation
+// in place of the buttons, and only the admin who pressed it may confirm, within
+// signalConfirmTTL. Confirming publishes the command and updates the cards. A command queued
+// while the broker is unavailable counts as sent.
+func (h *Handler) handleSignalActionCallback(ctx context.Context, q *tgbotapi.CallbackQuery, data string) {
+	tr := h.tr(q.From)
+	parts := strings.SplitN(data, ":", 4)
+	if len(parts) != 4 || !h.signalActionsEnabled() {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	step, signalID := parts[1], parts[3]
+	if h.roleOf(q.From.ID) < roleAdmin {
+		h.answerCallbackBestEffort(q, tr.T("access_denied"))
+		return
+	}
+	rec, known, err := h.signals.Signal(signalID)
+	if err != nil {
+		h.answerCallbackBestEffort(q, tr.T("error", "err", err))
+		return
+	}
+	if !known {
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+		return
+	}
+	chatID, messageID := q.Message.Chat.ID, q.Message.MessageID
+	if rec.Done() {
+		h.answerCallbackBestEffort(q, tr.T("signal.action.done"))
+		h.editKeyboardBestEffort(chatID, messageID, nil)
+		return
+	}
+
+	if step == signalActionAsk {
+		action, ok := signalActionOf(parts[2])
+		if !ok {
+			h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+			return
+		}
+		now := time.Now()
+		nonce := h.signalActions.put(pendingSignalAction{signalID: signalID, action: action, userID: q.From.ID,
+			expires: now.Add(signalConfirmTTL)}, now)
+		h.answerCallbackBestEffort(q, "")
+		h.editKeyboardBestEffort(chatID, messageID, signalConfirmKeyboard(h.groupTr(), action, nonce, signalID))
+		return
+	}
+
+	nonce := parts[2]
+	p, ok := h.signalActions.take(nonce)
+	if !ok || p.signalID != signalID || time.Now().After(p.expires) {
+		h.answerCallbackBestEffort(q, tr.T("signal.action.expired"))
+		h.editKeyboardBestEffort(chatID, messageID, h.signalKeyboard(rec, chatID))
+		return
+	}
+	if q.From.ID != p.userID {
+		h.signalActions.restore(nonce, p)
+		h.answerCallbackBestEffort(q, tr.T("signal.action.not_yours"))
+		return
+	}
+	switch step {
+	case signalActionCancel:
+		h.answerCallbackBestEffort(q, "")
+		h.editKeyboardBestEffort(chatID, messageID, h.signalKeyboard(rec, chatID))
+	case signalActionConfirm:
+		cmd := domain.SignalCommand{ID: newCommandID(), SignalID: signalID, Action: p.action, UserID: q.From.ID,
+			User: userLabel(q.From), Time: time.Now().UTC()}
+		pubCtx, cancel := context.WithTimeout(ctx, signalCommandTimeout)
+		defer cancel()
+		answer := tr.T("signal.action.sent")
+		if err := h.signalCmds.PublishSignalCommand(pubCtx, cmd); errors.Is(err, ports.ErrDeferred) {
+			answer = tr.T("signal.action.queued")
+		} else if err != nil {
+			h.answerCallbackBestEffort(q, tr.T("signal.action.failed", "err", err))
+			h.editKeyboardBestEffort(chatID, messageID, h.signalKeyboard(rec, chatID))
+			return
+		}
+		record := func(r *domain.SignalRecord) { r.Actions = append(r.Actions, domain.SignalAction{SignalCommand: cmd}) }
+		if err := h.signals.UpdateSignal(signalID, record); err != nil {
+			h.answerCallbackBestEffort(q, tr.T("error", "err", err))
+			return
+		}
+		record(&rec)
+		h.answerCallbackBestEffort(q, answer)
+		if err := h.editSignalCards(rec); err != nil {
+			return
+		}
+	default:
+		h.signalActions.restore(nonce, p)
+		h.answerCallbackBestEffort(q, tr.T("unknown_action"))
+	}
+}
+
+// PostSignalCommandReply records the trading core's reply to a signal command and shows it on
+// the signal's cards. Replies to unknown commands are invalid and repeated replies are ignored.
+// Errors of the card edits alone wrap ErrPartialDelivery.
+func (h *Handler) PostSignalCommandReply(reply domain.SignalCommandReply) error {
+	if h.signals == nil {
+		return fmt.Errorf("%w: reply to command %s without a signal store", domain.ErrInvalidSignal, reply.CommandID)
+	}
+	rec, known, err := h.signals.Signal(reply.SignalID)
+	if err != nil {
+		return err
+	}
+	action, ok := rec.Action(reply.CommandID)
+	if !known || !ok {
+		return fmt.Errorf("%w: reply to unknown command %s of signal %s", domain.ErrInvalidSignal, reply.CommandID, reply.SignalID)
+	}
+	if action.Reply != nil {
+		return nil
+	}
+	record := func(r *domain.SignalRecord) {
+		for i := range r.Actions {
+			if r.Actions[i].ID == reply.CommandID {
+				r.Actions[i].Reply = &reply
+			}
+		}
+	}
+	if err := h.signals.UpdateSignal(reply.SignalID, record); err != nil {
+		return err
+	}
+	record(&rec)
+	if err := h.editSignalCards(rec); err != nil {
+		return fmt.Errorf("%w: %w", ErrPartialDelivery, err)
+	}
+	return nil
+}
+
+// SignalCommandDropped records that cmd never reached the trading core, for reason
+// domain.SignalCommandExpired or domain.SignalCommandUnroutable: the cards show it and offer the
+// actions again, and the admin who sent it is told by DM.
+func (h *Handler) SignalCommandDropped(cmd domain.SignalCommand, reason string) error {
+	if h.signals == nil {
+		return nil
+	}
+	rec, known, err := h.signals.Signal(cmd.SignalID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if _, ok := rec.Action(cmd.ID); known && ok {
+		record := func(r *domain.SignalRecord) {
+			for i := range r.Actions {
+				if r.Actions[i].ID == cmd.ID {
+					r.Actions[i].Dropped = reason
+				}
+			}
+		}
+		if err := h.signals.UpdateSignal(cmd.SignalID, record); err != nil {
+			return err
+		}
+		record(&rec)
+		errs = append(errs, h.editSignalCards(rec))
+	}
+	name := rec.Signal.Symbol
+	if name == "" {
+		name = cmd.SignalID
+	}
+	tr := h.tr(&tgbotapi.User{ID: cmd.UserID})
+	text := tr.T("signal.action.dropped", "action", tr.T("signal.action."+string(cmd.Action)), "signal", name,
+		"reason", tr.T("signal.dropped."+reason))
+	if _, err := h.bot.Send(tgbotapi.NewMessage(cmd.UserID, text)); err != nil {
+		errs = append(errs, fmt.Errorf("notify %d of dropped command %s: %w", cmd.UserID, cmd.ID, err))
+	}
+	return errors.Join(errs...)
+}
+
+// formatSignalAction renders who took action a and the core's reply, if any.
+func formatSignalAction(tr i18n.Localizer, a domain.SignalAction) []string {
+	lines := []string{"👤 " + tr.T("signal.acted."+string(a.Action), "user", a.User)}
+	if a.Dropped != "" {
+		lines = append(lines, "↳ "+tr.T("signal.not_delivered", "reason", tr.T("signal.dropped."+a.Dropped)))
+	}
+	if r := a.Reply; r != nil {
+		key := "signal.reply.ok"
+		if !r.OK {
+			key = "signal.reply.failed"
+		}
+		line := "↳ " + tr.T(key)
+		if r.Message != "" {
+			line += ": " + r.Message
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// appendLines adds plain text lines to the end of n, escaped for its parse mode.
+func appendLines(n Notification, lines []string) Notification {
+	for _, l := range lines {
+		switch format.Mode(n.ParseMode) {
+		case format.ModeMarkdownV2:
+			l = format.EscapeMarkdownV2(l)
+		case format.ModeHTML:
+			l = format.EscapeHTML(l)
+		}
+		n.Text += "\n" + l
+	}
+	return n
+}
+
+func (h *Handler) editKeyboardBestEffort(chatID int64, messageID int, kb *tgbotapi.InlineKeyboardMarkup) {
+	edit := tgbotapi.EditMessageReplyMarkupConfig{BaseEdit: tgbotapi.BaseEdit{ChatID: chatID, MessageID: messageID, ReplyMarkup: kb}}
+	if _, err := h.bot.Request(edit); err != nil {
+		return
+	}
+}
+
+// userLabel names u on signal cards: "@username", else their full name, else their ID.
+func userLabel(u *tgbotapi.User) string {
+	if u.UserName != "" {
+		return "@" + u.UserName
+	}
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+	return strconv.FormatInt(u.ID, 10)
+}
+
+// newCommandID returns a random 128-bit hex ID.
+func newCommandID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) //nolint:errcheck // crypto/rand.Read never fails
+	return hex.EncodeToString(b[:])
+}
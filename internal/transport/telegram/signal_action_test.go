@@ -0,0 +1,240 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type memCommands struct {
+	sent []domain.SignalCommand
+	err  error
+}
+
+func (m *memCommands) PublishSignalCommand(_ context.Context, cmd domain.SignalCommand) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, cmd)
+	return nil
+}
+
+// keyboardData returns the callback data of every button in a reply_markup parameter.
+func keyboardData(t *testing.T, markup string) []string {
+	t.Helper()
+	if markup == "" {
+		return nil
+	}
+	var kb tgbotapi.InlineKeyboardMarkup
+	require.NoError(t, json.Unmarshal([]byte(markup), &kb))
+	var out []string
+	for _, row := range kb.InlineKeyboard {
+		for _, b := range row {
+			out = append(out, *b.CallbackData)
+		}
+	}
+	return out
+}
+
+func signalCallback(userID int64, messageID int, data string) *tgbotapi.CallbackQuery {
+	q := callback(userID, -100, data)
+	q.From.UserName = "bob"
+	q.Message.MessageID = messageID
+	return q
+}
+
+// askSignalAction presses the button of action code on signal s1 as userID and returns the
+// confirm and cancel callback data.
+func askSignalAction(t *testing.T, h *Handler, fake *fakeTelegram, userID int64, messageID int, code string) (confirm, cancel string) {
+	t.Helper()
+	h.handleCallback(context.Background(), signalCallback(userID, messageID, "sa:ask:"+code+":s1"))
+	markups := fake.Calls("editMessageReplyMarkup")
+	data := keyboardData(t, markups[len(markups)-1].params.Get("reply_markup"))
+	require.Len(t, data, 2)
+	return data[0], data[1]
+}
+
+func TestHandler_signalActions(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	store := memSignals{}
+	pub := &memCommands{}
+	prefs := memPreferences{
+		7: {Notify: []domain.NotificationCategory{domain.NotifySignals}},
+		8: {Notify: []domain.NotificationCategory{domain.NotifySignals}},
+	}
+	cfg := &config.Config{UserIDs: []int64{7}, ViewerIDs: []int64{8}}
+	h := NewHandler(bot, cfg, nil, WithPreferences(prefs), WithSignals(store), WithSignalActions(pub))
+	ctx := context.Background()
+
+	require.NoError(t, h.PostSignal(-100, "signals", testSignal(), nil))
+	sent := fake.Calls("sendMessage")
+	require.Len(t, sent, 3)
+	require.Equal(t, []string{"sa:ask:ack:s1", "sa:ask:skip:s1", "sa:ask:close:s1", "sa:ask:be:s1"},
+		keyboardData(t, sent[0].params.Get("reply_markup")))
+	require.Len(t, keyboardData(t, sent[1].params.Get("reply_markup")), 4, "admin DM")
+	require.Empty(t, sent[2].params.Get("reply_markup"), "viewer DM")
+	group, _ := store["s1"].Message(-100)
+
+	// Viewers cannot act.
+	h.handleCallback(ctx, signalCallback(8, group.MessageID, "sa:ask:ack:s1"))
+	require.Equal(t, "Access denied", fake.Calls("answerCallbackQuery")[0].params.Get("text"))
+
+	// The first press asks for confirmation in place of the buttons; cancel restores them.
+	confirm, cancel := askSignalAction(t, h, fake, 7, group.MessageID, "ack")
+	require.Regexp(t, `^sa:ok:[0-9a-f]{16}:s1$`, confirm)
+	require.Equal(t, strings.Replace(confirm, "sa:ok:", "sa:no:", 1), cancel)
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, cancel))
+	require.Len(t, keyboardData(t, fake.Calls("editMessageReplyMarkup")[1].params.Get("reply_markup")), 4)
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, confirm))
+	answers := fake.Calls("answerCallbackQuery")
+	require.Equal(t, "This confirmation has expired; press the action again", answers[len(answers)-1].params.Get("text"))
+	require.Empty(t, pub.sent)
+
+	// Confirming publishes the command and shows who acted on every card; the nonce is single-use.
+	confirm, _ = askSignalAction(t, h, fake, 7, group.MessageID, "ack")
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, confirm))
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, confirm))
+	require.Len(t, pub.sent, 1)
+	cmd := pub.sent[0]
+	require.Equal(t, domain.SignalAcknowledge, cmd.Action)
+	require.Equal(t, "s1", cmd.SignalID)
+	require.Equal(t, int64(7), cmd.UserID)
+	require.Equal(t, "@bob", cmd.User)
+	require.Len(t, cmd.ID, 32)
+	require.Len(t, store["s1"].Actions, 1)
+	edits := fake.Calls("editMessageText")
+	require.Len(t, edits, 3)
+	require.True(t, strings.HasSuffix(edits[0].params.Get("text"), "\n👤 Acknowledged by @bob"))
+	require.Len(t, keyboardData(t, edits[0].params.Get("reply_markup")), 4)
+	require.Empty(t, edits[2].params.Get("reply_markup"), "viewer DM")
+
+	// The core's reply is added below the action; a repeated reply is ignored.
+	reply := domain.SignalCommandReply{CommandID: cmd.ID, SignalID: "s1", OK: true, Message: "noted"}
+	require.NoError(t, h.PostSignalCommandReply(reply))
+	edits = fake.Calls("editMessageText")
+	require.Len(t, edits, 6)
+	require.True(t, strings.HasSuffix(edits[3].params.Get("text"), "\n👤 Acknowledged by @bob\n↳ Core: done: noted"))
+	require.NoError(t, h.PostSignalCommandReply(reply))
+	require.Len(t, fake.Calls("editMessageText"), 6)
+	err := h.PostSignalCommandReply(domain.SignalCommandReply{CommandID: "nope", SignalID: "s1"})
+	require.ErrorIs(t, err, domain.ErrInvalidSignal)
+
+	// A failed publish keeps the buttons and records nothing.
+	pub.err = errors.New("broker down")
+	confirm, _ = askSignalAction(t, h, fake, 7, group.MessageID, "close")
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, confirm))
+	answers = fake.Calls("answerCallbackQuery")
+	require.Equal(t, "Could not send the command: broker down", answers[len(answers)-1].params.Get("text"))
+	require.Len(t, store["s1"].Actions, 1)
+
+	// A command queued in the outbox while the broker is down counts as sent.
+	pub.err = fmt.Errorf("%w: connection refused", ports.ErrDeferred)
+	confirm, _ = askSignalAction(t, h, fake, 7, group.MessageID, "be")
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, confirm))
+	answers = fake.Calls("answerCallbackQuery")
+	require.Equal(t, "The broker is unavailable; the command is queued and will be sent when it is back", answers[len(answers)-1].params.Get("text"))
+	require.Len(t, store["s1"].Actions, 2)
+	require.Len(t, fake.Calls("editMessageText"), 9)
+	pub.err = nil
+
+	// Closing ends the signal: the buttons are removed and further presses are refused.
+	confirm, _ = askSignalAction(t, h, fake, 7, group.MessageID, "close")
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, confirm))
+	edits = fake.Calls("editMessageText")
+	require.Len(t, edits, 12)
+	require.Empty(t, edits[9].params.Get("reply_markup"))
+	require.True(t, strings.HasSuffix(edits[9].params.Get("text"), "\n👤 Close requested by @bob"))
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, "sa:ask:be:s1"))
+	answers = fake.Calls("answerCallbackQuery")
+	require.Equal(t, "This signal is already closed", answers[len(answers)-1].params.Get("text"))
+	require.Len(t, pub.sent, 2)
+}
+
+func TestHandler_signalActions_twoAdmins(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	store := memSignals{}
+	pub := &memCommands{}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7, 9}}, nil, WithSignals(store), WithSignalActions(pub))
+	ctx := context.Background()
+	require.NoError(t, h.PostSignal(-100, "signals", testSignal(), nil))
+	group, _ := store["s1"].Message(-100)
+
+	// Only the admin who asked may confirm, and another ask replaces the pending action.
+	first, _ := askSignalAction(t, h, fake, 7, group.MessageID, "close")
+	h.handleCallback(ctx, signalCallback(9, group.MessageID, first))
+	answers := fake.Calls("answerCallbackQuery")
+	require.Equal(t, "Only the admin who pressed the action can confirm it", answers[len(answers)-1].params.Get("text"))
+	second, _ := askSignalAction(t, h, fake, 9, group.MessageID, "skip")
+	h.handleCallback(ctx, signalCallback(7, group.MessageID, first))
+	answers = fake.Calls("answerCallbackQuery")
+	require.Equal(t, "This confirmation has expired; press the action again", answers[len(answers)-1].params.Get("text"))
+	h.handleCallback(ctx, signalCallback(9, group.MessageID, second))
+	require.Len(t, pub.sent, 1)
+	require.Equal(t, domain.SignalSkip, pub.sent[0].Action)
+	require.Equal(t, int64(9), pub.sent[0].UserID)
+}
+
+func TestHandler_SignalCommandDropped(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	store := memSignals{}
+	pub := &memCommands{err: fmt.Errorf("%w: connection refused", ports.ErrDeferred)}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, nil, WithSignals(store), WithSignalActions(pub))
+	require.NoError(t, h.PostSignal(-100, "signals", testSignal(), nil))
+	group, _ := store["s1"].Message(-100)
+
+	confirm, _ := askSignalAction(t, h, fake, 7, group.MessageID, "close")
+	h.handleCallback(context.Background(), signalCallback(7, group.MessageID, confirm))
+	require.True(t, store["s1"].Done())
+	cmd := store["s1"].Actions[0].SignalCommand
+
+	// A queued close that expired is shown as not delivered, the buttons come back, and the
+	// admin who sent it is told.
+	sent := len(fake.Calls("sendMessage"))
+	require.NoError(t, h.SignalCommandDropped(cmd, domain.SignalCommandExpired))
+	require.False(t, store["s1"].Done())
+	edits := fake.Calls("editMessageText")
+	last := edits[len(edits)-1]
+	require.True(t, strings.HasSuffix(last.params.Get("text"),
+		"\n👤 Close requested by @bob\n↳ Not delivered: it expired while the broker was unavailable"))
+	require.Len(t, keyboardData(t, last.params.Get("reply_markup")), 4)
+	dms := fake.Calls("sendMessage")[sent:]
+	require.Len(t, dms, 1)
+	require.Equal(t, "7", dms[0].params.Get("chat_id"))
+	require.Equal(t, "✖️ Close now on BTCUSDT was not sent to the trading core: it expired while the broker was unavailable",
+		dms[0].params.Get("text"))
+}
+
+func TestHandler_signalActions_finalEvent(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	store := memSignals{}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, nil, WithSignals(store), WithSignalActions(&memCommands{}))
+
+	require.NoError(t, h.PostSignal(-100, "signals", testSignal(), nil))
+	require.NotEmpty(t, fake.Calls("sendMessage")[0].params.Get("reply_markup"))
+
+	// Without SIGNAL_EDIT_CARDS only events that end the signal edit the card, to drop the buttons.
+	tp1 := domain.SignalEvent{SignalID: "s1", Type: domain.SignalTakeProfit, Level: 1, Price: 110}
+	require.NoError(t, h.PostSignalEvent(-100, "signals", tp1))
+	require.Empty(t, fake.Calls("editMessageText"))
+	require.NoError(t, h.PostSignalEvent(-100, "signals", domain.SignalEvent{SignalID: "s1", Type: domain.SignalClosed, Price: 112}))
+	edits := fake.Calls("editMessageText")
+	require.Len(t, edits, 1)
+	require.Empty(t, edits[0].params.Get("reply_markup"))
+}
+
+func TestAppendLines(t *testing.T) {
+	lines := []string{"👤 Acknowledged by @a_b", "↳ Core: done: 1 < 2"}
+	require.Equal(t, "card\n👤 Acknowledged by @a\\_b\n↳ Core: done: 1 < 2",
+		appendLines(Notification{Text: "card", ParseMode: tgbotapi.ModeMarkdownV2}, lines).Text)
+	require.Equal(t, "<b>card</b>\n👤 Acknowledged by @a_b\n↳ Core: done: 1 &lt; 2",
+		appendLines(Notification{Text: "<b>card</b>", ParseMode: tgbotapi.ModeHTML}, lines).Text)
+}
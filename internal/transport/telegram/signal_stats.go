@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultSignalRange is the /signals stats range when none is given.
+const defaultSignalRange = "30d"
+
+// signalStatsSymbols is how many best and worst symbols /signals stats lists.
+const signalStatsSymbols = 3
+
+// cmdSignals handles "/signals stats [range] [strategy]"; range is "<N>d", "all" or
+// "<from>:<to>" dates and defaults to the last 30 days.
+func (h *Handler) cmdSignals(_ context.Context, msg *tgbotapi.Message, args string) {
+	chatID := msg.Chat.ID
+	tr := h.tr(msg.From)
+	if h.signalUC == nil {
+		h.replyBestEffort(chatID, tr.T("signals.unavailable"))
+		return
+	}
+	fields := strings.Fields(args)
+	if len(fields) > 0 && fields[0] == "stats" {
+		fields = fields[1:]
+	}
+	rng := defaultSignalRange
+	if len(fields) > 0 && isSignalRange(fields[0]) {
+		rng, fields = fields[0], fields[1:]
+	}
+	if len(fields) > 1 {
+		h.replyBestEffort(chatID, tr.T("signals.usage"))
+		return
+	}
+	var strategy string
+	if len(fields) == 1 {
+		strategy = fields[0]
+	}
+	p, ok := h.signalPeriod(msg.From.ID, rng, time.Now())
+	if !ok {
+		h.replyBestEffort(chatID, tr.T("signals.usage"))
+		return
+	}
+
+	st, err := h.signalUC.Stats(p, strategy)
+	switch {
+	case errors.Is(err, usecase.ErrNoData) && p == nil:
+		h.replyBestEffort(chatID, tr.T("signals.none_all"))
+	case errors.Is(err, usecase.ErrNoData):
+		h.replyBestEffort(chatID, tr.T("signals.none", "from", p.From, "to", p.To))
+	case err != nil:
+		h.replyBestEffort(chatID, tr.T("error", "err", err))
+	default:
+		h.replyBestEffort(chatID, formatSignalStats(tr, h.moneyFor(msg.From.ID), st))
+	}
+}
+
+// isSignalRange reports whether arg looks like a /signals stats range rather than a strategy.
+func isSignalRange(arg string) bool {
+	if arg == "all" || strings.Contains(arg, ":") {
+		return true
+	}
+	n, ok := strings.CutSuffix(arg, "d")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(n)
+	return err == nil
+}
+
+// signalPeriod resolves a /signals stats range in the user's time zone; "all" yields nil.
+// "<N>d" is the N calendar days ending today.
+func (h *Handler) signalPeriod(userID int64, rng string, now time.Time) (*domain.Period, bool) {
+	if rng == "all" {
+		return nil, true
+	}
+	if from, to, ok := strings.Cut(rng, ":"); ok {
+		p := h.period(userID, from, to)
+		start, end, err := p.Bounds()
+		if err != nil || !start.Before(end) {
+			return nil, false
+		}
+		return &p, true
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(rng, "d"))
+	if err != nil || days < 1 {
+		return nil, false
+	}
+	today := now.In(h.location(userID))
+	from := today.AddDate(0, 0, 1-days)
+	p := h.period(userID, from.Format(time.DateOnly), today.Format(time.DateOnly))
+	return &p, true
+}
+
+func formatSignalStats(tr i18n.Localizer, f moneyFormat, st *domain.SignalStats) string {
+	var strategy string
+	if st.Strategy != "" {
+		strategy = " · " + st.Strategy
+	}
+	title := tr.T("signals.title_all", "strategy", strategy)
+	if st.From != "" {
+		title = tr.T("signals.title", "from", st.From, "to", st.To, "zone", zoneSuffix(st.TimeZone), "strategy", strategy)
+	}
+	lines := []string{
+		title,
+		tr.T("signals.count", "signals", tr.N("signals.n", st.Signals), "resolved", st.Resolved, "open", st.Open, "cancelled", st.Cancelled),
+	}
+	for i, r := range st.TakeProfits {
+		lines = append(lines, tr.T("signals.tp", "level", i+1, "hit", r.Hit, "of", r.Of, "pct", formatRate(tr, f, r)))
+	}
+	lines = append(lines,
+		tr.T("signals.sl", "hit", st.StopLoss.Hit, "of", st.StopLoss.Of, "pct", formatRate(tr, f, st.StopLoss)),
+		tr.T("signals.avg_r", "value", formatR(tr, f, st.AvgR)),
+	)
+
+	var best, worst []string
+	for _, s := range st.Symbols {
+		if s.TotalR > 0 && len(best) < signalStatsSymbols {
+			best = append(best, formatSymbolR(tr, f, s))
+		}
+	}
+	for i := len(st.Symbols) - 1; i >= 0; i-- {
+		if s := st.Symbols[i]; s.TotalR < 0 && len(worst) < signalStatsSymbols {
+			worst = append(worst, formatSymbolR(tr, f, s))
+		}
+	}
+	if len(best) > 0 {
+		lines = append(lines, tr.T("signals.best", "symbols", strings.Join(best, ", ")))
+	}
+	if len(worst) > 0 {
+		lines = append(lines, tr.T("signals.worst", "symbols", strings.Join(worst, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatRate renders r as a percentage, or "n/a" when nothing was counted.
+func formatRate(tr i18n.Localizer, f moneyFormat, r domain.Ratio) string {
+	if r.Of == 0 {
+		return tr.T("not_available")
+	}
+	return f.Number(r.Rate()*100, 0) + "%"
+}
+
+// formatR renders an R multiple with an explicit sign, e.g. "+1.25R", or "n/a" for NaN.
+func formatR(tr i18n.Localizer, f moneyFormat, v float64) string {
+	if math.IsNaN(v) {
+		return tr.T("not_available")
+	}
+	s := f.Number(v, 2)
+	if math.Round(v*100) > 0 {
+		s = "+" + s
+	}
+	return s + "R"
+}
+
+// formatSymbolR renders a symbol's total R and resolved count, e.g. "BTCUSDT +3.50R (2)".
+func formatSymbolR(tr i18n.Localizer, f moneyFormat, s domain.SymbolStats) string {
+	return fmt.Sprintf("%s %s (%d)", s.Symbol, formatR(tr, f, s.TotalR), s.Resolved)
+}
@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSignalStats(t *testing.T) {
+	st := &domain.SignalStats{
+		From: "2026-01-01", To: "2026-01-31", TimeZone: "Europe/Kyiv", Strategy: "breakout",
+		Signals: 6, Open: 2, Cancelled: 1, Resolved: 3,
+		TakeProfits: []domain.Ratio{{Hit: 3, Of: 4}, {Hit: 0, Of: 0}},
+		StopLoss:    domain.Ratio{Hit: 2, Of: 3},
+		AvgR:        1.0 / 3,
+		Symbols: []domain.SymbolStats{
+			{Symbol: "BTCUSDT", Resolved: 1, TotalR: 2},
+			{Symbol: "SOLUSDT", Resolved: 1, TotalR: 0},
+			{Symbol: "ETHUSDT", Resolved: 2, TotalR: -1.5},
+		},
+	}
+	require.Equal(t, "Signals 2026-01-01 — 2026-01-31 (Europe/Kyiv) · breakout\n"+
+		"6 signals: 3 resolved, 2 open, 1 cancelled\n"+
+		"TP1 hit: 3/4 (75%)\n"+
+		"TP2 hit: 0/0 (n/a)\n"+
+		"Stopped out: 2/3 (67%)\n"+
+		"Average R: +0.33R\n"+
+		"Best: BTCUSDT +2.00R (1)\n"+
+		"Worst: ETHUSDT -1.50R (2)", formatSignalStats(enTr, enMoney, st))
+}
+
+func TestHandler_signalPeriod(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	h := NewHandler(nil, &config.Config{ScheduleLocation: kyiv}, nil)
+	now := time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC) // already March 11 in Kyiv
+
+	p, ok := h.signalPeriod(1, "7d", now)
+	require.True(t, ok)
+	require.Equal(t, "2026-03-05", p.From)
+	require.Equal(t, "2026-03-11", p.To)
+
+	p, ok = h.signalPeriod(1, "2026-01-01:2026-01-31", now)
+	require.True(t, ok)
+	require.Equal(t, "2026-01-01", p.From)
+
+	p, ok = h.signalPeriod(1, "all", now)
+	require.True(t, ok)
+	require.Nil(t, p)
+
+	for _, bad := range []string{"0d", "2026-02-01:2026-01-01", "2026-13-01:2026-12-31"} {
+		_, ok = h.signalPeriod(1, bad, now)
+		require.False(t, ok, bad)
+	}
+}
+
+func TestHandler_cmdSignals(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	at := time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)
+	store := memSignals{
+		"a": {
+			Signal: domain.Signal{ID: "a", Symbol: "BTCUSDT", Side: domain.SideLong, Entry: 100, StopLoss: 95,
+				TakeProfits: []float64{110}, Strategy: "breakout", Time: at},
+			Events: []domain.SignalEvent{{Type: domain.SignalTakeProfit, Level: 1, Price: 110}},
+		},
+		"b": {
+			Signal: domain.Signal{ID: "b", Symbol: "ETHUSDT", Side: domain.SideShort, Entry: 100, StopLoss: 110,
+				TakeProfits: []float64{90}, Strategy: "reversal", Time: at},
+			Events: []domain.SignalEvent{{Type: domain.SignalStopLoss, Price: 110}},
+		},
+	}
+	h := NewHandler(bot, &config.Config{UserIDs: []int64{7}}, nil, WithSignalStats(usecase.NewSignalUsecase(store)))
+
+	h.handleMessage(context.Background(), commandMessage(7, "/signals stats 2026-01-01:2026-01-31 breakout"))
+	h.handleMessage(context.Background(), commandMessage(7, "/signals stats all"))
+	h.handleMessage(context.Background(), commandMessage(7, "/signals stats 2025-01-01:2025-01-31"))
+	h.handleMessage(context.Background(), commandMessage(7, "/signals stats all breakout extra"))
+
+	calls := fake.Calls("sendMessage")
+	require.Len(t, calls, 4)
+	require.Contains(t, calls[0].params.Get("text"), "Signals 2026-01-01 — 2026-01-31 · breakout\n1 signal:")
+	require.Contains(t, calls[0].params.Get("text"), "Average R: +2.00R")
+	require.Contains(t, calls[1].params.Get("text"), "Average R: +0.50R")
+	require.Contains(t, calls[1].params.Get("text"), "Worst: ETHUSDT -1.00R (1)")
+	require.Equal(t, "No signals from 2025-01-01 to 2025-01-31", calls[2].params.Get("text"))
+	require.Contains(t, calls[3].params.Get("text"), "Usage: /signals stats")
+}
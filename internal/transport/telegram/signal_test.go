@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/i18n"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram/format"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSignal(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	sig := domain.Signal{
+		Symbol: "BTCUSDT", Side: domain.SideLong, Entry: 64250.5, StopLoss: 63100, TakeProfits: []float64{66800, 68000},
+		Leverage: 5, Strategy: "breakout", Confidence: 0.72, Time: time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC),
+	}
+	m := formatSignal(enTr, enMoney, sig, kyiv).Render(format.ModeEntities)
+	require.Equal(t, `🟢 LONG BTCUSDT ×5
+Strategy: breakout · Confidence: 72%
+Entry: 64,250.50
+SL: 63,100.00 (-1.79%)
+TP1: 66,800.00 (+3.97%) · R/R 2.22
+TP2: 68,000.00 (+5.84%) · R/R 3.26
+🕒 2026-01-15 10:30 EET`, m.Text)
+	require.Equal(t, "bold", m.Entities[0].Type)
+	require.Equal(t, 3, m.Entities[0].Offset)
+	require.Equal(t, len("LONG BTCUSDT"), m.Entities[0].Length)
+
+	short := domain.Signal{Symbol: "PEPEUSDT", Side: domain.SideShort, Entry: 0.00001234, StopLoss: 0.0000128,
+		TakeProfits: []float64{0.0000115}, Time: sig.Time}
+	uk := formatSignal(i18n.Default().Localizer("uk"), newMoneyFormat(&config.Config{NumberLocale: "uk"}), short, time.UTC).String()
+	require.Equal(t, `🔴 ШОРТ PEPEUSDT
+Вхід: 0,00001234
+SL: 0,00001280 (+3,73%)
+TP: 0,00001150 (-6,81%) · R/R 1,83
+🕒 2026-01-15 08:30 UTC`, uk)
+}
+
+func TestPriceDecimals(t *testing.T) {
+	require.Equal(t, 2, priceDecimals(64250.5))
+	require.Equal(t, 2, priceDecimals(312.4))
+	require.Equal(t, 4, priceDecimals(1.2345))
+	require.Equal(t, 5, priceDecimals(0.5))
+	require.Equal(t, 8, priceDecimals(0.00001234))
+}
+
+type memSignals map[string]domain.SignalRecord
+
+func (m memSignals) Signal(id string) (domain.SignalRecord, bool, error) {
+	rec, ok := m[id]
+	return rec, ok, nil
+}
+
+func (m memSignals) Signals() ([]domain.SignalRecord, error) {
+	return slices.Collect(maps.Values(m)), nil
+}
+
+func (m memSignals) UpdateSignal(id string, fn func(*domain.SignalRecord)) error {
+	rec := m[id]
+	fn(&rec)
+	m[id] = rec
+	return nil
+}
+
+func testSignal() domain.Signal {
+	return domain.Signal{ID: "s1", Symbol: "BTCUSDT", Side: domain.SideLong, Entry: 100, StopLoss: 95,
+		TakeProfits: []float64{110, 120}, Time: time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)}
+}
+
+func TestHandler_signalLifecycle(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	store := memSignals{}
+	prefs := memPreferences{7: {Notify: []domain.NotificationCategory{domain.NotifySignals}}}
+	cfg := &config.Config{UserIDs: []int64{7}, SignalEditCards: true}
+	h := NewHandler(bot, cfg, nil, WithPreferences(prefs), WithSignals(store))
+
+	require.NoError(t, h.PostSignal(-100, "signals", testSignal(), nil))
+	require.Len(t, fake.Calls("sendMessage"), 2)
+	require.Len(t, store["s1"].Messages, 2)
+	group, ok := store["s1"].Message(-100)
+	require.True(t, ok)
+	dm, ok := store["s1"].Message(7)
+	require.True(t, ok)
+
+	// A redelivered signal is not posted again.
+	require.NoError(t, h.PostSignal(-100, "signals", testSignal(), nil))
+	require.Len(t, fake.Calls("sendMessage"), 2)
+	// Nor is one whose messages were archived.
+	archived := testSignal()
+	archived.ID = "s0"
+	store["s0"] = domain.SignalRecord{Signal: archived, Archived: true}
+	require.NoError(t, h.PostSignal(-100, "signals", archived, nil))
+	require.Len(t, fake.Calls("sendMessage"), 2)
+
+	tp1 := domain.SignalEvent{SignalID: "s1", Type: domain.SignalTakeProfit, Level: 1, Price: 110, Time: time.Now()}
+	require.NoError(t, h.PostSignalEvent(-100, "signals", tp1))
+	calls := fake.Calls("sendMessage")
+	require.Len(t, calls, 4)
+	require.Equal(t, "🎯 BTCUSDT: TP1 hit at 110.00 (+10.00%)", calls[2].params.Get("text"))
+	require.Equal(t, strconv.Itoa(group.MessageID), calls[2].params.Get("reply_to_message_id"))
+	require.Equal(t, "true", calls[2].params.Get("allow_sending_without_reply"))
+	require.Equal(t, strconv.Itoa(dm.MessageID), calls[3].params.Get("reply_to_message_id"))
+
+	edits := fake.Calls("editMessageText")
+	require.Len(t, edits, 2)
+	require.True(t, strings.HasSuffix(edits[0].params.Get("text"), "\nStatus: TP1 hit"))
+	require.Equal(t, strconv.Itoa(group.MessageID), edits[0].params.Get("message_id"))
+
+	// Duplicates are ignored; unknown levels are invalid.
+	require.NoError(t, h.PostSignalEvent(-100, "signals", tp1))
+	require.Len(t, fake.Calls("sendMessage"), 4)
+	err := h.PostSignalEvent(-100, "signals", domain.SignalEvent{SignalID: "s1", Type: domain.SignalTakeProfit, Level: 3, Price: 130})
+	require.ErrorIs(t, err, domain.ErrInvalidSignal)
+
+	require.NoError(t, h.PostSignalEvent(-100, "signals", domain.SignalEvent{SignalID: "s1", Type: domain.SignalStopLoss, Price: 95}))
+	require.Equal(t, "🛑 BTCUSDT: stopped out at 95.00 (-5.00%)", fake.Calls("sendMessage")[4].params.Get("text"))
+	require.Len(t, store["s1"].Events, 2)
+}
+
+func TestHandler_PostSignalEvent_unknown(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	h := NewHandler(bot, &config.Config{}, nil, WithSignals(memSignals{}))
+
+	ev := domain.SignalEvent{SignalID: "ghost", Type: domain.SignalClosed, Price: 1.5}
+	require.NoError(t, h.PostSignalEvent(-100, "signals", ev))
+	call := fake.Calls("sendMessage")[0]
+	require.Equal(t, "🏁 ghost: closed at 1.5000", call.params.Get("text"))
+	require.Empty(t, call.params.Get("reply_to_message_id"))
+
+	short := testSignal()
+	short.Side, short.StopLoss, short.TakeProfits = domain.SideShort, 105, []float64{90}
+	m := formatSignalEvent(i18n.Default().Localizer("ru"), enMoney, &short, domain.SignalEvent{SignalID: "s1", Type: domain.SignalTakeProfit, Level: 1, Price: 90})
+	require.Equal(t, "🎯 BTCUSDT: TP1 достигнут по 90.00 (+10.00%)", m.Text)
+	m = formatSignalEvent(enTr, enMoney, &short, domain.SignalEvent{SignalID: "s1", Type: domain.SignalCancelled})
+	require.Equal(t, "🚫 BTCUSDT: cancelled", m.Text)
+}
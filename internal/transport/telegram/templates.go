@@ -0,0 +1,342 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/transport/telegram/format"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Notification is a queue message ready to send.
+type Notification struct {
+	Text      string
+	ParseMode string // empty for plain text
+	Entities  []tgbotapi.MessageEntity
+	Silent    bool // deliver without a notification sound
+	// Raw marks Text as the producer's own markup: if Telegram cannot parse it, it is sent
+	// verbatim as plain text instead of with the markup stripped.
+	Raw bool
+}
+
+// notificationTemplate renders JSON payloads of one queue. Templates ending in ".html" use
+// html/template and the Telegram HTML parse mode; others are text/template sent with the
+// route's parse mode.
+type notificationTemplate struct {
+	path      string
+	parseMode string
+	tmpl      interface {
+		Execute(w io.Writer, data any) error
+	}
+	samples []json.RawMessage
+}
+
+// samplesPath returns the sample payloads file of a template: "signal.html" → "signal.samples.json".
+func samplesPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".samples.json"
+}
+
+// LoadTemplates parses the template of every route that has one and renders it against every
+// sample payload. The loaded set replaces the current one only if all templates are valid.
+func (h *Handler) LoadTemplates(routes []config.QueueConsumer) error {
+	set := make(map[string]*notificationTemplate, len(routes))
+	for _, r := range routes {
+		if r.Template == "" {
+			continue
+		}
+		t, err := h.loadTemplate(r.Template, r.ParseMode)
+		if err != nil {
+			return fmt.Errorf("template for %s: %w", r.QueueName, err)
+		}
+		set[r.QueueName] = t
+	}
+	h.templatesMu.Lock()
+	defer h.templatesMu.Unlock()
+	h.templates = set
+	return nil
+}
+
+func (h *Handler) loadTemplate(path, parseMode string) (*notificationTemplate, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	t := &notificationTemplate{path: path, parseMode: parseMode}
+	name := filepath.Base(path)
+	if filepath.Ext(path) == ".html" {
+		t.parseMode = tgbotapi.ModeHTML
+		t.tmpl, err = htmltemplate.New(name).Option("missingkey=error").Funcs(h.templateFuncs()).Parse(string(src))
+	} else {
+		t.tmpl, err = template.New(name).Option("missingkey=error").Funcs(h.templateFuncs()).Parse(string(src))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	b, err := os.ReadFile(samplesPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("samples: %w", err)
+	}
+	if err := json.Unmarshal(b, &t.samples); err != nil {
+		return nil, fmt.Errorf("samples: %w", err)
+	}
+	if len(t.samples) == 0 {
+		return nil, fmt.Errorf("samples: %s has no payloads", samplesPath(path))
+	}
+	for i, s := range t.samples {
+		if _, err := t.render(s); err != nil {
+			return nil, fmt.Errorf("sample %d: %w", i+1, err)
+		}
+	}
+	return t, nil
+}
+
+func (t *notificationTemplate) render(payload []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return "", fmt.Errorf("decode payload: %w", err)
+	}
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("execute: %w", err)
+	}
+	out := strings.TrimSpace(b.String())
+	if out == "" {
+		return "", errors.New("execute: empty output")
+	}
+	return out, nil
+}
+
+func (h *Handler) template(queue string) (*notificationTemplate, bool) {
+	h.templatesMu.RLock()
+	defer h.templatesMu.RUnlock()
+	t, ok := h.templates[queue]
+	return t, ok
+}
+
+// HasTemplate reports whether queue has a notification template.
+func (h *Handler) HasTemplate(queue string) bool {
+	_, ok := h.template(queue)
+	return ok
+}
+
+// RenderNotification renders payload with the template of queue. Without a template the payload
+// is sent as is in the route's parse mode, and verbatim as plain text if it does not parse. When
+// rendering fails it is sent as plain text, and the error is returned for logging.
+func (h *Handler) RenderNotification(queue string, payload []byte) (Notification, error) {
+	t, ok := h.template(queue)
+	if !ok {
+		return Notification{Text: string(payload), ParseMode: h.routeParseMode(queue), Raw: true}, nil
+	}
+	text, err := t.render(payload)
+	if err != nil {
+		return Notification{Text: string(payload)}, fmt.Errorf("template for %s: %w", queue, err)
+	}
+	return Notification{Text: text, ParseMode: t.parseMode}, nil
+}
+
+func (h *Handler) routeParseMode(queue string) string {
+	for _, r := range h.config().QueueConsumers {
+		if r.QueueName == queue {
+			return r.ParseMode
+		}
+	}
+	return ""
+}
+
+// SendNotification posts n to chatID, split if it is long and as plain text if Telegram cannot
+// parse its markup.
+func (h *Handler) SendNotification(chatID int64, n Notification) error {
+	if _, err := h.sendNotification(notificationMessage(chatID, n), n); err != nil {
+		return fmt.Errorf("telegram send notification: %w", err)
+	}
+	return nil
+}
+
+// templateFuncs are the helpers available to notification templates. Numbers may be JSON
+// numbers or numeric strings; times may be RFC 3339 strings or Unix seconds. md escapes text
+// for MarkdownV2 routes; html/template escapes on its own. list wraps a single value in a
+// list, for fields such as take_profit that may be either.
+func (h *Handler) templateFuncs() map[string]any {
+	return map[string]any{
+		"formatMoney": func(v any, currency string) (string, error) {
+			f, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			return h.money().Amount(f, currency), nil
+		},
+		"pct": func(v any) (string, error) {
+			f, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			return h.money().Percent(f, 2), nil
+		},
+		"sideEmoji": sideEmoji,
+		"md":        format.EscapeMarkdownV2,
+		"list":      toList,
+		"since": func(v any) (string, error) {
+			t, err := toTime(v)
+			if err != nil {
+				return "", err
+			}
+			return formatSince(time.Since(t)), nil
+		},
+	}
+}
+
+// sideEmoji marks a trade side: green for buy/long, red for sell/short, white otherwise.
+func sideEmoji(side any) string {
+	switch strings.ToLower(fmt.Sprint(side)) {
+	case "buy", "long":
+		return "🟢"
+	case "sell", "short":
+		return "🔴"
+	default:
+		return "⚪"
+	}
+}
+
+// toList returns v if it is a list, nothing if it is null, and v as a one-item list otherwise.
+func toList(v any) []any {
+	switch x := v.(type) {
+	case []any:
+		return x
+	case nil:
+		return nil
+	default:
+		return []any{x}
+	}
+}
+
+// formatSince renders an elapsed duration in its two largest units, e.g. "2h 5m" or "3d 4h".
+func formatSince(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	days, hours, mins := int(d/(24*time.Hour)), int(d/time.Hour)%24, int(d/time.Minute)%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	default:
+		return fmt.Sprintf("%dm", mins)
+	}
+}
+
+func toFloat(v any) (float64, error) {
+	switch x := v.(type) {
+	case json.Number:
+		return x.Float64()
+	case float64:
+		return x, nil
+	case int:
+		return float64(x), nil
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", x)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
+
+func toTime(v any) (time.Time, error) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, x)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("not an RFC 3339 time: %q", x)
+		}
+		return t, nil
+	}
+	sec, err := toFloat(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not a time: %v", v)
+	}
+	return time.Unix(int64(sec), 0), nil
+}
+
+// cmdTemplate lists the notification templates, reloads them from disk, or previews one
+// rendered against its first sample or the JSON given after the queue name.
+func (h *Handler) cmdTemplate(_ context.Context, msg *tgbotapi.Message, args string) {
+	chatID := msg.Chat.ID
+	tr := h.tr(msg.From)
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		var lines []string
+		for _, r := range h.config().QueueConsumers {
+			t, ok := h.template(r.QueueName)
+			if !ok {
+				lines = append(lines, tr.T("template.none", "queue", r.QueueName))
+				continue
+			}
+			mode := "text"
+			if t.parseMode == tgbotapi.ModeHTML {
+				mode = "HTML"
+			}
+			lines = append(lines, tr.T("template.entry", "queue", r.QueueName, "path", t.path, "mode", mode))
+		}
+		h.replyBestEffort(chatID, strings.Join(lines, "\n"))
+	case fields[0] == "reload" && len(fields) == 1:
+		if err := h.LoadTemplates(h.config().QueueConsumers); err != nil {
+			h.replyBestEffort(chatID, tr.T("error", "err", err))
+			return
+		}
+		h.replyBestEffort(chatID, tr.T("template.reloaded"))
+	case fields[0] == "preview" && len(fields) >= 2:
+		t, ok := h.template(fields[1])
+		if !ok {
+			h.replyBestEffort(chatID, tr.T("template.unknown_queue", "queue", fields[1]))
+			return
+		}
+		payload := []byte(t.samples[0])
+		if rest := afterFields(args, 2); rest != "" {
+			payload = []byte(rest)
+		}
+		text, err := t.render(payload)
+		if err != nil {
+			h.replyBestEffort(chatID, tr.T("template.invalid", "err", err))
+			return
+		}
+		if err := h.SendNotification(chatID, Notification{Text: text, ParseMode: t.parseMode}); err != nil {
+			h.replyBestEffort(chatID, tr.T("template.invalid", "err", err))
+		}
+	default:
+		h.replyBestEffort(chatID, tr.T("template.usage"))
+	}
+}
+
+// afterFields returns s without its first n whitespace-separated fields, trimmed.
+func afterFields(s string, n int) string {
+	for range n {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		i := strings.IndexFunc(s, unicode.IsSpace)
+		if i < 0 {
+			return ""
+		}
+		s = s[i:]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/config"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, name, src, samples string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(src), 0o600))
+	require.NoError(t, os.WriteFile(samplesPath(path), []byte(samples), 0o600))
+	return path
+}
+
+func TestHandler_LoadTemplates(t *testing.T) {
+	h := NewHandler(nil, &config.Config{NumberLocale: "en"}, nil)
+
+	valid := writeTemplate(t, "signal.html",
+		`{{sideEmoji .side}} <b>{{.symbol}}</b> {{formatMoney .entry "USDT"}} {{pct .pct}} {{.note}}`,
+		`[{"side":"buy","symbol":"BTC","entry":64250.5,"pct":"3.5","note":"a<b"}]`)
+	routes := []config.QueueConsumer{{QueueName: "signals", Template: valid}, {QueueName: "system"}}
+	require.NoError(t, h.LoadTemplates(routes))
+
+	n, err := h.RenderNotification("signals", []byte(`{"side":"short","symbol":"ETH","entry":"3120","pct":-1,"note":"x&y"}`))
+	require.NoError(t, err)
+	require.Equal(t, Notification{Text: "🔴 <b>ETH</b> 3,120.00 USDT -1.00% x&amp;y", ParseMode: tgbotapi.ModeHTML}, n)
+
+	n, err = h.RenderNotification("system", []byte("disk full"))
+	require.NoError(t, err)
+	require.Equal(t, Notification{Text: "disk full", Raw: true}, n)
+
+	n, err = h.RenderNotification("signals", []byte(`{"side":"buy"}`))
+	require.ErrorContains(t, err, "symbol")
+	require.Equal(t, Notification{Text: `{"side":"buy"}`}, n)
+
+	missing := writeTemplate(t, "system.txt", `{{.level}}: {{.text}}`, `[{"level":"warn"}]`)
+	err = h.LoadTemplates([]config.QueueConsumer{{QueueName: "system", Template: missing}})
+	require.ErrorContains(t, err, "sample 1")
+	_, ok := h.template("signals")
+	require.True(t, ok, "failed load keeps the previous templates")
+
+	empty := writeTemplate(t, "empty.txt", `{{.text}}`, `[]`)
+	require.ErrorContains(t, h.LoadTemplates([]config.QueueConsumer{{QueueName: "system", Template: empty}}), "no payloads")
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	require.Equal(t, "🟢", sideEmoji("LONG"))
+	require.Equal(t, "⚪", sideEmoji(nil))
+	require.Equal(t, "<1m", formatSince(30*time.Second))
+	require.Equal(t, "5m", formatSince(5*time.Minute))
+	require.Equal(t, "2h 3m", formatSince(2*time.Hour+3*time.Minute))
+	require.Equal(t, "3d 4h", formatSince(76*time.Hour))
+	require.Equal(t, []any{1.5}, toList(1.5))
+	require.Equal(t, []any{1.5, 2.0}, toList([]any{1.5, 2.0}))
+	require.Empty(t, toList(nil))
+
+	at, err := toTime(float64(1700000000))
+	require.NoError(t, err)
+	require.Equal(t, int64(1700000000), at.Unix())
+	_, err = toTime("yesterday")
+	require.Error(t, err)
+}
+
+func TestHandler_templatePreviewPayload(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	path := writeTemplate(t, "view.txt", `{{.text}}`, `[{"text":"sample"}]`)
+	cfg := &config.Config{UserIDs: []int64{7}, QueueConsumers: []config.QueueConsumer{{QueueName: "view", Template: path}}}
+	h := NewHandler(bot, cfg, nil)
+	require.NoError(t, h.LoadTemplates(cfg.QueueConsumers))
+
+	// The queue name also occurs in "preview" and in the payload.
+	h.handleMessage(context.Background(), commandMessage(7, `/template preview view {"text":"view 2"}`))
+	require.Equal(t, "view 2", fake.Calls("sendMessage")[0].params.Get("text"))
+	h.handleMessage(context.Background(), commandMessage(7, "/template preview  view\n{\"text\": \"multi line\"}"))
+	require.Equal(t, "multi line", fake.Calls("sendMessage")[1].params.Get("text"))
+
+	require.Empty(t, afterFields("preview view  ", 2))
+	require.Equal(t, `{"a": 1}`, afterFields(` preview  view {"a": 1} `, 2))
+}
+
+func TestHandler_templateCommand(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	path := writeTemplate(t, "system.txt", `[{{.level}}] {{.text}}`, `[{"level":"warn","text":"sample"}]`)
+	cfg := &config.Config{UserIDs: []int64{7}, QueueConsumers: []config.QueueConsumer{
+		{QueueName: "system-queue", Template: path},
+		{QueueName: "pnl-reports-queue"},
+	}}
+	h := NewHandler(bot, cfg, nil)
+	require.NoError(t, h.LoadTemplates(cfg.QueueConsumers))
+
+	h.handleMessage(context.Background(), commandMessage(7, "/template"))
+	text := fake.Calls("sendMessage")[0].params.Get("text")
+	require.Contains(t, text, "system-queue: "+path+" (text)")
+	require.Contains(t, text, "pnl-reports-queue: no template")
+
+	h.handleMessage(context.Background(), commandMessage(7, "/template preview system-queue"))
+	require.Equal(t, "[warn] sample", fake.Calls("sendMessage")[1].params.Get("text"))
+
+	h.handleMessage(context.Background(), commandMessage(7, `/template preview system-queue {"level":"info","text":"custom"}`))
+	require.Equal(t, "[info] custom", fake.Calls("sendMessage")[2].params.Get("text"))
+
+	h.handleMessage(context.Background(), commandMessage(7, `/template preview system-queue {"level":"info"}`))
+	require.Contains(t, fake.Calls("sendMessage")[3].params.Get("text"), "Template preview failed")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{{.text}}!`), 0o600))
+	h.handleMessage(context.Background(), commandMessage(7, "/template reload"))
+	require.Equal(t, "Templates reloaded", fake.Calls("sendMessage")[4].params.Get("text"))
+	n, err := h.RenderNotification("system-queue", []byte(`{"text":"up"}`))
+	require.NoError(t, err)
+	require.Equal(t, "up!", n.Text)
+
+	h.handleMessage(context.Background(), commandMessage(8, "/template"))
+	require.Len(t, fake.Calls("sendMessage"), 6)
+	require.NotContains(t, fake.Calls("sendMessage")[5].params.Get("text"), "system-queue")
+}
+
+func TestShippedTemplates(t *testing.T) {
+	h := NewHandler(nil, &config.Config{}, nil)
+	require.NoError(t, h.LoadTemplates([]config.QueueConsumer{{QueueName: "signals", Template: "../../../templates/signal.html"}}))
+}
+
+func TestHandler_routeParseMode(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	cfg := &config.Config{QueueConsumers: []config.QueueConsumer{{QueueName: "system-queue", ParseMode: tgbotapi.ModeMarkdownV2}}}
+	h := NewHandler(bot, cfg, nil)
+	path := writeTemplate(t, "signal.txt", `*{{md .symbol}}* {{md .note}}`, `[{"symbol":"BTC_USDT","note":"+1.5%"}]`)
+	cfg.QueueConsumers = append(cfg.QueueConsumers, config.QueueConsumer{QueueName: "signals", Template: path, ParseMode: tgbotapi.ModeMarkdownV2})
+	require.NoError(t, h.LoadTemplates(cfg.QueueConsumers))
+
+	n, err := h.RenderNotification("system-queue", []byte("*up*"))
+	require.NoError(t, err)
+	require.Equal(t, Notification{Text: "*up*", ParseMode: tgbotapi.ModeMarkdownV2, Raw: true}, n)
+
+	n, err = h.RenderNotification("signals", []byte(`{"symbol":"PEPE_USDT","note":"x.y"}`))
+	require.NoError(t, err)
+	require.Equal(t, Notification{Text: `*PEPE\_USDT* x\.y`, ParseMode: tgbotapi.ModeMarkdownV2}, n)
+
+	require.NoError(t, h.SendNotification(-100, n))
+	fake.mu.Lock()
+	fake.rejectMarkup = true
+	fake.mu.Unlock()
+	require.NoError(t, h.SendNotification(-100, Notification{Text: "<b>BTC</b> &amp; <i>ETH", ParseMode: tgbotapi.ModeHTML}))
+
+	calls := fake.Calls("sendMessage")
+	require.Len(t, calls, 3)
+	require.Equal(t, tgbotapi.ModeMarkdownV2, calls[0].params.Get("parse_mode"))
+	require.Equal(t, tgbotapi.ModeHTML, calls[1].params.Get("parse_mode"))
+	require.Empty(t, calls[2].params.Get("parse_mode"))
+	require.Equal(t, "BTC & ETH", calls[2].params.Get("text"))
+
+	// Raw payloads that do not parse keep every character.
+	raw, err := h.RenderNotification("system-queue", []byte("1000_PEPE_USDT *halted* at 2*3"))
+	require.NoError(t, err)
+	require.NoError(t, h.SendNotification(-100, raw))
+	calls = fake.Calls("sendMessage")
+	require.Len(t, calls, 5)
+	require.Equal(t, tgbotapi.ModeMarkdownV2, calls[3].params.Get("parse_mode"))
+	require.Empty(t, calls[4].params.Get("parse_mode"))
+	require.Equal(t, "1000_PEPE_USDT *halted* at 2*3", calls[4].params.Get("text"))
+}
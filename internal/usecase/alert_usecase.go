@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+const (
+	// MaxAlertsPerUser bounds how many alerts one user may define.
+	MaxAlertsPerUser = 20
+	// defaultAlertInterval is how often alerts are evaluated; see WithAlertInterval.
+	defaultAlertInterval = time.Minute
+	// defaultAlertHysteresis is the re-arm band as a fraction of the threshold; see WithAlertHysteresis.
+	defaultAlertHysteresis = 0.1
+)
+
+// ErrTooManyAlerts is returned when a user already has MaxAlertsPerUser alerts.
+var ErrTooManyAlerts = errors.New("too many alerts")
+
+// errNoValue skips an alert whose metric is currently unknown, e.g. the price of a symbol
+// without an open position.
+var errNoValue = errors.New("metric not available")
+
+// AlertUsecase manages user alerts and evaluates them against the report and portfolio ports.
+type AlertUsecase struct {
+	store      ports.AlertStore
+	reports    ports.ReportFetcher
+	portfolio  ports.PortfolioFetcher
+	clock      ports.Clock
+	logger     ports.Logger
+	interval   time.Duration
+	hysteresis float64
+}
+
+// AlertOption configures an AlertUsecase.
+type AlertOption func(*AlertUsecase)
+
+// WithAlertInterval sets how often Run evaluates the alerts; the default is one minute.
+func WithAlertInterval(d time.Duration) AlertOption {
+	return func(u *AlertUsecase) { u.interval = d }
+}
+
+// WithAlertHysteresis sets how far past its threshold, as a fraction of the threshold, a metric
+// must move back before a fired alert re-arms; the default is 0.1.
+func WithAlertHysteresis(h float64) AlertOption {
+	return func(u *AlertUsecase) { u.hysteresis = h }
+}
+
+// WithAlertClock replaces the wall clock, for tests.
+func WithAlertClock(c ports.Clock) AlertOption {
+	return func(u *AlertUsecase) { u.clock = c }
+}
+
+// WithAlertLogger logs failed evaluations of Run to logger.
+func WithAlertLogger(logger ports.Logger) AlertOption {
+	return func(u *AlertUsecase) { u.logger = logger }
+}
+
+// NewAlertUsecase returns a use case keeping alerts in store and reading today's PnL from
+// reports and positions and balances from portfolio.
+func NewAlertUsecase(store ports.AlertStore, reports ports.ReportFetcher, portfolio ports.PortfolioFetcher, opts ...AlertOption) *AlertUsecase {
+	u := &AlertUsecase{
+		store:      store,
+		reports:    reports,
+		portfolio:  portfolio,
+		clock:      ports.SystemClock{},
+		interval:   defaultAlertInterval,
+		hysteresis: defaultAlertHysteresis,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// Add validates a and stores it under a new ID; the stored alert is returned.
+func (u *AlertUsecase) Add(a domain.Alert) (domain.Alert, error) {
+	if err := a.Validate(); err != nil {
+		return domain.Alert{}, err
+	}
+	own, err := u.List(a.UserID)
+	if err != nil {
+		return domain.Alert{}, err
+	}
+	if len(own) >= MaxAlertsPerUser {
+		return domain.Alert{}, ErrTooManyAlerts
+	}
+	a.ID, a.Created = newAlertID(), u.clock.Now().UTC()
+	a.Triggered, a.LastFired = false, time.Time{}
+	if err := u.store.AddAlert(a); err != nil {
+		return domain.Alert{}, fmt.Errorf("add alert: %w", err)
+	}
+	return a, nil
+}
+
+// List returns the alerts of userID in creation order.
+func (u *AlertUsecase) List(userID int64) ([]domain.Alert, error) {
+	all, err := u.store.Alerts()
+	if err != nil {
+		return nil, fmt.Errorf("list alerts: %w", err)
+	}
+	var out []domain.Alert
+	for _, a := range all {
+		if a.UserID == userID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// Delete removes alert id of userID; it reports false when userID has no such alert.
+func (u *AlertUsecase) Delete(userID int64, id string) (bool, error) {
+	own, err := u.List(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range own {
+		if a.ID == id {
+			ok, err := u.store.DeleteAlert(id)
+			if err != nil {
+				return false, fmt.Errorf("delete alert: %w", err)
+			}
+			return ok, nil
+		}
+	}
+	return false, nil
+}
+
+// Run evaluates the alerts every interval in a background goroutine until ctx is canceled.
+func (u *AlertUsecase) Run(ctx context.Context, n ports.AlertNotifier) {
+	go func() {
+		for {
+			if err := u.Evaluate(ctx, n); err != nil && u.logger != nil {
+				u.logger.Error("failed to evaluate alerts", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-u.clock.After(u.interval):
+			}
+		}
+	}()
+}
+
+// Evaluate checks every alert once and notifies n of those that fire. Each account's data is
+// fetched at most once per evaluation. Alerts are saved only when their state changed, and a
+// fired alert only once n was notified, so a failed notification is retried next time. Alerts
+// whose data cannot be fetched are skipped and their errors returned joined.
+func (u *AlertUsecase) Evaluate(ctx context.Context, n ports.AlertNotifier) error {
+	alerts, err := u.store.Alerts()
+	if err != nil {
+		return fmt.Errorf("evaluate alerts: %w", err)
+	}
+	now := u.clock.Now()
+	src := &alertSource{u: u, now: now, positions: map[string]fetched[[]ports.PositionResult]{},
+		balances: map[string]fetched[[]ports.BalanceResult]{}, pnl: map[string]fetched[float64]{}}
+
+	var errs []error
+	for _, a := range alerts {
+		v, err := src.value(ctx, a)
+		if errors.Is(err, errNoValue) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("alert %s: %w", a.ID, err))
+			continue
+		}
+		next := a
+		fire := next.Evaluate(v, now, u.hysteresis)
+		if next.Triggered == a.Triggered && next.LastFired.Equal(a.LastFired) {
+			continue
+		}
+		if fire {
+			if err := n.NotifyAlert(next, v); err != nil {
+				errs = append(errs, fmt.Errorf("alert %s: notify: %w", a.ID, err))
+				continue
+			}
+		}
+		if _, err := u.store.UpdateAlert(a.ID, func(s *domain.Alert) {
+			s.Triggered, s.LastFired = next.Triggered, next.LastFired
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("alert %s: %w", a.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fetched is a cached port result.
+type fetched[T any] struct {
+	v   T
+	err error
+}
+
+// alertSource computes alert metrics for one evaluation, caching port results per account.
+type alertSource struct {
+	u         *AlertUsecase
+	now       time.Time
+	positions map[string]fetched[[]ports.PositionResult]
+	balances  map[string]fetched[[]ports.BalanceResult]
+	pnl       map[string]fetched[float64] // by account and time zone
+}
+
+func (s *alertSource) value(ctx context.Context, a domain.Alert) (float64, error) {
+	switch a.Metric {
+	case domain.AlertPnL:
+		return s.dailyPnL(ctx, a)
+	case domain.AlertUnrealized, domain.AlertLoss:
+		ps, err := s.openPositions(ctx, a.Account)
+		if err != nil {
+			return 0, err
+		}
+		var upnl float64
+		for _, p := range ps {
+			if a.Symbol == "" || strings.EqualFold(p.Symbol, a.Symbol) {
+				upnl += p.UnrealizedPnL
+			}
+		}
+		if a.Metric == domain.AlertLoss {
+			return -upnl, nil
+		}
+		return upnl, nil
+	case domain.AlertPrice:
+		ps, err := s.openPositions(ctx, a.Account)
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range ps {
+			if strings.EqualFold(p.Symbol, a.Symbol) && p.MarkPrice > 0 {
+				return p.MarkPrice, nil
+			}
+		}
+		return 0, errNoValue
+	}
+	return 0, errNoValue
+}
+
+// dailyPnL returns today's realized PnL of the account of a, in percent of the quote currency
+// balance at the start of the day when a is a percentage alert.
+func (s *alertSource) dailyPnL(ctx context.Context, a domain.Alert) (float64, error) {
+	pnl, err := s.realized(ctx, a.Account, a.Location())
+	if err != nil || !a.Percent {
+		return pnl, err
+	}
+	bs, err := s.accountBalances(ctx, a.Account)
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range bs {
+		if strings.EqualFold(b.Asset, a.Currency) {
+			if opening := b.Total - pnl; opening > 0 {
+				return pnl / opening * 100, nil
+			}
+		}
+	}
+	return 0, errNoValue
+}
+
+// realized returns the realized PnL of account today in loc.
+func (s *alertSource) realized(ctx context.Context, account string, loc *time.Location) (float64, error) {
+	key := account + "|" + loc.String()
+	if r, ok := s.pnl[key]; ok {
+		return r.v, r.err
+	}
+	var r fetched[float64]
+	today := s.now.In(loc).Format(time.DateOnly)
+	start, end, err := domain.Period{From: today, To: today, Location: loc}.Bounds()
+	if err != nil {
+		return 0, err
+	}
+	if rep, err := s.u.reports.FetchReport(ctx, account, start, end); err != nil {
+		r.err = fmt.Errorf("fetch report: %w", err)
+	} else {
+		r.v = rep.Income - rep.Expense
+	}
+	s.pnl[key] = r
+	return r.v, r.err
+}
+
+func (s *alertSource) accountBalances(ctx context.Context, account string) ([]ports.BalanceResult, error) {
+	r, ok := s.balances[account]
+	if !ok {
+		r.v, r.err = s.u.portfolio.FetchBalances(ctx, account)
+		if r.err != nil {
+			r.err = fmt.Errorf("fetch balances: %w", r.err)
+		}
+		s.balances[account] = r
+	}
+	return r.v, r.err
+}
+
+func (s *alertSource) openPositions(ctx context.Context, account string) ([]ports.PositionResult, error) {
+	r, ok := s.positions[account]
+	if !ok {
+		r.v, r.err = s.u.portfolio.FetchPositions(ctx, account)
+		if r.err != nil {
+			r.err = fmt.Errorf("fetch positions: %w", r.err)
+		}
+		s.positions[account] = r
+	}
+	return r.v, r.err
+}
+
+// newAlertID returns a random 32-bit hex ID, short enough for callback data.
+func newAlertID() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:]) //nolint:errcheck // crypto/rand.Read never fails
+	return hex.EncodeToString(b[:])
+}
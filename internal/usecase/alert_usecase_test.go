@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/stretchr/testify/require"
+)
+
+type memAlerts struct {
+	alerts  []domain.Alert
+	updates int
+}
+
+func (m *memAlerts) Alerts() ([]domain.Alert, error) {
+	return append([]domain.Alert(nil), m.alerts...), nil
+}
+
+func (m *memAlerts) AddAlert(a domain.Alert) error {
+	m.alerts = append(m.alerts, a)
+	return nil
+}
+
+func (m *memAlerts) DeleteAlert(id string) (bool, error) {
+	for i, a := range m.alerts {
+		if a.ID == id {
+			m.alerts = append(m.alerts[:i], m.alerts[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memAlerts) UpdateAlert(id string, fn func(*domain.Alert)) (bool, error) {
+	m.updates++
+	for i := range m.alerts {
+		if m.alerts[i].ID == id {
+			fn(&m.alerts[i])
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c *fixedClock) Now() time.Time                       { return c.now }
+func (c *fixedClock) After(time.Duration) <-chan time.Time { return make(chan time.Time) }
+
+type firedAlert struct {
+	id    string
+	value float64
+}
+
+type recordingNotifier []firedAlert
+
+func (r *recordingNotifier) NotifyAlert(a domain.Alert, value float64) error {
+	*r = append(*r, firedAlert{a.ID, value})
+	return nil
+}
+
+type failingNotifier struct{ err error }
+
+func (f failingNotifier) NotifyAlert(domain.Alert, float64) error { return f.err }
+
+func TestAlertUsecase_manage(t *testing.T) {
+	store := &memAlerts{}
+	clock := &fixedClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	uc := NewAlertUsecase(store, nil, nil, WithAlertClock(clock))
+
+	a, err := uc.Add(domain.Alert{UserID: 7, Metric: domain.AlertLoss, Symbol: "BTCUSDT", Op: domain.AlertAbove, Threshold: 500, Triggered: true})
+	require.NoError(t, err)
+	require.Len(t, a.ID, 8)
+	require.Equal(t, clock.now, a.Created)
+	require.False(t, a.Triggered)
+
+	_, err = uc.Add(domain.Alert{UserID: 7, Metric: "equity", Op: domain.AlertAbove})
+	require.ErrorIs(t, err, domain.ErrInvalidAlert)
+
+	for range MaxAlertsPerUser - 1 {
+		_, err = uc.Add(domain.Alert{UserID: 7, Metric: domain.AlertUnrealized, Op: domain.AlertBelow, Threshold: -100})
+		require.NoError(t, err)
+	}
+	_, err = uc.Add(domain.Alert{UserID: 7, Metric: domain.AlertUnrealized, Op: domain.AlertBelow, Threshold: -100})
+	require.ErrorIs(t, err, ErrTooManyAlerts)
+	_, err = uc.Add(domain.Alert{UserID: 8, Metric: domain.AlertUnrealized, Op: domain.AlertBelow, Threshold: -100})
+	require.NoError(t, err)
+
+	ok, err := uc.Delete(8, a.ID)
+	require.NoError(t, err)
+	require.False(t, ok, "only the owner may delete an alert")
+	ok, err = uc.Delete(7, a.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	own, err := uc.List(7)
+	require.NoError(t, err)
+	require.Len(t, own, MaxAlertsPerUser-1)
+}
+
+type countingReports struct {
+	mockReportFetcher
+	froms []time.Time
+}
+
+func (c *countingReports) FetchReport(ctx context.Context, account string, from, to time.Time) (*ports.ReportResult, error) {
+	c.froms = append(c.froms, from)
+	return c.mockReportFetcher.FetchReport(ctx, account, from, to)
+}
+
+func TestAlertUsecase_Evaluate(t *testing.T) {
+	reports := &countingReports{mockReportFetcher: mockReportFetcher{result: &ports.ReportResult{Income: 10, Expense: 40}}}
+	portfolio := &mockPortfolio{
+		positions: map[string][]ports.PositionResult{"main": {
+			{Symbol: "BTCUSDT", UnrealizedPnL: -600, MarkPrice: 61000},
+			{Symbol: "ETHUSDT", UnrealizedPnL: 150, MarkPrice: 3000},
+		}},
+		balances: map[string][]ports.BalanceResult{"main": {{Asset: "USDT", Total: 970}}},
+	}
+	store := &memAlerts{alerts: []domain.Alert{
+		{ID: "pct", Account: "main", Currency: "USDT", TimeZone: "Europe/Kyiv", Metric: domain.AlertPnL, Op: domain.AlertBelow, Threshold: -2, Percent: true},
+		{ID: "pnl", Account: "main", Metric: domain.AlertPnL, Op: domain.AlertBelow, Threshold: -50},
+		{ID: "loss", Account: "main", Metric: domain.AlertLoss, Symbol: "btcusdt", Op: domain.AlertAbove, Threshold: 500, Cooldown: time.Hour},
+		{ID: "upnl", Account: "main", Metric: domain.AlertUnrealized, Op: domain.AlertBelow, Threshold: -400},
+		{ID: "price", Account: "main", Metric: domain.AlertPrice, Symbol: "SOLUSDT", Op: domain.AlertAbove, Threshold: 100},
+		{ID: "gone", Account: "alt", Metric: domain.AlertUnrealized, Op: domain.AlertBelow, Threshold: -1},
+	}}
+	clock := &fixedClock{now: time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)}
+	uc := NewAlertUsecase(store, reports, portfolio, WithAlertClock(clock))
+	ctx := context.Background()
+
+	var fired recordingNotifier
+	err := uc.Evaluate(ctx, &fired)
+	require.ErrorContains(t, err, "alert gone: fetch positions: unknown account")
+	require.Equal(t, recordingNotifier{{"pct", -3}, {"loss", 600}, {"upnl", -450}}, fired)
+	// Today in Kyiv started at 22:00 UTC; reports are fetched once per account and zone.
+	require.Equal(t, []time.Time{
+		time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC).Add(-2 * time.Hour),
+		time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}, []time.Time{reports.froms[0].UTC(), reports.froms[1].UTC()})
+
+	require.Equal(t, 3, store.updates, "only fired alerts are saved")
+
+	// Fired alerts stay quiet while their condition holds, and nothing is saved.
+	fired = nil
+	_, err = uc.store.DeleteAlert("gone")
+	require.NoError(t, err)
+	require.NoError(t, uc.Evaluate(ctx, &fired))
+	require.Empty(t, fired)
+	require.Equal(t, 3, store.updates)
+
+	// Once recovered past the band, an alert fires again; the loss alert is still cooling down.
+	portfolio.positions["main"][0].UnrealizedPnL = -400
+	clock.now = clock.now.Add(time.Minute)
+	require.NoError(t, uc.Evaluate(ctx, &fired))
+	portfolio.positions["main"][0].UnrealizedPnL = -700
+	clock.now = clock.now.Add(time.Minute)
+	require.NoError(t, uc.Evaluate(ctx, &fired))
+	require.Equal(t, recordingNotifier{{"upnl", -550}}, fired)
+
+	reports.err = errors.New("core down")
+	require.ErrorContains(t, uc.Evaluate(ctx, &fired), "alert pct: fetch report: core down")
+}
+
+func TestAlertUsecase_Evaluate_notifyFailure(t *testing.T) {
+	portfolio := &mockPortfolio{positions: map[string][]ports.PositionResult{"main": {{Symbol: "BTCUSDT", UnrealizedPnL: -600}}}}
+	store := &memAlerts{alerts: []domain.Alert{
+		{ID: "loss", Account: "main", Metric: domain.AlertLoss, Op: domain.AlertAbove, Threshold: 500},
+	}}
+	uc := NewAlertUsecase(store, nil, portfolio, WithAlertClock(&fixedClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}))
+	ctx := context.Background()
+
+	// An alert whose notification failed is not saved as fired and fires again next time.
+	err := uc.Evaluate(ctx, failingNotifier{errors.New("telegram: blocked")})
+	require.ErrorContains(t, err, "alert loss: notify: telegram: blocked")
+	require.False(t, store.alerts[0].Triggered)
+	require.Zero(t, store.updates)
+
+	var fired recordingNotifier
+	require.NoError(t, uc.Evaluate(ctx, &fired))
+	require.Equal(t, recordingNotifier{{"loss", 600}}, fired)
+	require.True(t, store.alerts[0].Triggered)
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// controlMethod is the trading core's remote procedure for control requests.
+const controlMethod = "control"
+
+// ControlUsecase is a ports.CoreController that calls the trading core's "control" procedure
+// through an RPC port.
+type ControlUsecase struct {
+	rpc ports.RPCClient
+}
+
+// NewControlUsecase returns a use case backed by rpc.
+func NewControlUsecase(rpc ports.RPCClient) *ControlUsecase {
+	return &ControlUsecase{rpc: rpc}
+}
+
+// Control implements ports.CoreController. A reply for another request is an error.
+func (c *ControlUsecase) Control(ctx context.Context, req domain.ControlRequest) (domain.ControlReply, error) {
+	var reply domain.ControlReply
+	if err := c.rpc.Call(ctx, controlMethod, req, &reply); err != nil {
+		return domain.ControlReply{}, fmt.Errorf("control %s: %w", req.Action, err)
+	}
+	if reply.RequestID == "" {
+		reply.RequestID = req.ID
+	}
+	if reply.RequestID != req.ID {
+		return domain.ControlReply{}, fmt.Errorf("control %s: reply for request %s, want %s", req.Action, reply.RequestID, req.ID)
+	}
+	return reply, nil
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRPC answers every call with reply, or fails it with err.
+type mockRPC struct {
+	method string
+	params any
+	reply  string
+	err    error
+}
+
+func (m *mockRPC) Call(_ context.Context, method string, params, result any) error {
+	m.method, m.params = method, params
+	if m.err != nil {
+		return m.err
+	}
+	return json.Unmarshal([]byte(m.reply), result)
+}
+
+func TestControlUsecase_Control(t *testing.T) {
+	req := domain.ControlRequest{ID: "r1", Action: domain.ControlPause, Strategy: "scalper"}
+
+	rpc := &mockRPC{reply: `{"ok":true,"paused_strategies":["scalper"],"open_positions":3}`}
+	got, err := NewControlUsecase(rpc).Control(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "control", rpc.method)
+	require.Equal(t, req, rpc.params)
+	require.Equal(t, domain.ControlReply{RequestID: "r1", OK: true, PausedStrategies: []string{"scalper"}, OpenPositions: 3}, got)
+
+	rpc.reply = `{"request_id":"r0","ok":true}`
+	_, err = NewControlUsecase(rpc).Control(context.Background(), req)
+	require.ErrorContains(t, err, "reply for request r0")
+
+	rpc.err = &ports.RemoteError{Method: "control", Message: "unknown strategy"}
+	_, err = NewControlUsecase(rpc).Control(context.Background(), req)
+	var remote *ports.RemoteError
+	require.ErrorAs(t, err, &remote)
+	require.Equal(t, "control pause: control: remote error: unknown strategy", err.Error())
+}
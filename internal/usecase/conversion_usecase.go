@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// ConversionUsecase converts report totals to another currency through a rates port.
+type ConversionUsecase struct {
+	rates ports.RateFetcher
+}
+
+// NewConversionUsecase returns a use case backed by rates.
+func NewConversionUsecase(rates ports.RateFetcher) *ConversionUsecase {
+	return &ConversionUsecase{rates: rates}
+}
+
+// Convert returns reps with amounts converted to currency, fetching each rate once. Reports of
+// an unknown currency (the default account) are kept as they are. Aggregates over AllAccounts
+// that end up in the same currency are merged.
+func (c *ConversionUsecase) Convert(ctx context.Context, reps []domain.Report, currency string) ([]domain.Report, error) {
+	rates := map[string]float64{}
+	out := make([]domain.Report, 0, len(reps))
+	merge := len(reps) > 0
+	for _, r := range reps {
+		merge = merge && r.Account == domain.AllAccounts
+		if r.Currency == "" || r.Currency == currency {
+			out = append(out, r)
+			continue
+		}
+		rate, ok := rates[r.Currency]
+		if !ok {
+			var err error
+			if rate, err = c.rates.FetchRate(ctx, r.Currency, currency); err != nil {
+				return nil, fmt.Errorf("convert %s to %s: %w", r.Currency, currency, err)
+			}
+			rates[r.Currency] = rate
+		}
+		r.Income = domain.AmountOf(r.Income * rate).Float64()
+		r.Expense = domain.AmountOf(r.Expense * rate).Float64()
+		r.Currency = currency
+		out = append(out, r)
+	}
+	if merge {
+		return domain.AggregateByCurrency(out), nil
+	}
+	return out, nil
+}
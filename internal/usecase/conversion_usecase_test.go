@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRates struct {
+	rates map[string]float64
+	calls int
+}
+
+func (m *mockRates) FetchRate(_ context.Context, base, quote string) (float64, error) {
+	m.calls++
+	r, ok := m.rates[base+"/"+quote]
+	if !ok {
+		return 0, errors.New("no rate")
+	}
+	return r, nil
+}
+
+func TestConversionUsecase_Convert(t *testing.T) {
+	t.Run("single reports", func(t *testing.T) {
+		rates := &mockRates{rates: map[string]float64{"USDT/EUR": 0.9}}
+		uc := NewConversionUsecase(rates)
+
+		got, err := uc.Convert(context.Background(), []domain.Report{
+			{Account: "a", Currency: "USDT", Income: 100, Expense: 10},
+			{Account: "b", Currency: "USDT", Income: 0.1, Expense: 0.2},
+			{Account: "c", Currency: "EUR", Income: 5},
+			{Income: 7},
+		}, "EUR")
+		require.NoError(t, err)
+		require.Equal(t, []domain.Report{
+			{Account: "a", Currency: "EUR", Income: 90, Expense: 9},
+			{Account: "b", Currency: "EUR", Income: 0.09, Expense: 0.18},
+			{Account: "c", Currency: "EUR", Income: 5},
+			{Income: 7},
+		}, got)
+		require.Equal(t, 1, rates.calls)
+	})
+
+	t.Run("aggregates merged", func(t *testing.T) {
+		uc := NewConversionUsecase(&mockRates{rates: map[string]float64{"USDT/USD": 1, "BTC/USD": 50000}})
+
+		got, err := uc.Convert(context.Background(), []domain.Report{
+			{Account: domain.AllAccounts, Currency: "BTC", Income: 0.01},
+			{Account: domain.AllAccounts, Currency: "USDT", Income: 100, Expense: 50},
+		}, "USD")
+		require.NoError(t, err)
+		require.Equal(t, []domain.Report{{Account: domain.AllAccounts, Currency: "USD", Income: 600, Expense: 50}}, got)
+	})
+
+	t.Run("missing rate", func(t *testing.T) {
+		uc := NewConversionUsecase(&mockRates{})
+		_, err := uc.Convert(context.Background(), []domain.Report{{Currency: "USDT"}}, "EUR")
+		require.ErrorContains(t, err, "convert USDT to EUR")
+	})
+}
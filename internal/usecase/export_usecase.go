@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// ExportKind selects which rows an export contains.
+type ExportKind string
+
+// Export kinds.
+const (
+	ExportTrades ExportKind = "trades"
+	ExportDays   ExportKind = "days"
+)
+
+// ErrUnknownExportKind is returned for kinds other than ExportTrades and ExportDays.
+var ErrUnknownExportKind = errors.New("unknown export kind")
+
+var (
+	tradeHeader = []string{"id", "symbol", "side", "opened_at", "closed_at", "quantity", "entry_price", "exit_price", "fee", "pnl"}
+	dayHeader   = []string{"date", "pnl", "cumulative_pnl"}
+)
+
+// ExportResult is a finished export; Cleanup must be called once the files are delivered.
+type ExportResult struct {
+	Files []ports.ExportFile
+	table ports.TableWriter
+}
+
+// Cleanup removes the exported files from disk.
+func (e *ExportResult) Cleanup() error {
+	if err := e.table.Remove(); err != nil {
+		return fmt.Errorf("cleanup export: %w", err)
+	}
+	return nil
+}
+
+// ExportUsecase streams report rows into export documents.
+type ExportUsecase struct {
+	trades ports.TradeFetcher
+	series ports.ReportSeriesFetcher
+	tables ports.TableWriterFactory
+}
+
+// NewExportUsecase returns a use case reading trades and daily PnL and writing via tables.
+func NewExportUsecase(trades ports.TradeFetcher, series ports.ReportSeriesFetcher, tables ports.TableWriterFactory) *ExportUsecase {
+	return &ExportUsecase{trades: trades, series: series, tables: tables}
+}
+
+// Export writes kind rows of acct for the period in format and returns the produced files.
+// An empty period yields ErrNoData.
+func (e *ExportUsecase) Export(ctx context.Context, acct domain.Account, kind ExportKind, format string, p domain.Period) (*ExportResult, error) {
+	start, end, err := p.Bounds()
+	if err != nil {
+		return nil, err
+	}
+	var header []string
+	switch kind {
+	case ExportTrades:
+		header = tradeHeader
+	case ExportDays:
+		header = dayHeader
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExportKind, kind)
+	}
+
+	name := fmt.Sprintf("%s_%s_%s", kind, p.From, p.To)
+	if acct.ID != "" {
+		name = acct.ID + "_" + name
+	}
+	table, err := e.tables.NewTableWriter(format, name, header)
+	if err != nil {
+		return nil, fmt.Errorf("open export: %w", err)
+	}
+
+	rows, err := e.writeRows(ctx, table, acct.ID, kind, start, end)
+	if err == nil && rows == 0 {
+		err = ErrNoData
+	}
+	var files []ports.ExportFile
+	if err == nil {
+		files, err = table.Close()
+	}
+	if err != nil {
+		_ = table.Remove() //nolint:errcheck // the export is discarded anyway
+		return nil, err
+	}
+	return &ExportResult{Files: files, table: table}, nil
+}
+
+func (e *ExportUsecase) writeRows(ctx context.Context, table ports.TableWriter, account string, kind ExportKind, from, to time.Time) (int, error) {
+	rows := 0
+	if kind == ExportTrades {
+		err := e.trades.FetchTrades(ctx, account, from, to, func(t ports.Trade) error {
+			rows++
+			return table.WriteRow(t.ID, t.Symbol, t.Side, t.OpenedAt, t.ClosedAt, t.Quantity, t.EntryPrice, t.ExitPrice, t.Fee, t.PnL) //nolint:wrapcheck // wrapped below
+		})
+		if err != nil {
+			return rows, fmt.Errorf("export trades: %w", err)
+		}
+		return rows, nil
+	}
+
+	points, err := e.series.FetchDailyPnL(ctx, account, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("export days: %w", err)
+	}
+	var cum domain.Amount
+	for _, p := range points {
+		cum += domain.AmountOf(p.PnL)
+		if err := table.WriteRow(p.Date, p.PnL, cum.Float64()); err != nil {
+			return rows, fmt.Errorf("export days: %w", err)
+		}
+		rows++
+	}
+	return rows, nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTradeFetcher struct {
+	trades []ports.Trade
+	err    error
+}
+
+func (m *mockTradeFetcher) FetchTrades(_ context.Context, _ string, _, _ time.Time, fn func(ports.Trade) error) error {
+	for _, t := range m.trades {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return m.err
+}
+
+type memTable struct {
+	format, name string
+	header       []string
+	rows         [][]any
+	removed      bool
+}
+
+func (m *memTable) WriteRow(cells ...any) error {
+	m.rows = append(m.rows, cells)
+	return nil
+}
+
+func (m *memTable) Close() ([]ports.ExportFile, error) {
+	return []ports.ExportFile{{Name: m.name + "." + m.format}}, nil
+}
+
+func (m *memTable) Remove() error {
+	m.removed = true
+	return nil
+}
+
+type memTables struct {
+	last *memTable
+}
+
+func (m *memTables) NewTableWriter(format, name string, header []string) (ports.TableWriter, error) {
+	m.last = &memTable{format: format, name: name, header: header}
+	return m.last, nil
+}
+
+func TestExportUsecase_Export(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("days with cumulative pnl", func(t *testing.T) {
+		tables := &memTables{}
+		series := &mockSeriesFetcher{points: []ports.PnLPoint{{Date: "2020-01-01", PnL: 5}, {Date: "2020-01-02", PnL: -2}}}
+		uc := NewExportUsecase(nil, series, tables)
+
+		res, err := uc.Export(ctx, domain.Account{}, ExportDays, "csv", period("2020-01-01", "2020-01-02"))
+		require.NoError(t, err)
+		require.Equal(t, "days_2020-01-01_2020-01-02.csv", res.Files[0].Name)
+		require.Equal(t, dayHeader, tables.last.header)
+		require.Equal(t, [][]any{{"2020-01-01", 5.0, 5.0}, {"2020-01-02", -2.0, 3.0}}, tables.last.rows)
+
+		require.NoError(t, res.Cleanup())
+		require.True(t, tables.last.removed)
+	})
+
+	t.Run("trades", func(t *testing.T) {
+		tables := &memTables{}
+		trades := &mockTradeFetcher{trades: []ports.Trade{{ID: "1", Symbol: "BTCUSDT", PnL: 3}}}
+		uc := NewExportUsecase(trades, nil, tables)
+
+		res, err := uc.Export(ctx, domain.Account{ID: "main"}, ExportTrades, "xlsx", period("2020-01-01", "2020-01-02"))
+		require.NoError(t, err)
+		require.Equal(t, "main_trades_2020-01-01_2020-01-02.xlsx", res.Files[0].Name)
+		require.Len(t, tables.last.rows, 1)
+		require.Equal(t, "BTCUSDT", tables.last.rows[0][1])
+	})
+
+	t.Run("empty range is removed", func(t *testing.T) {
+		tables := &memTables{}
+		uc := NewExportUsecase(&mockTradeFetcher{}, nil, tables)
+
+		_, err := uc.Export(ctx, domain.Account{}, ExportTrades, "csv", period("2020-01-01", "2020-01-02"))
+		require.ErrorIs(t, err, ErrNoData)
+		require.True(t, tables.last.removed)
+	})
+
+	t.Run("fetch error is removed", func(t *testing.T) {
+		tables := &memTables{}
+		uc := NewExportUsecase(&mockTradeFetcher{trades: []ports.Trade{{ID: "1"}}, err: errors.New("boom")}, nil, tables)
+
+		_, err := uc.Export(ctx, domain.Account{}, ExportTrades, "csv", period("2020-01-01", "2020-01-02"))
+		require.ErrorContains(t, err, "boom")
+		require.True(t, tables.last.removed)
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		uc := NewExportUsecase(nil, nil, &memTables{})
+		_, err := uc.Export(ctx, domain.Account{}, "orders", "csv", period("2020-01-01", "2020-01-02"))
+		require.ErrorIs(t, err, ErrUnknownExportKind)
+	})
+}
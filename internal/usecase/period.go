@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the calendar date format exchanged with the reports API.
+const DateLayout = "2006-01-02"
+
+// ErrInvalidRange is returned when a range ends before it starts.
+var ErrInvalidRange = errors.New("invalid range")
+
+// PreviousDay returns the calendar day before at, in at's location, as an inclusive range.
+func PreviousDay(at time.Time) (from, to string) {
+	d := at.AddDate(0, 0, -1).Format(DateLayout)
+	return d, d
+}
+
+// PreviousWeek returns the seven calendar days ending the day before at, as an inclusive range.
+func PreviousWeek(at time.Time) (from, to string) {
+	return at.AddDate(0, 0, -7).Format(DateLayout), at.AddDate(0, 0, -1).Format(DateLayout)
+}
+
+// PrecedingRange returns the range of equal length that ends the day before from.
+func PrecedingRange(from, to string) (pFrom, pTo string, err error) {
+	f, t, err := parseRange(from, to)
+	if err != nil {
+		return "", "", err
+	}
+	days := int(t.Sub(f).Hours()/24) + 1
+	end := f.AddDate(0, 0, -1)
+	return end.AddDate(0, 0, -(days - 1)).Format(DateLayout), end.Format(DateLayout), nil
+}
+
+// YearAgoRange returns the same calendar range one year earlier; Feb 29 maps to Feb 28.
+func YearAgoRange(from, to string) (pFrom, pTo string, err error) {
+	f, t, err := parseRange(from, to)
+	if err != nil {
+		return "", "", err
+	}
+	return yearAgo(f).Format(DateLayout), yearAgo(t).Format(DateLayout), nil
+}
+
+func yearAgo(d time.Time) time.Time {
+	y := time.Date(d.Year()-1, d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	if y.Month() != d.Month() {
+		// Feb 29 normalized into March; step back to the last day of February.
+		y = y.AddDate(0, 0, -y.Day())
+	}
+	return y
+}
+
+func parseRange(from, to string) (f, t time.Time, err error) {
+	if f, err = time.Parse(DateLayout, from); err != nil {
+		return f, t, fmt.Errorf("invalid from date: %w", err)
+	}
+	if t, err = time.Parse(DateLayout, to); err != nil {
+		return f, t, fmt.Errorf("invalid to date: %w", err)
+	}
+	if t.Before(f) {
+		return f, t, fmt.Errorf("%w: %s is after %s", ErrInvalidRange, from, to)
+	}
+	return f, t, nil
+}
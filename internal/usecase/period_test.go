@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviousDayAndWeek(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	require.NoError(t, err)
+	// Monday 08:00 in Warsaw is still Monday locally but Monday 06:00 UTC.
+	at := time.Date(2024, 3, 11, 8, 0, 0, 0, warsaw)
+
+	from, to := PreviousDay(at)
+	require.Equal(t, "2024-03-10", from)
+	require.Equal(t, "2024-03-10", to)
+
+	from, to = PreviousWeek(at)
+	require.Equal(t, "2024-03-04", from)
+	require.Equal(t, "2024-03-10", to)
+}
+
+func TestPrecedingAndYearAgoRange(t *testing.T) {
+	tests := []struct {
+		name             string
+		from, to         string
+		prevFrom, prevTo string
+		yoyFrom, yoyTo   string
+	}{
+		{"month", "2024-03-01", "2024-03-31", "2024-01-30", "2024-02-29", "2023-03-01", "2023-03-31"},
+		{"single day", "2024-01-01", "2024-01-01", "2023-12-31", "2023-12-31", "2023-01-01", "2023-01-01"},
+		{"leap day", "2024-02-01", "2024-02-29", "2024-01-03", "2024-01-31", "2023-02-01", "2023-02-28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, to, err := PrecedingRange(tt.from, tt.to)
+			require.NoError(t, err)
+			require.Equal(t, []string{tt.prevFrom, tt.prevTo}, []string{f, to})
+
+			f, to, err = YearAgoRange(tt.from, tt.to)
+			require.NoError(t, err)
+			require.Equal(t, []string{tt.yoyFrom, tt.yoyTo}, []string{f, to})
+		})
+	}
+
+	_, _, err := PrecedingRange("2024-03-31", "2024-03-01")
+	require.ErrorIs(t, err, ErrInvalidRange)
+	_, _, err = YearAgoRange("bad", "2024-03-01")
+	require.ErrorContains(t, err, "invalid from date")
+}
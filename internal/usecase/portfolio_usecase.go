@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// PortfolioUsecase loads the live positions and balances of accounts through a portfolio port.
+type PortfolioUsecase struct {
+	fetcher ports.PortfolioFetcher
+}
+
+// NewPortfolioUsecase returns a use case backed by fetcher.
+func NewPortfolioUsecase(fetcher ports.PortfolioFetcher) *PortfolioUsecase {
+	return &PortfolioUsecase{fetcher: fetcher}
+}
+
+// Positions returns the open positions of accts, fetched concurrently, in account order and
+// by symbol within an account.
+func (u *PortfolioUsecase) Positions(ctx context.Context, accts []domain.Account) ([]domain.Position, error) {
+	perAcct, err := fetchAll(ctx, accts, func(ctx context.Context, a domain.Account) ([]domain.Position, error) {
+		rows, err := u.fetcher.FetchPositions(ctx, a.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetch positions: %w", err)
+		}
+		out := make([]domain.Position, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, domain.Position{
+				Account: a.ID, Currency: a.Quote, Symbol: r.Symbol, Side: domain.ParseSide(r.Side), Size: math.Abs(r.Size),
+				EntryPrice: r.EntryPrice, MarkPrice: r.MarkPrice, UnrealizedPnL: r.UnrealizedPnL, LiquidationPrice: r.LiquidationPrice,
+			})
+		}
+		slices.SortFunc(out, func(x, y domain.Position) int {
+			return cmp.Or(strings.Compare(x.Symbol, y.Symbol), strings.Compare(string(x.Side), string(y.Side)))
+		})
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Concat(perAcct...), nil
+}
+
+// Balances returns the non-zero balances of accts, fetched concurrently, in account order and
+// by asset within an account.
+func (u *PortfolioUsecase) Balances(ctx context.Context, accts []domain.Account) ([]domain.Balance, error) {
+	perAcct, err := fetchAll(ctx, accts, func(ctx context.Context, a domain.Account) ([]domain.Balance, error) {
+		rows, err := u.fetcher.FetchBalances(ctx, a.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetch balances: %w", err)
+		}
+		out := make([]domain.Balance, 0, len(rows))
+		for _, r := range rows {
+			if r.Total == 0 && r.Available == 0 {
+				continue
+			}
+			out = append(out, domain.Balance{Account: a.ID, Asset: strings.ToUpper(r.Asset), Total: r.Total, Available: r.Available})
+		}
+		slices.SortFunc(out, func(x, y domain.Balance) int { return strings.Compare(x.Asset, y.Asset) })
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Concat(perAcct...), nil
+}
+
+// fetchAll runs fetch for every account concurrently and returns the results in account order.
+// The first failure cancels the other fetches.
+func fetchAll[T any](ctx context.Context, accts []domain.Account, fetch func(context.Context, domain.Account) (T, error)) ([]T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := make([]T, len(accts))
+	errs := make([]error, len(accts))
+	var wg sync.WaitGroup
+	for i, a := range accts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := fetch(ctx, a)
+			if err != nil {
+				errs[i] = fmt.Errorf("account %s: %w", a.Label(), err)
+				cancel()
+				return
+			}
+			out[i] = v
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPortfolio struct {
+	positions map[string][]ports.PositionResult
+	balances  map[string][]ports.BalanceResult
+}
+
+func (m *mockPortfolio) FetchPositions(_ context.Context, account string) ([]ports.PositionResult, error) {
+	rows, ok := m.positions[account]
+	if !ok {
+		return nil, errors.New("unknown account")
+	}
+	return rows, nil
+}
+
+func (m *mockPortfolio) FetchBalances(_ context.Context, account string) ([]ports.BalanceResult, error) {
+	rows, ok := m.balances[account]
+	if !ok {
+		return nil, errors.New("unknown account")
+	}
+	return rows, nil
+}
+
+func TestPortfolioUsecase(t *testing.T) {
+	main := domain.Account{ID: "main", Exchange: "binance", Quote: "USDT"}
+	alt := domain.Account{ID: "alt", Quote: "USDC"}
+	uc := NewPortfolioUsecase(&mockPortfolio{
+		positions: map[string][]ports.PositionResult{
+			"main": {
+				{Symbol: "SOLUSDT", Side: "sell", Size: -10, EntryPrice: 150, MarkPrice: 140, UnrealizedPnL: 100, LiquidationPrice: 175},
+				{Symbol: "BTCUSDT", Side: "long", Size: 0.5, EntryPrice: 60000, MarkPrice: 61000, UnrealizedPnL: 500, LiquidationPrice: 48800},
+			},
+			"alt": {{Symbol: "ETHUSDC", Side: "BUY", Size: 1, EntryPrice: 3000, MarkPrice: 2900, UnrealizedPnL: -100}},
+		},
+		balances: map[string][]ports.BalanceResult{
+			"main": {{Asset: "usdt", Total: 1000, Available: 600}, {Asset: "BNB", Total: 0}, {Asset: "BTC", Total: 0.1, Available: 0.1}},
+		},
+	})
+	ctx := context.Background()
+
+	got, err := uc.Positions(ctx, []domain.Account{main, alt})
+	require.NoError(t, err)
+	require.Equal(t, []domain.Position{
+		{Account: "main", Currency: "USDT", Symbol: "BTCUSDT", Side: domain.SideLong, Size: 0.5, EntryPrice: 60000, MarkPrice: 61000, UnrealizedPnL: 500, LiquidationPrice: 48800},
+		{Account: "main", Currency: "USDT", Symbol: "SOLUSDT", Side: domain.SideShort, Size: 10, EntryPrice: 150, MarkPrice: 140, UnrealizedPnL: 100, LiquidationPrice: 175},
+		{Account: "alt", Currency: "USDC", Symbol: "ETHUSDC", Side: domain.SideLong, Size: 1, EntryPrice: 3000, MarkPrice: 2900, UnrealizedPnL: -100},
+	}, got)
+	require.InDelta(t, 0.2, got[0].LiquidationDistance(), 1e-9)
+	require.InDelta(t, 0.25, got[1].LiquidationDistance(), 1e-9)
+	require.True(t, math.IsNaN(got[2].LiquidationDistance()))
+
+	balances, err := uc.Balances(ctx, []domain.Account{main})
+	require.NoError(t, err)
+	require.Equal(t, []domain.Balance{
+		{Account: "main", Asset: "BTC", Total: 0.1, Available: 0.1},
+		{Account: "main", Asset: "USDT", Total: 1000, Available: 600},
+	}, balances)
+
+	_, err = uc.Balances(ctx, []domain.Account{main, alt})
+	require.ErrorContains(t, err, "account alt: fetch balances: unknown account")
+}
@@ -2,35 +2,199 @@
 package usecase
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
 )
 
-// ReportUsecase loads reports through a ReportFetcher port.
+// ErrNoData is returned when a range has no data points to present.
+var ErrNoData = errors.New("no data for the selected range")
+
+// ReportUsecase loads reports through the report ports.
 type ReportUsecase struct {
-	fetcher ports.ReportFetcher
+	fetcher   ports.ReportFetcher
+	series    ports.ReportSeriesFetcher
+	breakdown ports.ReportBreakdownFetcher
+	charts    ports.ChartRenderer
 }
 
-// NewReportUsecase returns a use case backed by fetcher.
-func NewReportUsecase(fetcher ports.ReportFetcher) *ReportUsecase {
-	return &ReportUsecase{fetcher: fetcher}
+// NewReportUsecase returns a use case backed by fetcher for totals, series for daily PnL,
+// breakdown for per-symbol and per-strategy PnL and charts for rendering.
+func NewReportUsecase(fetcher ports.ReportFetcher, series ports.ReportSeriesFetcher, breakdown ports.ReportBreakdownFetcher, charts ports.ChartRenderer) *ReportUsecase {
+	return &ReportUsecase{fetcher: fetcher, series: series, breakdown: breakdown, charts: charts}
 }
 
-// GetReport validates date strings and returns a domain report for the inclusive range.
-func (r *ReportUsecase) GetReport(ctx context.Context, from, to string) (*domain.Report, error) {
-	if _, err := time.Parse("2006-01-02", from); err != nil {
-		return nil, fmt.Errorf("invalid from date: %w", err)
-	}
-	if _, err := time.Parse("2006-01-02", to); err != nil {
-		return nil, fmt.Errorf("invalid to date: %w", err)
+// GetReport validates the period and returns a domain report of acct for it.
+func (r *ReportUsecase) GetReport(ctx context.Context, acct domain.Account, p domain.Period) (*domain.Report, error) {
+	start, end, err := p.Bounds()
+	if err != nil {
+		return nil, err
 	}
-	resp, err := r.fetcher.FetchReport(ctx, from, to)
+	resp, err := r.fetcher.FetchReport(ctx, acct.ID, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("fetch report: %w", err)
 	}
-	return &domain.Report{From: from, To: to, Income: resp.Income, Expense: resp.Expense}, nil
+	return &domain.Report{
+		Account: acct.ID, Currency: acct.Quote, TimeZone: p.Zone(),
+		From: p.From, To: p.To, Income: resp.Income, Expense: resp.Expense,
+	}, nil
+}
+
+// GetAggregateReport fetches every account concurrently and returns one aggregate per quote currency.
+func (r *ReportUsecase) GetAggregateReport(ctx context.Context, accts []domain.Account, p domain.Period) ([]domain.Report, error) {
+	if _, _, err := p.Bounds(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reports := make([]domain.Report, len(accts))
+	errs := make([]error, len(accts))
+	var wg sync.WaitGroup
+	for i, a := range accts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rep, err := r.GetReport(ctx, a, p)
+			if err != nil {
+				errs[i] = fmt.Errorf("account %s: %w", a.Label(), err)
+				cancel()
+				return
+			}
+			reports[i] = *rep
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return domain.AggregateByCurrency(reports), nil
+}
+
+// GetDailyPnL returns the daily PnL series of acct for the period, days split in its time zone.
+func (r *ReportUsecase) GetDailyPnL(ctx context.Context, acct domain.Account, p domain.Period) ([]domain.DailyPnL, error) {
+	start, end, err := p.Bounds()
+	if err != nil {
+		return nil, err
+	}
+	points, err := r.series.FetchDailyPnL(ctx, acct.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("fetch daily pnl: %w", err)
+	}
+	out := make([]domain.DailyPnL, 0, len(points))
+	for _, p := range points {
+		out = append(out, domain.DailyPnL{Date: p.Date, PnL: p.PnL})
+	}
+	return out, nil
+}
+
+// GetPnLChart renders the cumulative PnL chart of acct for the period.
+func (r *ReportUsecase) GetPnLChart(ctx context.Context, acct domain.Account, p domain.Period) ([]byte, error) {
+	series, err := r.GetDailyPnL(ctx, acct, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, ErrNoData
+	}
+	img, err := r.charts.RenderPnL(series)
+	if err != nil {
+		return nil, fmt.Errorf("render chart: %w", err)
+	}
+	return img, nil
+}
+
+// GetBreakdown returns the per-symbol and per-strategy PnL of acct for the period,
+// each ordered by PnL, best first.
+func (r *ReportUsecase) GetBreakdown(ctx context.Context, acct domain.Account, p domain.Period) (*domain.Breakdown, error) {
+	start, end, err := p.Bounds()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.breakdown.FetchBreakdown(ctx, acct.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("fetch breakdown: %w", err)
+	}
+	if len(resp.Symbols) == 0 && len(resp.Strategies) == 0 {
+		return nil, ErrNoData
+	}
+	return &domain.Breakdown{
+		Account:    acct.ID,
+		Currency:   acct.Quote,
+		TimeZone:   p.Zone(),
+		From:       p.From,
+		To:         p.To,
+		Symbols:    pnlGroups(resp.Symbols),
+		Strategies: pnlGroups(resp.Strategies),
+	}, nil
+}
+
+func pnlGroups(rows []ports.PnLGroupResult) []domain.PnLGroup {
+	out := make([]domain.PnLGroup, 0, len(rows))
+	for _, g := range rows {
+		out = append(out, domain.PnLGroup(g))
+	}
+	slices.SortStableFunc(out, func(a, b domain.PnLGroup) int {
+		if c := cmp.Compare(b.PnL, a.PnL); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+// Compare fetches the report of acct for the period and for its reference period concurrently.
+func (r *ReportUsecase) Compare(ctx context.Context, acct domain.Account, p domain.Period, mode domain.ComparisonMode) (*domain.ReportComparison, error) {
+	ref := domain.Period{Location: p.Location}
+	var err error
+	switch mode {
+	case domain.ComparePrevious:
+		ref.From, ref.To, err = PrecedingRange(p.From, p.To)
+	case domain.CompareYearAgo:
+		ref.From, ref.To, err = YearAgoRange(p.From, p.To)
+	default:
+		return nil, fmt.Errorf("unknown comparison mode %q", mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		cur, prv *domain.Report
+		curErr   error
+		prvErr   error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if cur, curErr = r.GetReport(ctx, acct, p); curErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if prv, prvErr = r.GetReport(ctx, acct, ref); prvErr != nil {
+			cancel()
+		}
+	}()
+	wg.Wait()
+
+	if curErr != nil {
+		return nil, curErr
+	}
+	if prvErr != nil {
+		return nil, fmt.Errorf("reference period: %w", prvErr)
+	}
+	return &domain.ReportComparison{Mode: mode, Current: *cur, Previous: *prv}, nil
 }
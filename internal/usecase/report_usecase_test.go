@@ -3,7 +3,9 @@ package usecase
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
 	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
@@ -15,7 +17,7 @@ type mockReportFetcher struct {
 	err    error
 }
 
-func (m *mockReportFetcher) FetchReport(_ context.Context, _, _ string) (*ports.ReportResult, error) {
+func (m *mockReportFetcher) FetchReport(_ context.Context, _ string, _, _ time.Time) (*ports.ReportResult, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -73,8 +75,8 @@ func TestGetReport(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			uc := NewReportUsecase(tt.fetcher)
-			got, err := uc.GetReport(context.Background(), tt.from, tt.to)
+			uc := NewReportUsecase(tt.fetcher, nil, nil, nil)
+			got, err := uc.GetReport(context.Background(), domain.Account{}, period(tt.from, tt.to))
 			if tt.wantErr {
 				require.Error(t, err)
 				if tt.errContains != "" {
@@ -87,3 +89,225 @@ func TestGetReport(t *testing.T) {
 		})
 	}
 }
+
+type mockSeriesFetcher struct {
+	points []ports.PnLPoint
+	err    error
+}
+
+func (m *mockSeriesFetcher) FetchDailyPnL(_ context.Context, _ string, _, _ time.Time) ([]ports.PnLPoint, error) {
+	return m.points, m.err
+}
+
+type mockChartRenderer struct {
+	got []domain.DailyPnL
+}
+
+func (m *mockChartRenderer) RenderPnL(series []domain.DailyPnL) ([]byte, error) {
+	m.got = series
+	return []byte("png"), nil
+}
+
+func TestGetPnLChart(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		series := &mockSeriesFetcher{points: []ports.PnLPoint{{Date: "2020-01-01", PnL: 5}, {Date: "2020-01-02", PnL: -2}}}
+		charts := &mockChartRenderer{}
+		uc := NewReportUsecase(nil, series, nil, charts)
+
+		img, err := uc.GetPnLChart(context.Background(), domain.Account{}, period("2020-01-01", "2020-01-02"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("png"), img)
+		require.Equal(t, []domain.DailyPnL{{Date: "2020-01-01", PnL: 5}, {Date: "2020-01-02", PnL: -2}}, charts.got)
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		uc := NewReportUsecase(nil, &mockSeriesFetcher{}, nil, &mockChartRenderer{})
+		_, err := uc.GetPnLChart(context.Background(), domain.Account{}, period("2020-01-01", "2020-01-02"))
+		require.ErrorIs(t, err, ErrNoData)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		uc := NewReportUsecase(nil, &mockSeriesFetcher{}, nil, &mockChartRenderer{})
+		_, err := uc.GetPnLChart(context.Background(), domain.Account{}, period("x", "2020-01-02"))
+		require.ErrorContains(t, err, "invalid from date")
+	})
+
+	t.Run("fetch error", func(t *testing.T) {
+		uc := NewReportUsecase(nil, &mockSeriesFetcher{err: errors.New("boom")}, nil, &mockChartRenderer{})
+		_, err := uc.GetPnLChart(context.Background(), domain.Account{}, period("2020-01-01", "2020-01-02"))
+		require.ErrorContains(t, err, "boom")
+	})
+}
+
+type rangeFetcher struct {
+	mu      sync.Mutex
+	results map[string]*ports.ReportResult
+	calls   []string
+}
+
+func (m *rangeFetcher) FetchReport(_ context.Context, account string, from, to time.Time) (*ports.ReportResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := from.Format(time.DateOnly) + ".." + to.AddDate(0, 0, -1).Format(time.DateOnly)
+	if account != "" {
+		key = account + "@" + key
+	}
+	m.calls = append(m.calls, key)
+	res, ok := m.results[key]
+	if !ok {
+		return nil, errors.New("unexpected range " + key)
+	}
+	return res, nil
+}
+
+func TestCompare(t *testing.T) {
+	fetcher := &rangeFetcher{results: map[string]*ports.ReportResult{
+		"2024-02-01..2024-02-29": {Income: 120, Expense: 20},
+		"2024-01-03..2024-01-31": {Income: 100, Expense: 40},
+		"2023-02-01..2023-02-28": {Income: 50, Expense: 10},
+	}}
+	uc := NewReportUsecase(fetcher, nil, nil, nil)
+
+	got, err := uc.Compare(context.Background(), domain.Account{}, period("2024-02-01", "2024-02-29"), domain.ComparePrevious)
+	require.NoError(t, err)
+	require.Equal(t, &domain.ReportComparison{
+		Mode:     domain.ComparePrevious,
+		Current:  domain.Report{From: "2024-02-01", To: "2024-02-29", Income: 120, Expense: 20},
+		Previous: domain.Report{From: "2024-01-03", To: "2024-01-31", Income: 100, Expense: 40},
+	}, got)
+
+	got, err = uc.Compare(context.Background(), domain.Account{}, period("2024-02-01", "2024-02-29"), domain.CompareYearAgo)
+	require.NoError(t, err)
+	require.Equal(t, "2023-02-28", got.Previous.To)
+
+	_, err = uc.Compare(context.Background(), domain.Account{}, period("2024-03-01", "2024-03-31"), domain.ComparePrevious)
+	require.ErrorContains(t, err, "unexpected range")
+
+	_, err = uc.Compare(context.Background(), domain.Account{}, period("2024-03-01", "2024-03-31"), "week")
+	require.ErrorContains(t, err, "unknown comparison mode")
+}
+
+func TestGetAggregateReport(t *testing.T) {
+	fetcher := &rangeFetcher{results: map[string]*ports.ReportResult{
+		"a@2024-01-01..2024-01-31": {Income: 10, Expense: 1},
+		"b@2024-01-01..2024-01-31": {Income: 20, Expense: 2},
+		"c@2024-01-01..2024-01-31": {Income: 5, Expense: 5},
+	}}
+	uc := NewReportUsecase(fetcher, nil, nil, nil)
+	accts := []domain.Account{{ID: "a", Quote: "USDT"}, {ID: "b", Quote: "USDT"}, {ID: "c", Quote: "USDC"}}
+
+	got, err := uc.GetAggregateReport(context.Background(), accts, period("2024-01-01", "2024-01-31"))
+	require.NoError(t, err)
+	require.Equal(t, []domain.Report{
+		{Account: domain.AllAccounts, Currency: "USDC", From: "2024-01-01", To: "2024-01-31", Income: 5, Expense: 5},
+		{Account: domain.AllAccounts, Currency: "USDT", From: "2024-01-01", To: "2024-01-31", Income: 30, Expense: 3},
+	}, got)
+
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	got, err = uc.GetAggregateReport(context.Background(), accts, domain.Period{From: "2024-01-01", To: "2024-01-31", Location: kyiv})
+	require.NoError(t, err)
+	for _, r := range got {
+		require.Equal(t, "Europe/Kyiv", r.TimeZone, r.Currency)
+	}
+
+	_, err = uc.GetAggregateReport(context.Background(), append(accts, domain.Account{ID: "d"}), period("2024-01-01", "2024-01-31"))
+	require.ErrorContains(t, err, "account d")
+}
+
+type mockBreakdownFetcher struct {
+	result *ports.BreakdownResult
+	err    error
+}
+
+func (m *mockBreakdownFetcher) FetchBreakdown(_ context.Context, _ string, _, _ time.Time) (*ports.BreakdownResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.result, nil
+}
+
+func TestGetBreakdown(t *testing.T) {
+	t.Run("sorted by pnl", func(t *testing.T) {
+		uc := NewReportUsecase(nil, nil, &mockBreakdownFetcher{result: &ports.BreakdownResult{
+			Symbols: []ports.PnLGroupResult{
+				{Name: "ETHUSDT", PnL: -3, Trades: 2},
+				{Name: "BTCUSDT", PnL: 10, Trades: 4, Wins: 3},
+				{Name: "SOLUSDT", PnL: 10, Trades: 1, Wins: 1},
+			},
+			Strategies: []ports.PnLGroupResult{{Name: "grid", PnL: 1}, {Name: "breakout", PnL: 6}},
+		}}, nil)
+
+		got, err := uc.GetBreakdown(context.Background(), domain.Account{ID: "main"}, period("2024-01-01", "2024-01-31"))
+		require.NoError(t, err)
+		require.Equal(t, "main", got.Account)
+		require.Equal(t, []string{"BTCUSDT", "SOLUSDT", "ETHUSDT"}, groupNames(got.Symbols))
+		require.Equal(t, []string{"breakout", "grid"}, groupNames(got.Strategies))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		uc := NewReportUsecase(nil, nil, &mockBreakdownFetcher{result: &ports.BreakdownResult{}}, nil)
+		_, err := uc.GetBreakdown(context.Background(), domain.Account{}, period("2024-01-01", "2024-01-31"))
+		require.ErrorIs(t, err, ErrNoData)
+	})
+
+	t.Run("fetch error", func(t *testing.T) {
+		uc := NewReportUsecase(nil, nil, &mockBreakdownFetcher{err: errors.New("boom")}, nil)
+		_, err := uc.GetBreakdown(context.Background(), domain.Account{}, period("2024-01-01", "2024-01-31"))
+		require.ErrorContains(t, err, "fetch breakdown: boom")
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		uc := NewReportUsecase(nil, nil, &mockBreakdownFetcher{}, nil)
+		_, err := uc.GetBreakdown(context.Background(), domain.Account{}, period("bad", "2024-01-31"))
+		require.ErrorContains(t, err, "invalid from date")
+	})
+}
+
+func groupNames(groups []domain.PnLGroup) []string {
+	out := make([]string, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, g.Name)
+	}
+	return out
+}
+
+func TestGetAggregateReport_exactSums(t *testing.T) {
+	fetcher := &rangeFetcher{results: map[string]*ports.ReportResult{
+		"a@2024-01-01..2024-01-31": {Income: 0.1},
+		"b@2024-01-01..2024-01-31": {Income: 0.2},
+	}}
+	uc := NewReportUsecase(fetcher, nil, nil, nil)
+
+	got, err := uc.GetAggregateReport(context.Background(), []domain.Account{{ID: "a"}, {ID: "b"}}, period("2024-01-01", "2024-01-31"))
+	require.NoError(t, err)
+	require.Equal(t, 0.3, got[0].Income)
+}
+
+func period(from, to string) domain.Period {
+	return domain.Period{From: from, To: to}
+}
+
+type boundsFetcher struct {
+	from, to time.Time
+}
+
+func (m *boundsFetcher) FetchReport(_ context.Context, _ string, from, to time.Time) (*ports.ReportResult, error) {
+	m.from, m.to = from, to
+	return &ports.ReportResult{}, nil
+}
+
+func TestGetReport_timeZone(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	fetcher := &boundsFetcher{}
+	uc := NewReportUsecase(fetcher, nil, nil, nil)
+
+	// The range spans the spring DST switch (UTC+2 → UTC+3 on 2024-03-31).
+	got, err := uc.GetReport(context.Background(), domain.Account{}, domain.Period{From: "2024-03-30", To: "2024-03-31", Location: kyiv})
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Kyiv", got.TimeZone)
+	require.Equal(t, time.Date(2024, 3, 29, 22, 0, 0, 0, time.UTC), fetcher.from.UTC())
+	require.Equal(t, time.Date(2024, 3, 31, 21, 0, 0, 0, time.UTC), fetcher.to.UTC())
+	require.Equal(t, kyiv, fetcher.from.Location())
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"math"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+)
+
+// periodsPerYear annualizes daily ratios; crypto markets trade every day.
+const periodsPerYear = 365
+
+// GetRisk computes risk metrics from the daily PnL series of acct for the period.
+func (r *ReportUsecase) GetRisk(ctx context.Context, acct domain.Account, p domain.Period) (*domain.RiskMetrics, error) {
+	series, err := r.GetDailyPnL(ctx, acct, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, ErrNoData
+	}
+	pnl := make([]float64, 0, len(series))
+	for _, d := range series {
+		pnl = append(pnl, d.PnL)
+	}
+	m := RiskMetricsOf(pnl)
+	m.Account, m.Currency, m.TimeZone, m.From, m.To = acct.ID, acct.Quote, p.Zone(), p.From, p.To
+	return &m, nil
+}
+
+// RiskMetricsOf computes every risk metric of a daily PnL series.
+func RiskMetricsOf(pnl []float64) domain.RiskMetrics {
+	dd, ddPct := MaxDrawdown(pnl)
+	win, loss := AverageWinLoss(pnl)
+	return domain.RiskMetrics{
+		Days:                len(pnl),
+		MaxDrawdown:         dd,
+		MaxDrawdownPct:      ddPct,
+		Sharpe:              SharpeRatio(pnl),
+		Sortino:             SortinoRatio(pnl),
+		ProfitFactor:        ProfitFactor(pnl),
+		AvgWin:              win,
+		AvgLoss:             loss,
+		LongestLosingStreak: LongestLosingStreak(pnl),
+	}
+}
+
+// MaxDrawdown returns the largest fall of cumulative PnL from a running peak (starting at zero)
+// and that fall as a percentage of the peak, NaN when the peak is not positive.
+func MaxDrawdown(pnl []float64) (abs, pct float64) {
+	var sum domain.Amount
+	var peak float64
+	pct = math.NaN()
+	for _, v := range pnl {
+		sum += domain.AmountOf(v)
+		cum := sum.Float64()
+		peak = max(peak, cum)
+		if dd := peak - cum; dd > abs {
+			abs = dd
+			pct = math.NaN()
+			if peak > 0 {
+				pct = dd / peak * 100
+			}
+		}
+	}
+	if abs == 0 {
+		pct = 0
+	}
+	return abs, pct
+}
+
+// SharpeRatio returns the annualized mean over sample standard deviation of daily PnL;
+// NaN for fewer than two days or zero volatility.
+func SharpeRatio(pnl []float64) float64 {
+	if len(pnl) < 2 {
+		return math.NaN()
+	}
+	m := mean(pnl)
+	var ss float64
+	for _, v := range pnl {
+		ss += (v - m) * (v - m)
+	}
+	sd := math.Sqrt(ss / float64(len(pnl)-1))
+	if sd == 0 {
+		return math.NaN()
+	}
+	return m / sd * math.Sqrt(periodsPerYear)
+}
+
+// SortinoRatio returns the annualized mean over downside deviation of daily PnL;
+// NaN for fewer than two days or no losing day.
+func SortinoRatio(pnl []float64) float64 {
+	if len(pnl) < 2 {
+		return math.NaN()
+	}
+	var ss float64
+	for _, v := range pnl {
+		if v < 0 {
+			ss += v * v
+		}
+	}
+	dd := math.Sqrt(ss / float64(len(pnl)))
+	if dd == 0 {
+		return math.NaN()
+	}
+	return mean(pnl) / dd * math.Sqrt(periodsPerYear)
+}
+
+// ProfitFactor returns gross profit over gross loss: +Inf without losses, NaN without either.
+func ProfitFactor(pnl []float64) float64 {
+	var profit, loss float64
+	for _, v := range pnl {
+		if v > 0 {
+			profit += v
+		} else {
+			loss -= v
+		}
+	}
+	switch {
+	case loss > 0:
+		return profit / loss
+	case profit > 0:
+		return math.Inf(1)
+	default:
+		return math.NaN()
+	}
+}
+
+// AverageWinLoss returns the mean of winning days and the (negative) mean of losing days;
+// each is zero when there is no such day.
+func AverageWinLoss(pnl []float64) (win, loss float64) {
+	var wins, losses int
+	for _, v := range pnl {
+		switch {
+		case v > 0:
+			win += v
+			wins++
+		case v < 0:
+			loss += v
+			losses++
+		}
+	}
+	if wins > 0 {
+		win /= float64(wins)
+	}
+	if losses > 0 {
+		loss /= float64(losses)
+	}
+	return win, loss
+}
+
+// LongestLosingStreak returns the longest run of consecutive losing days.
+func LongestLosingStreak(pnl []float64) int {
+	var cur, best int
+	for _, v := range pnl {
+		if v < 0 {
+			cur++
+			best = max(best, cur)
+		} else {
+			cur = 0
+		}
+	}
+	return best
+}
+
+func mean(xs []float64) float64 {
+	var s float64
+	for _, v := range xs {
+		s += v
+	}
+	return s / float64(len(xs))
+}
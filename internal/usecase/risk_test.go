@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+	"github.com/stretchr/testify/require"
+)
+
+var nan = math.NaN()
+
+func TestRiskMetricsOf(t *testing.T) {
+	tests := []struct {
+		name string
+		pnl  []float64
+		want domain.RiskMetrics
+	}{
+		{
+			name: "mixed",
+			pnl:  []float64{10, -5, -5, 20, -10},
+			want: domain.RiskMetrics{
+				Days: 5, MaxDrawdown: 10, MaxDrawdownPct: 100,
+				Sharpe: 3.0446, Sortino: 6.9761, ProfitFactor: 1.5,
+				AvgWin: 15, AvgLoss: -6.6667, LongestLosingStreak: 2,
+			},
+		},
+		{
+			name: "all losing",
+			pnl:  []float64{-2, -4},
+			want: domain.RiskMetrics{
+				Days: 2, MaxDrawdown: 6, MaxDrawdownPct: nan,
+				Sharpe: -40.5278, Sortino: -18.1246, ProfitFactor: 0,
+				AvgLoss: -3, LongestLosingStreak: 2,
+			},
+		},
+		{
+			name: "all winning",
+			pnl:  []float64{3, 1},
+			want: domain.RiskMetrics{
+				Days: 2, Sharpe: 27.0185, Sortino: nan, ProfitFactor: math.Inf(1), AvgWin: 2,
+			},
+		},
+		{
+			name: "constant",
+			pnl:  []float64{5, 5, 5},
+			want: domain.RiskMetrics{
+				Days: 3, Sharpe: nan, Sortino: nan, ProfitFactor: math.Inf(1), AvgWin: 5,
+			},
+		},
+		{
+			name: "flat",
+			pnl:  []float64{0, 0},
+			want: domain.RiskMetrics{Days: 2, Sharpe: nan, Sortino: nan, ProfitFactor: nan},
+		},
+		{
+			name: "single losing day",
+			pnl:  []float64{-3},
+			want: domain.RiskMetrics{
+				Days: 1, MaxDrawdown: 3, MaxDrawdownPct: nan,
+				Sharpe: nan, Sortino: nan, AvgLoss: -3, LongestLosingStreak: 1,
+			},
+		},
+		{
+			name: "empty",
+			pnl:  nil,
+			want: domain.RiskMetrics{Sharpe: nan, Sortino: nan, ProfitFactor: nan},
+		},
+		{
+			name: "flat day ends streak",
+			pnl:  []float64{-1, -1, 0, -1, 4},
+			want: domain.RiskMetrics{
+				Days: 5, MaxDrawdown: 3, MaxDrawdownPct: nan,
+				Sharpe: 1.7625, Sortino: 4.9329, ProfitFactor: 4.0 / 3,
+				AvgWin: 4, AvgLoss: -1, LongestLosingStreak: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RiskMetricsOf(tt.pnl)
+			require.Equal(t, tt.want.Days, got.Days)
+			requireFloat(t, "max drawdown", tt.want.MaxDrawdown, got.MaxDrawdown)
+			requireFloat(t, "max drawdown %", tt.want.MaxDrawdownPct, got.MaxDrawdownPct)
+			requireFloat(t, "sharpe", tt.want.Sharpe, got.Sharpe)
+			requireFloat(t, "sortino", tt.want.Sortino, got.Sortino)
+			requireFloat(t, "profit factor", tt.want.ProfitFactor, got.ProfitFactor)
+			requireFloat(t, "avg win", tt.want.AvgWin, got.AvgWin)
+			requireFloat(t, "avg loss", tt.want.AvgLoss, got.AvgLoss)
+			require.Equal(t, tt.want.LongestLosingStreak, got.LongestLosingStreak)
+		})
+	}
+}
+
+func TestMaxDrawdown_recoveredPeak(t *testing.T) {
+	// The deepest fall (from 40 to 10) is measured against its own peak, not the first one.
+	abs, pct := MaxDrawdown([]float64{20, -10, 30, -30, 5})
+	require.InDelta(t, 30, abs, 1e-9)
+	require.InDelta(t, 75, pct, 1e-9)
+}
+
+func TestGetRisk(t *testing.T) {
+	series := &mockSeriesFetcher{points: []ports.PnLPoint{{Date: "2024-01-01", PnL: 10}, {Date: "2024-01-02", PnL: -5}}}
+	uc := NewReportUsecase(nil, series, nil, nil)
+
+	got, err := uc.GetRisk(context.Background(), domain.Account{ID: "main"}, period("2024-01-01", "2024-01-02"))
+	require.NoError(t, err)
+	require.Equal(t, "main", got.Account)
+	require.Equal(t, 2, got.Days)
+	require.InDelta(t, 5, got.MaxDrawdown, 1e-9)
+
+	_, err = NewReportUsecase(nil, &mockSeriesFetcher{}, nil, nil).GetRisk(context.Background(), domain.Account{}, period("2024-01-01", "2024-01-02"))
+	require.ErrorIs(t, err, ErrNoData)
+}
+
+func requireFloat(t *testing.T, name string, want, got float64) {
+	t.Helper()
+	switch {
+	case math.IsNaN(want):
+		require.Truef(t, math.IsNaN(got), "%s: want NaN, got %v", name, got)
+	case math.IsInf(want, 0):
+		require.Equalf(t, want, got, name)
+	default:
+		require.InDeltaf(t, want, got, 1e-3, name)
+	}
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/ports"
+)
+
+// SignalUsecase computes statistics over the ledger of published signals.
+type SignalUsecase struct {
+	signals ports.SignalReader
+}
+
+// NewSignalUsecase returns a use case reading the signal ledger from signals.
+func NewSignalUsecase(signals ports.SignalReader) *SignalUsecase {
+	return &SignalUsecase{signals: signals}
+}
+
+// Stats summarizes the signals published within p, or at any time when p is nil, optionally
+// only those of strategy. No matching signals yield ErrNoData.
+func (s *SignalUsecase) Stats(p *domain.Period, strategy string) (*domain.SignalStats, error) {
+	recs, err := s.signals.Signals()
+	if err != nil {
+		return nil, fmt.Errorf("signal stats: %w", err)
+	}
+	if p != nil {
+		start, end, err := p.Bounds()
+		if err != nil {
+			return nil, err
+		}
+		recs = slices.DeleteFunc(recs, func(r domain.SignalRecord) bool {
+			return r.Signal.Time.Before(start) || !r.Signal.Time.Before(end)
+		})
+	}
+	st := SignalStatsOf(recs, strategy)
+	if st.Signals == 0 {
+		return nil, ErrNoData
+	}
+	if p != nil {
+		st.From, st.To, st.TimeZone = p.From, p.To, p.Zone()
+	}
+	return &st, nil
+}
+
+// SignalStatsOf summarizes recs, skipping signals of other strategies when strategy is set.
+func SignalStatsOf(recs []domain.SignalRecord, strategy string) domain.SignalStats {
+	st := domain.SignalStats{Strategy: strategy, AvgR: math.NaN()}
+	symbols := map[string]*domain.SymbolStats{}
+	var totalR float64
+	for _, r := range recs {
+		sig := r.Signal
+		if strategy != "" && sig.Strategy != strategy {
+			continue
+		}
+		st.Signals++
+		if r.Cancelled() {
+			st.Cancelled++
+			continue
+		}
+		for len(st.TakeProfits) < len(sig.TakeProfits) {
+			st.TakeProfits = append(st.TakeProfits, domain.Ratio{})
+		}
+		exit, resolved := r.Exit()
+		for i := range sig.TakeProfits {
+			hit := r.HitLevel(i + 1)
+			if !resolved && !hit {
+				continue
+			}
+			st.TakeProfits[i].Of++
+			if hit {
+				st.TakeProfits[i].Hit++
+			}
+		}
+		if !resolved {
+			st.Open++
+			continue
+		}
+		st.Resolved++
+		st.StopLoss.Of++
+		if r.StoppedOut() {
+			st.StopLoss.Hit++
+		}
+		rm := sig.RMultiple(exit)
+		totalR += rm
+		sym, ok := symbols[sig.Symbol]
+		if !ok {
+			sym = &domain.SymbolStats{Symbol: sig.Symbol}
+			symbols[sig.Symbol] = sym
+		}
+		sym.Resolved++
+		sym.TotalR += rm
+	}
+	if st.Resolved > 0 {
+		st.AvgR = totalR / float64(st.Resolved)
+	}
+	for _, sym := range symbols {
+		st.Symbols = append(st.Symbols, *sym)
+	}
+	slices.SortFunc(st.Symbols, func(a, b domain.SymbolStats) int {
+		if c := cmp.Compare(b.TotalR, a.TotalR); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Symbol, b.Symbol)
+	})
+	return st
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/antonhancharyk/crypto-knight-tg-bot/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSignals struct {
+	recs []domain.SignalRecord
+}
+
+func (m *mockSignals) Signal(id string) (domain.SignalRecord, bool, error) {
+	for _, r := range m.recs {
+		if r.Signal.ID == id {
+			return r, true, nil
+		}
+	}
+	return domain.SignalRecord{}, false, nil
+}
+
+func (m *mockSignals) Signals() ([]domain.SignalRecord, error) {
+	return append([]domain.SignalRecord(nil), m.recs...), nil
+}
+
+// ledgerRecord is a long signal entered at 100 with a stop at 95 (1R = 5) and the given events.
+func ledgerRecord(id, symbol, strategy string, at time.Time, tps []float64, events ...domain.SignalEvent) domain.SignalRecord {
+	return domain.SignalRecord{
+		Signal: domain.Signal{ID: id, Symbol: symbol, Side: domain.SideLong, Entry: 100, StopLoss: 95,
+			TakeProfits: tps, Strategy: strategy, Time: at},
+		Events: events,
+	}
+}
+
+func tp(level int, price float64) domain.SignalEvent {
+	return domain.SignalEvent{Type: domain.SignalTakeProfit, Level: level, Price: price}
+}
+
+func TestSignalStatsOf(t *testing.T) {
+	at := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	recs := []domain.SignalRecord{
+		// Both targets hit: +2R.
+		ledgerRecord("a", "BTCUSDT", "breakout", at, []float64{105, 110}, tp(1, 105), tp(2, 110)),
+		// First target, then stopped at break-even: 0R.
+		ledgerRecord("b", "ETHUSDT", "breakout", at, []float64{105, 110}, tp(1, 105),
+			domain.SignalEvent{Type: domain.SignalStopLoss, Price: 100}),
+		// Stopped out: -1R.
+		ledgerRecord("c", "ETHUSDT", "reversal", at, []float64{105}, domain.SignalEvent{Type: domain.SignalStopLoss, Price: 95}),
+		// Open after the first target: counts towards TP1 only.
+		ledgerRecord("d", "SOLUSDT", "breakout", at, []float64{105, 110}, tp(1, 105)),
+		// Open without events: not counted in any rate.
+		ledgerRecord("e", "SOLUSDT", "breakout", at, []float64{105, 110}),
+		// Cancelled: excluded from every rate.
+		ledgerRecord("f", "XRPUSDT", "breakout", at, []float64{105}, domain.SignalEvent{Type: domain.SignalCancelled}),
+	}
+
+	st := SignalStatsOf(recs, "")
+	require.Equal(t, 6, st.Signals)
+	require.Equal(t, 2, st.Open)
+	require.Equal(t, 1, st.Cancelled)
+	require.Equal(t, 3, st.Resolved)
+	require.Equal(t, []domain.Ratio{{Hit: 3, Of: 4}, {Hit: 1, Of: 2}}, st.TakeProfits)
+	require.Equal(t, domain.Ratio{Hit: 2, Of: 3}, st.StopLoss)
+	require.InDelta(t, 1.0/3, st.AvgR, 1e-9)
+	require.Equal(t, []domain.SymbolStats{
+		{Symbol: "BTCUSDT", Resolved: 1, TotalR: 2},
+		{Symbol: "ETHUSDT", Resolved: 2, TotalR: -1},
+	}, st.Symbols)
+
+	st = SignalStatsOf(recs, "reversal")
+	require.Equal(t, 1, st.Signals)
+	require.Equal(t, []domain.Ratio{{Hit: 0, Of: 1}}, st.TakeProfits)
+	require.InDelta(t, -1, st.AvgR, 1e-9)
+
+	st = SignalStatsOf(recs[3:5], "")
+	require.True(t, math.IsNaN(st.AvgR))
+	require.Empty(t, st.Symbols)
+}
+
+func TestSignalUsecase_Stats(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	store := &mockSignals{recs: []domain.SignalRecord{
+		ledgerRecord("a", "BTCUSDT", "", time.Date(2024, 3, 9, 22, 30, 0, 0, time.UTC), []float64{110}, tp(1, 110)),
+		ledgerRecord("b", "BTCUSDT", "", time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC), []float64{110}),
+	}}
+	uc := NewSignalUsecase(store)
+
+	// 22:30 UTC on March 9 is already March 10 in Kyiv.
+	st, err := uc.Stats(&domain.Period{From: "2024-03-10", To: "2024-03-10", Location: kyiv}, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, st.Signals)
+	require.Equal(t, "2024-03-10", st.From)
+	require.Equal(t, "Europe/Kyiv", st.TimeZone)
+	require.InDelta(t, 2, st.AvgR, 1e-9)
+
+	st, err = uc.Stats(nil, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, st.Signals)
+	require.Empty(t, st.From)
+
+	_, err = uc.Stats(&domain.Period{From: "2024-04-01", To: "2024-04-30"}, "")
+	require.ErrorIs(t, err, ErrNoData)
+	_, err = uc.Stats(nil, "scalp")
+	require.ErrorIs(t, err, ErrNoData)
+}